@@ -0,0 +1,370 @@
+// Package alerts evaluates rules over decoded bridge events to flag large or
+// suspicious transfers: amounts above a threshold, bursts of transfers to one
+// EVM address, transfers of assets recently onboarded or unpaused, and
+// transfers involving blocked EVM addresses.
+//
+// Rules are configured in YAML, see Config. Sliding windows are measured in
+// block time, so replaying history yields the same findings as running live,
+// and events must carry the timestamp of their block.
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/onflow/cadence"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Finding is a transfer matched by a rule
+type Finding struct {
+	Rule          string    `json:"rule"`
+	Kind          Kind      `json:"kind"`
+	Severity      Severity  `json:"severity"`
+	Time          time.Time `json:"time"`
+	BlockHeight   uint64    `json:"blockHeight"`
+	TransactionID string    `json:"transactionId"`
+	EventIndex    int       `json:"eventIndex"`
+	Type          string    `json:"type"`
+	Direction     Direction `json:"direction"`
+	// Recipient of a transfer to EVM, caller of a transfer from EVM
+	EVMAddress string `json:"evmAddress"`
+	Message    string `json:"message"`
+}
+
+// Returns an emit function writing findings to w as JSON lines
+func JSONLines(w io.Writer) func(Finding) {
+	encoder := json.NewEncoder(w)
+	return func(finding Finding) {
+		encoder.Encode(finding)
+	}
+}
+
+// transfer is a bridge transfer extracted from an event
+type transfer struct {
+	record     events.Record
+	time       time.Time
+	direction  Direction
+	cadence    string
+	evmAddress string
+	// EVM contract of the asset
+	evmContract string
+	// Amount of tokens as a UFix64 when bridged to EVM and in units of the
+	// ERC20 at evmContract when bridged from EVM, nil for NFTs
+	toEVMAmount   *cadence.UFix64
+	fromEVMAmount *big.Int
+}
+
+// Returns the EVM address of the transfer blocked by the rule, the
+// counterparty before the asset contract, or the empty string
+func (t transfer) blocked(blocked map[string]bool) string {
+	for _, address := range []string{t.evmAddress, t.evmContract} {
+		if blocked[address] {
+			return address
+		}
+	}
+	return ""
+}
+
+// Engine evaluates rules over bridge events applied in block order
+type Engine struct {
+	// Names the types of EVM-native assets onboarded, whose events carry the
+	// contract name only. Onboardings of EVM-native assets are ignored unless
+	// set
+	BridgeEnv bridge.Environment
+	// Resolves the decimals of tokens bridged from EVM for amount rules
+	// without decimals. Nil unless set
+	Decimals *Decimals
+
+	rules []Rule
+	emit  func(Finding)
+
+	// Latest block time seen
+	now time.Time
+	// Transfer times per rule and EVM address for burst rules
+	bursts map[string]map[string][]time.Time
+	// Onboarding and unpause times per type; the empty type stands for the
+	// whole bridge
+	onboarded map[string]time.Time
+	unpaused  map[string]time.Time
+	// Blocked addresses per rule and recent transfers for blocked address rules
+	blocked map[string]map[string]bool
+	recent  []transfer
+}
+
+// Returns an engine evaluating the rules of the config, passing findings to emit
+func NewEngine(config Config, emit func(Finding)) (*Engine, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	e := &Engine{
+		rules:     config.Rules,
+		emit:      emit,
+		bursts:    map[string]map[string][]time.Time{},
+		onboarded: map[string]time.Time{},
+		unpaused:  map[string]time.Time{},
+		blocked:   map[string]map[string]bool{},
+	}
+	for _, rule := range config.Rules {
+		if rule.Kind != KindBlockedAddress {
+			continue
+		}
+		e.blocked[rule.Name] = map[string]bool{}
+		for _, address := range rule.Addresses {
+			e.blocked[rule.Name][bridge.NormalizeEVMAddress(address)] = true
+		}
+	}
+	return e, nil
+}
+
+// Applies an event, emitting a finding for every rule matching it. Events
+// without block timestamp are rejected
+func (e *Engine) Apply(record events.Record) error {
+	return e.apply(context.Background(), record)
+}
+
+func (e *Engine) apply(ctx context.Context, record events.Record) error {
+	if record.BlockTimestamp.IsZero() {
+		return fmt.Errorf("Cannot apply event at height %d: no block timestamp to measure windows", record.BlockHeight)
+	}
+	if record.BlockTimestamp.After(e.now) {
+		e.now = record.BlockTimestamp
+	}
+	switch event := record.Event.(type) {
+	case events.Onboarded:
+		e.onboarded[event.Type] = e.now
+		return nil
+	case events.BridgeDefiningContractDeployed:
+		if e.BridgeEnv.FlowEVMBridgeAddress != "" {
+			e.onboarded[e.BridgeEnv.BridgeDefinedType(event.ContractName, event.IsERC721)] = e.now
+		}
+		return nil
+	case events.AssociationUpdated:
+		e.onboarded[event.Type] = e.now
+		return nil
+	case events.CustomAssociationEstablished:
+		e.onboarded[event.Type] = e.now
+		return nil
+	case events.AssetPauseStatusUpdated:
+		if !event.Paused {
+			e.unpaused[event.Type] = e.now
+		}
+		return nil
+	case events.BridgePauseStatusUpdated:
+		if !event.Paused {
+			e.unpaused[""] = e.now
+		}
+		return nil
+	}
+
+	t, ok := e.transferOf(record)
+	if !ok {
+		return nil
+	}
+	for _, rule := range e.rules {
+		if !rule.matches(t) {
+			continue
+		}
+		message, err := e.evaluate(ctx, rule, t)
+		if err != nil {
+			return fmt.Errorf("Cannot evaluate rule %s: %w", rule.Name, err)
+		}
+		if message != "" {
+			e.emit(newFinding(rule, t, message))
+		}
+	}
+	e.remember(t)
+	return nil
+}
+
+// Blocks an EVM address for every blocked address rule, emitting findings
+// for the transfers of assets at the address or with it as counterparty
+// within the window of each rule. Use it when an EVM contract is blocked with
+// block_evm_address.cdc, which emits no event
+func (e *Engine) Block(evmAddress string) {
+	evmAddress = bridge.NormalizeEVMAddress(evmAddress)
+	for _, rule := range e.rules {
+		if rule.Kind != KindBlockedAddress || e.blocked[rule.Name][evmAddress] {
+			continue
+		}
+		e.blocked[rule.Name][evmAddress] = true
+		for _, t := range e.recent {
+			if (t.evmAddress == evmAddress || t.evmContract == evmAddress) && rule.matches(t) && !t.time.Before(e.now.Add(-rule.Window)) {
+				e.emit(newFinding(rule, t, fmt.Sprintf("Transfer involving %s, blocked after the transfer", evmAddress)))
+			}
+		}
+	}
+}
+
+// Returns a consumer of every bridge event of the Environment, which applies
+// every event to the engine
+func (e *Engine) Consumer(source events.EventSource, env bridge.Environment, checkpoints events.CheckpointStore, startHeight uint64) *events.Consumer {
+	return events.ConsumerFor(e, events.TypeIDs(env), source, checkpoints, startHeight)
+}
+
+// Applies a record passed by an events.Consumer
+func (e *Engine) Handle(ctx context.Context, record events.Record) error {
+	return e.apply(ctx, record)
+}
+
+// Returns the message of a finding if the rule fires for the transfer
+func (e *Engine) evaluate(ctx context.Context, rule Rule, t transfer) (string, error) {
+	switch rule.Kind {
+	case KindAmount:
+		threshold, _ := cadence.NewUFix64(rule.Threshold)
+		amount, err := e.amount(ctx, rule, t)
+		if err != nil || amount == nil || *amount < threshold {
+			return "", err
+		}
+		return fmt.Sprintf("Transfer of %s at or above %s", amount, threshold), nil
+	case KindBurst:
+		times := e.bursts[rule.Name][t.evmAddress]
+		start := t.time.Add(-rule.Window)
+		for len(times) > 0 && !times[0].After(start) {
+			times = times[1:]
+		}
+		times = append(times, t.time)
+		if e.bursts[rule.Name] == nil {
+			e.bursts[rule.Name] = map[string][]time.Time{}
+		}
+		e.bursts[rule.Name][t.evmAddress] = times
+		// Fire when the count is reached, not on every following transfer
+		if len(times) != rule.Count {
+			return "", nil
+		}
+		return fmt.Sprintf("%d transfers involving %s within %s", len(times), t.evmAddress, rule.Window), nil
+	case KindNewlyOnboarded:
+		onboarded, ok := e.onboarded[t.cadence]
+		if !ok || t.time.Sub(onboarded) > rule.Window {
+			return "", nil
+		}
+		return fmt.Sprintf("Transfer %s after onboarding", t.time.Sub(onboarded)), nil
+	case KindRecentlyUnpaused:
+		unpaused, ok := e.unpaused[t.cadence]
+		if bridgeUnpaused, bridgeOK := e.unpaused[""]; bridgeOK && (!ok || bridgeUnpaused.After(unpaused)) {
+			unpaused, ok = bridgeUnpaused, true
+		}
+		if !ok || t.time.Sub(unpaused) > rule.Window {
+			return "", nil
+		}
+		return fmt.Sprintf("Transfer %s after unpause", t.time.Sub(unpaused)), nil
+	case KindBlockedAddress:
+		address := t.blocked(e.blocked[rule.Name])
+		if address == "" {
+			return "", nil
+		}
+		return fmt.Sprintf("Transfer involving blocked address %s", address), nil
+	}
+	return "", nil
+}
+
+// Keeps transfers within the longest blocked address window
+func (e *Engine) remember(t transfer) {
+	var window time.Duration
+	for _, rule := range e.rules {
+		if rule.Kind == KindBlockedAddress && rule.Window > window {
+			window = rule.Window
+		}
+	}
+	if window == 0 {
+		return
+	}
+	start := e.now.Add(-window)
+	for len(e.recent) > 0 && e.recent[0].time.Before(start) {
+		e.recent = e.recent[1:]
+	}
+	e.recent = append(e.recent, t)
+}
+
+func (e *Engine) transferOf(record events.Record) (transfer, bool) {
+	t := transfer{record: record, time: e.now}
+	switch event := record.Event.(type) {
+	case events.BridgedNFTToEVM:
+		t.direction, t.cadence, t.evmAddress, t.evmContract = DirectionToEVM, event.Type, event.To, event.EVMContractAddress
+	case events.BridgedNFTFromEVM:
+		t.direction, t.cadence, t.evmAddress, t.evmContract = DirectionFromEVM, event.Type, event.Caller, event.EVMContractAddress
+	case events.BridgedTokensToEVM:
+		amount := event.Amount
+		t.direction, t.cadence, t.evmAddress, t.evmContract = DirectionToEVM, event.Type, event.To, event.EVMContractAddress
+		t.toEVMAmount = &amount
+	case events.BridgedTokensFromEVM:
+		t.direction, t.cadence, t.evmAddress, t.evmContract = DirectionFromEVM, event.Type, event.Caller, event.EVMContractAddress
+		t.fromEVMAmount = event.Amount
+	default:
+		return t, false
+	}
+	t.evmAddress = bridge.NormalizeEVMAddress(t.evmAddress)
+	t.evmContract = bridge.NormalizeEVMAddress(t.evmContract)
+	return t, true
+}
+
+// Returns the amount of a token transfer as a UFix64, nil for NFTs. Amounts
+// bridged from EVM are converted with the decimals of the rule, or else of
+// the ERC20
+func (e *Engine) amount(ctx context.Context, rule Rule, t transfer) (*cadence.UFix64, error) {
+	if t.fromEVMAmount == nil {
+		return t.toEVMAmount, nil
+	}
+	var decimals uint8
+	switch {
+	case rule.Decimals != nil:
+		decimals = *rule.Decimals
+	case e.Decimals != nil:
+		var err error
+		if decimals, err = e.Decimals.Of(ctx, t.evmContract); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Cannot convert amount of %s bridged from EVM: rule has no decimals and engine no Decimals", t.cadence)
+	}
+	amount, err := bridge.ConvertERC20AmountToCadenceAmount(t.fromEVMAmount, decimals)
+	if err != nil {
+		return nil, err
+	}
+	return &amount, nil
+}
+
+func (r Rule) matches(t transfer) bool {
+	if r.Direction != DirectionAny && r.Direction != t.direction {
+		return false
+	}
+	if len(r.Types) == 0 {
+		return true
+	}
+	for _, cadenceType := range r.Types {
+		if cadenceType == t.cadence {
+			return true
+		}
+	}
+	return false
+}
+
+func newFinding(rule Rule, t transfer, message string) Finding {
+	return Finding{
+		Rule:          rule.Name,
+		Kind:          rule.Kind,
+		Severity:      rule.Severity,
+		Time:          t.time,
+		BlockHeight:   t.record.BlockHeight,
+		TransactionID: t.record.TransactionID.String(),
+		EventIndex:    t.record.EventIndex,
+		Type:          t.cadence,
+		Direction:     t.direction,
+		EVMAddress:    t.evmAddress,
+		Message:       message,
+	}
+}
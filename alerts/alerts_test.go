@@ -0,0 +1,309 @@
+package alerts_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/alerts"
+	"github.com/onflow/flow-evm-bridge/client"
+	"github.com/onflow/flow-evm-bridge/events"
+)
+
+const config = `
+rules:
+  - name: large-flow
+    kind: amount
+    severity: critical
+    types: [A.1654653399040a61.FlowToken.Vault]
+    threshold: "1000.0"
+  - name: burst
+    kind: burst
+    severity: warning
+    direction: to_evm
+    window: 10m
+    count: 3
+  - name: new-asset
+    kind: newly-onboarded
+    severity: info
+    window: 1h
+  - name: unpaused
+    kind: recently-unpaused
+    severity: warning
+    window: 30m
+  - name: blocked
+    kind: blocked-address
+    severity: critical
+    window: 24h
+    addresses: ["0x00000000000000000000000000000000000000AA"]
+`
+
+const (
+	flowToken    = "A.1654653399040a61.FlowToken.Vault"
+	exampleNFT   = "A.0ae53cb6e3f42a79.ExampleNFT.NFT"
+	alice        = "00000000000000000000000000000000000000a1"
+	blocked      = "00000000000000000000000000000000000000aa"
+	blockedLater = "00000000000000000000000000000000000000bb"
+	erc20        = "00000000000000000000000000000000000000e2"
+	erc721       = "00000000000000000000000000000000000000e7"
+)
+
+var start = time.Date(2024, 9, 4, 12, 0, 0, 0, time.UTC)
+
+type engine struct {
+	*alerts.Engine
+	findings []alerts.Finding
+	height   uint64
+}
+
+func newEngine(t *testing.T) *engine {
+	parsed, err := alerts.ParseConfig([]byte(config))
+	require.Nil(t, err)
+	e := &engine{}
+	e.Engine, err = alerts.NewEngine(parsed, func(finding alerts.Finding) {
+		e.findings = append(e.findings, finding)
+	})
+	require.Nil(t, err)
+	return e
+}
+
+func (e *engine) apply(t *testing.T, at time.Duration, event events.Event) []alerts.Finding {
+	e.height++
+	before := len(e.findings)
+	require.Nil(t, e.Apply(events.Record{Event: event, BlockHeight: e.height, BlockTimestamp: start.Add(at)}))
+	return e.findings[before:]
+}
+
+func rules(findings []alerts.Finding) []string {
+	names := []string{}
+	for _, finding := range findings {
+		names = append(names, finding.Rule)
+	}
+	return names
+}
+
+func ufix64(t *testing.T, value string) cadence.UFix64 {
+	amount, err := cadence.NewUFix64(value)
+	require.Nil(t, err)
+	return amount
+}
+
+func TestParseConfig(t *testing.T) {
+	parsed, err := alerts.ParseConfig([]byte(config))
+	require.Nil(t, err)
+	require.Len(t, parsed.Rules, 5)
+	assert.Equal(t, 10*time.Minute, parsed.Rules[1].Window)
+
+	_, err = alerts.ParseConfig([]byte(`
+rules:
+  - name: a
+    kind: amount
+    severity: critical
+    threshold: lots
+  - name: a
+    kind: unknown
+    severity: loud
+`))
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Invalid threshold")
+	assert.Contains(t, err.Error(), "Unknown severity")
+	assert.Contains(t, err.Error(), "Rule 2: duplicate name a")
+}
+
+func TestAmountRule(t *testing.T) {
+	e := newEngine(t)
+	assert.Empty(t, e.apply(t, 0, events.BridgedTokensToEVM{Type: flowToken, Amount: ufix64(t, "999.0"), To: alice}))
+	assert.Equal(t, []string{"large-flow"}, rules(e.apply(t, time.Hour, events.BridgedTokensToEVM{Type: flowToken, Amount: ufix64(t, "1000.0"), To: alice})))
+
+	// Amounts from EVM are converted with the decimals of the ERC20, resolved
+	// once per contract
+	fake := client.NewFake()
+	fake.OnScriptFunc("getTokenDecimals", func(arguments []cadence.Value) (cadence.Value, error) {
+		assert.Equal(t, cadence.String(erc20), arguments[0])
+		return cadence.UInt8(18), nil
+	})
+	env, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	e.Decimals = &alerts.Decimals{BridgeEnv: env, CoreEnv: coreEnv, Scripts: fake}
+	amount, _ := new(big.Int).SetString("2000000000000000000000", 10)
+	findings := e.apply(t, 2*time.Hour, events.BridgedTokensFromEVM{Type: flowToken, Amount: amount, Caller: alice, EVMContractAddress: "0x" + strings.ToUpper(erc20)})
+	require.Equal(t, []string{"large-flow"}, rules(findings))
+	assert.Equal(t, alerts.DirectionFromEVM, findings[0].Direction)
+	assert.Equal(t, alerts.SeverityCritical, findings[0].Severity)
+	amount, _ = new(big.Int).SetString("999000000000000000000", 10)
+	assert.Empty(t, e.apply(t, 3*time.Hour, events.BridgedTokensFromEVM{Type: flowToken, Amount: amount, Caller: alice, EVMContractAddress: erc20}))
+	assert.Len(t, fake.Calls(), 1)
+}
+
+func TestAmountRuleWithoutDecimals(t *testing.T) {
+	e := newEngine(t)
+	err := e.Apply(events.Record{
+		Event:          events.BridgedTokensFromEVM{Type: flowToken, Amount: big.NewInt(1), Caller: alice, EVMContractAddress: erc20},
+		BlockHeight:    1,
+		BlockTimestamp: start,
+	})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no decimals")
+
+	// Rules with decimals need no resolution
+	parsed, err := alerts.ParseConfig([]byte(`
+rules:
+  - name: large-erc20
+    kind: amount
+    severity: critical
+    threshold: "1.0"
+    decimals: 6
+`))
+	require.Nil(t, err)
+	findings := []alerts.Finding{}
+	engine, err := alerts.NewEngine(parsed, func(finding alerts.Finding) { findings = append(findings, finding) })
+	require.Nil(t, err)
+	require.Nil(t, engine.Apply(events.Record{
+		Event:          events.BridgedTokensFromEVM{Type: flowToken, Amount: big.NewInt(2_000_000), Caller: alice, EVMContractAddress: erc20},
+		BlockHeight:    1,
+		BlockTimestamp: start,
+	}))
+	assert.Equal(t, []string{"large-erc20"}, rules(findings))
+}
+
+func TestEventWithoutBlockTimestamp(t *testing.T) {
+	e := newEngine(t)
+	err := e.Apply(events.Record{Event: events.BridgedTokensToEVM{Type: flowToken, Amount: ufix64(t, "1.0"), To: alice}, BlockHeight: 7})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no block timestamp")
+}
+
+func TestEngineConsumer(t *testing.T) {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	address, err := common.HexToAddress(env.FlowEVMBridgeAddress)
+	require.Nil(t, err)
+	location := common.NewAddressLocation(nil, address, "IFlowEVMTokenBridge")
+	event := cadence.NewEvent([]cadence.Value{
+		cadence.String(flowToken),
+		ufix64(t, "1000.0"),
+		cadence.UInt64(1),
+		cadence.String(alice),
+		cadence.String("0000000000000000000000000000000000000f10"),
+		cadence.NewAddress(flow.HexToAddress(env.FlowEVMBridgeAddress)),
+	}).WithType(cadence.NewEventType(location, events.TypeBridgedTokensToEVM, []cadence.Field{
+		{Identifier: "type", Type: cadence.StringType},
+		{Identifier: "amount", Type: cadence.UFix64Type},
+		{Identifier: "bridgedUUID", Type: cadence.UInt64Type},
+		{Identifier: "to", Type: cadence.StringType},
+		{Identifier: "evmContractAddress", Type: cadence.StringType},
+		{Identifier: "bridgeAddress", Type: cadence.AddressType},
+	}, nil))
+	source := events.NewMemorySource()
+	source.Add(3, flow.Event{Type: event.EventType.ID(), TransactionID: flow.Identifier{1}, Value: event})
+	block := source.Blocks[3]
+	block.BlockTimestamp = start
+	source.Blocks[3] = block
+
+	e := newEngine(t)
+	checkpoints := &events.MemoryCheckpointStore{}
+	_, err = e.Consumer(source, env, checkpoints, 1).Sync(context.Background())
+	require.Nil(t, err)
+	// Resuming from the checkpoint evaluates no event twice
+	_, err = e.Consumer(source, env, checkpoints, 1).Sync(context.Background())
+	require.Nil(t, err)
+	require.Equal(t, []string{"large-flow"}, rules(e.findings))
+	assert.Equal(t, uint64(3), e.findings[0].BlockHeight)
+}
+
+func TestBurstRule(t *testing.T) {
+	e := newEngine(t)
+	nft := func(id uint64) events.Event {
+		return events.BridgedNFTToEVM{Type: exampleNFT, ID: id, EVMID: new(big.Int).SetUint64(id), To: alice}
+	}
+	assert.Empty(t, e.apply(t, 0, nft(1)))
+	assert.Empty(t, e.apply(t, 5*time.Minute, nft(2)))
+	// The first transfer left the window
+	assert.Empty(t, e.apply(t, 11*time.Minute, nft(3)))
+	findings := e.apply(t, 12*time.Minute, nft(4))
+	require.Equal(t, []string{"burst"}, rules(findings))
+	assert.Equal(t, alice, findings[0].EVMAddress)
+	// Fires once per burst
+	assert.Empty(t, e.apply(t, 13*time.Minute, nft(5)))
+}
+
+func TestOnboardedAndUnpausedRules(t *testing.T) {
+	e := newEngine(t)
+	transfer := events.BridgedNFTToEVM{Type: exampleNFT, EVMID: big.NewInt(1), To: alice}
+	e.apply(t, 0, events.Onboarded{Type: exampleNFT})
+	assert.Equal(t, []string{"new-asset"}, rules(e.apply(t, 30*time.Minute, transfer)))
+	assert.Empty(t, e.apply(t, 2*time.Hour, transfer))
+
+	e.apply(t, 3*time.Hour, events.AssetPauseStatusUpdated{Paused: false, Type: exampleNFT})
+	assert.Equal(t, []string{"unpaused"}, rules(e.apply(t, 3*time.Hour+time.Minute, transfer)))
+
+	e.apply(t, 5*time.Hour, events.BridgePauseStatusUpdated{Paused: false})
+	assert.Equal(t, []string{"unpaused"}, rules(e.apply(t, 5*time.Hour+time.Minute, transfer)))
+	assert.Empty(t, e.apply(t, 6*time.Hour, transfer))
+}
+
+func TestAssociationsAreOnboardings(t *testing.T) {
+	bridgeEnv, _, err := bridge.NetworkEnvironment("mainnet")
+	require.Nil(t, err)
+	bridgedNFT := bridgeEnv.BridgeDefinedType("EVMVMBridgedNFT_"+erc721, true)
+	customNFT := "A.0ae53cb6e3f42a79.CustomNFT.NFT"
+	updatedNFT := "A.0ae53cb6e3f42a79.UpdatedNFT.NFT"
+	transfer := func(cadenceType string) events.Event {
+		return events.BridgedNFTFromEVM{Type: cadenceType, EVMID: big.NewInt(1), Caller: alice}
+	}
+
+	// EVM-native assets are named by the Environment
+	e := newEngine(t)
+	e.apply(t, 0, events.BridgeDefiningContractDeployed{ContractName: "EVMVMBridgedNFT_" + erc721, IsERC721: true, EVMContractAddress: erc721})
+	assert.Empty(t, e.apply(t, time.Minute, transfer(bridgedNFT)))
+
+	e = newEngine(t)
+	e.BridgeEnv = bridgeEnv
+	e.apply(t, 0, events.BridgeDefiningContractDeployed{ContractName: "EVMVMBridgedNFT_" + erc721, IsERC721: true, EVMContractAddress: erc721})
+	e.apply(t, 0, events.CustomAssociationEstablished{Type: customNFT, EVMContractAddress: erc721})
+	e.apply(t, 0, events.AssociationUpdated{Type: updatedNFT, EVMAddress: erc721})
+	for _, cadenceType := range []string{bridgedNFT, customNFT, updatedNFT} {
+		assert.Equal(t, []string{"new-asset"}, rules(e.apply(t, time.Minute, transfer(cadenceType))), cadenceType)
+	}
+}
+
+func TestBlockedAddressRule(t *testing.T) {
+	e := newEngine(t)
+	// Blocked contract of the asset
+	findings := e.apply(t, 0, events.BridgedTokensToEVM{Type: flowToken, Amount: ufix64(t, "1.0"), To: alice, EVMContractAddress: blocked})
+	require.Equal(t, []string{"blocked"}, rules(findings))
+	assert.Contains(t, findings[0].Message, blocked)
+	// Blocked counterparty
+	assert.Equal(t, []string{"blocked"}, rules(e.apply(t, 0, events.BridgedNFTToEVM{Type: exampleNFT, EVMID: big.NewInt(1), To: blocked, EVMContractAddress: erc721})))
+
+	assert.Empty(t, e.apply(t, time.Hour, events.BridgedNFTFromEVM{Type: exampleNFT, EVMID: big.NewInt(2), Caller: alice, EVMContractAddress: blockedLater}))
+	e.apply(t, 2*time.Hour, events.BridgedNFTToEVM{Type: exampleNFT, EVMID: big.NewInt(3), To: alice, EVMContractAddress: erc721})
+
+	// Blocking the contract flags the earlier transfer of its asset
+	before := len(e.findings)
+	e.Block("0x" + strings.ToUpper(blockedLater))
+	findings = e.findings[before:]
+	require.Equal(t, []string{"blocked"}, rules(findings))
+	assert.Equal(t, uint64(3), findings[0].BlockHeight)
+	assert.Contains(t, findings[0].Message, "blocked after")
+}
+
+func TestJSONLines(t *testing.T) {
+	var output bytes.Buffer
+	alerts.JSONLines(&output)(alerts.Finding{Rule: "burst", Kind: alerts.KindBurst, Severity: alerts.SeverityWarning})
+	var finding map[string]any
+	require.Nil(t, json.Unmarshal(output.Bytes(), &finding))
+	assert.Equal(t, "burst", finding["rule"])
+	assert.Equal(t, "warning", finding["severity"])
+}
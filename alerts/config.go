@@ -0,0 +1,137 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/onflow/cadence"
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is the kind of a rule
+type Kind string
+
+const (
+	// Transfers of fungible tokens of at least Threshold
+	KindAmount Kind = "amount"
+	// At least Count transfers to the same EVM address within Window
+	KindBurst Kind = "burst"
+	// Transfers of an asset within Window after it was onboarded or
+	// associated with an EVM contract
+	KindNewlyOnboarded Kind = "newly-onboarded"
+	// Transfers of an asset within Window after it or the bridge was unpaused
+	KindRecentlyUnpaused Kind = "recently-unpaused"
+	// Transfers of an asset at a blocked EVM contract address or with a
+	// blocked counterparty, including transfers within Window before the
+	// address was blocked
+	KindBlockedAddress Kind = "blocked-address"
+)
+
+// Direction filters transfers of a rule
+type Direction string
+
+const (
+	DirectionToEVM   Direction = "to_evm"
+	DirectionFromEVM Direction = "from_evm"
+	// Both directions, the default
+	DirectionAny Direction = ""
+)
+
+// Rule is a configured rule
+type Rule struct {
+	Name     string   `yaml:"name"`
+	Kind     Kind     `yaml:"kind"`
+	Severity Severity `yaml:"severity"`
+	// Cadence types the rule applies to, all types if empty
+	Types     []string  `yaml:"types"`
+	Direction Direction `yaml:"direction"`
+	// Amount threshold as a UFix64, e.g. "10000.0"
+	Threshold string `yaml:"threshold"`
+	// Decimals of the ERC20, used to compare amounts bridged from EVM, which
+	// are denominated in the ERC20's smallest unit. Resolved by the Decimals
+	// of the Engine per ERC20 if unset
+	Decimals *uint8 `yaml:"decimals"`
+	// Sliding window, e.g. "10m"
+	Window time.Duration `yaml:"window"`
+	Count  int           `yaml:"count"`
+	// Blocked EVM addresses
+	Addresses []string `yaml:"addresses"`
+}
+
+// Config is the YAML configuration of an Engine
+type Config struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Reads and validates a YAML configuration file
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return ParseConfig(data)
+}
+
+// Parses and validates a YAML configuration
+func ParseConfig(data []byte) (Config, error) {
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, err
+	}
+	return config, config.Validate()
+}
+
+// Returns an error for every invalid rule
+func (c Config) Validate() error {
+	errs := []error{}
+	names := map[string]bool{}
+	for i, rule := range c.Rules {
+		if err := rule.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("Rule %d (%s): %w", i+1, rule.Name, err))
+		}
+		if names[rule.Name] {
+			errs = append(errs, fmt.Errorf("Rule %d: duplicate name %s", i+1, rule.Name))
+		}
+		names[rule.Name] = true
+	}
+	return errors.Join(errs...)
+}
+
+func (r Rule) validate() error {
+	if r.Name == "" {
+		return errors.New("Missing name")
+	}
+	switch r.Severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return fmt.Errorf("Unknown severity %q", r.Severity)
+	}
+	switch r.Direction {
+	case DirectionAny, DirectionToEVM, DirectionFromEVM:
+	default:
+		return fmt.Errorf("Unknown direction %q", r.Direction)
+	}
+	switch r.Kind {
+	case KindAmount:
+		if _, err := cadence.NewUFix64(r.Threshold); err != nil {
+			return fmt.Errorf("Invalid threshold %q: %w", r.Threshold, err)
+		}
+	case KindBurst:
+		if r.Window <= 0 || r.Count <= 0 {
+			return errors.New("Window and count must be positive")
+		}
+	case KindNewlyOnboarded, KindRecentlyUnpaused:
+		if r.Window <= 0 {
+			return errors.New("Window must be positive")
+		}
+	case KindBlockedAddress:
+		if r.Window < 0 {
+			return errors.New("Window must not be negative")
+		}
+	default:
+		return fmt.Errorf("Unknown kind %q", r.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onflow/cadence"
+	coreContracts "github.com/onflow/flow-core-contracts/lib/go/templates"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/results"
+)
+
+const pathGetTokenDecimals = "cadence/scripts/utils/get_token_decimals.cdc"
+
+// ScriptExecutor executes a script and returns its result
+type ScriptExecutor interface {
+	ExecuteScript(ctx context.Context, code []byte, arguments []cadence.Value) (cadence.Value, error)
+}
+
+// Decimals resolves the decimals of ERC20s with get_token_decimals.cdc,
+// caching them by EVM contract. It is not safe for concurrent use
+type Decimals struct {
+	BridgeEnv bridge.Environment
+	CoreEnv   coreContracts.Environment
+	Scripts   ScriptExecutor
+
+	cache map[string]uint8
+}
+
+// Returns the decimals of the ERC20 at the EVM address
+func (d *Decimals) Of(ctx context.Context, evmAddress string) (uint8, error) {
+	evmAddress = bridge.NormalizeEVMAddress(evmAddress)
+	if decimals, ok := d.cache[evmAddress]; ok {
+		return decimals, nil
+	}
+	code, err := bridge.GetCadenceScriptCode(pathGetTokenDecimals, d.BridgeEnv, d.CoreEnv)
+	if err != nil {
+		return 0, err
+	}
+	value, err := d.Scripts.ExecuteScript(ctx, code, []cadence.Value{cadence.String(evmAddress)})
+	if err != nil {
+		return 0, fmt.Errorf("Cannot get decimals of %s: %w", evmAddress, err)
+	}
+	decimals, err := results.UInt8(value)
+	if err != nil {
+		return 0, fmt.Errorf("Cannot get decimals of %s: %w", evmAddress, err)
+	}
+	if d.cache == nil {
+		d.cache = map[string]uint8{}
+	}
+	d.cache[evmAddress] = decimals
+	return decimals, nil
+}
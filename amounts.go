@@ -0,0 +1,41 @@
+package bridge
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/onflow/cadence"
+)
+
+// Number of decimals of UFix64 amounts in Cadence
+const UFix64Decimals = 8
+
+// Converts an amount of an ERC20 with the given decimals to a UFix64 amount,
+// truncating digits beyond 8 decimals like
+// FlowEVMBridgeUtils.convertERC20AmountToCadenceAmount
+func ConvertERC20AmountToCadenceAmount(amount *big.Int, decimals uint8) (cadence.UFix64, error) {
+	var units *big.Int
+	if decimals >= UFix64Decimals {
+		units = new(big.Int).Quo(amount, pow10(decimals-UFix64Decimals))
+	} else {
+		units = new(big.Int).Mul(amount, pow10(UFix64Decimals-decimals))
+	}
+	if units.Sign() < 0 || !units.IsUint64() {
+		return 0, errors.New("Amount " + amount.String() + " does not fit in UFix64")
+	}
+	return cadence.UFix64(units.Uint64()), nil
+}
+
+// Converts a UFix64 amount to an amount of an ERC20 with the given decimals
+// like FlowEVMBridgeUtils.convertCadenceAmountToERC20Amount
+func ConvertCadenceAmountToERC20Amount(amount cadence.UFix64, decimals uint8) *big.Int {
+	units := new(big.Int).SetUint64(uint64(amount))
+	if decimals >= UFix64Decimals {
+		return units.Mul(units, pow10(decimals-UFix64Decimals))
+	}
+	return units.Quo(units, pow10(UFix64Decimals-decimals))
+}
+
+func pow10(exponent uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+}
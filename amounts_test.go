@@ -0,0 +1,34 @@
+package bridge_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+)
+
+func TestConvertAmounts(t *testing.T) {
+	amount, err := cadence.NewUFix64("1.5")
+	require.Nil(t, err)
+
+	erc20Amount := bridge.ConvertCadenceAmountToERC20Amount(amount, 18)
+	assert.Equal(t, "1500000000000000000", erc20Amount.String())
+	assert.Equal(t, "150", bridge.ConvertCadenceAmountToERC20Amount(amount, 2).String())
+
+	// Digits beyond 8 decimals are truncated
+	converted, err := bridge.ConvertERC20AmountToCadenceAmount(big.NewInt(0).Add(erc20Amount, big.NewInt(1)), 18)
+	require.Nil(t, err)
+	assert.Equal(t, amount, converted)
+
+	converted, err = bridge.ConvertERC20AmountToCadenceAmount(big.NewInt(150), 2)
+	require.Nil(t, err)
+	assert.Equal(t, amount, converted)
+
+	tooLarge, _ := new(big.Int).SetString("1000000000000000000000000000000", 10)
+	_, err = bridge.ConvertERC20AmountToCadenceAmount(tooLarge, 6)
+	assert.NotNil(t, err)
+}
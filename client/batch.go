@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/onflow/flow-go-sdk"
+
+	"github.com/onflow/flow-evm-bridge/txerrors"
+)
+
+// Computation an NFT is assumed to need in a batch bridge transaction when
+// Batching does not set it. It covers NFTs resolving several metadata views
+const DefaultComputationPerNFT = 250
+
+// Share of the compute limit batches are sized for, leaving room for the
+// fixed cost of the transaction and onboarding
+const batchComputeShare = 0.8
+
+// Batching configures how BridgeNFTsInBatches splits NFTs into transactions
+type Batching struct {
+	// Computation bridging one NFT needs, configured or measured from a
+	// previous transaction. DefaultComputationPerNFT if zero
+	ComputationPerNFT uint64
+	// Computation limit the batches are sized for and sent with.
+	// DefaultComputeLimit if zero
+	ComputeLimit uint64
+	// Upper bound on the NFTs of a batch, unbounded if zero
+	MaxBatchSize int
+	// Times the NFTs of batches failing with a retryable error are sent again
+	Retries int
+}
+
+// Returns the compute limit of the batch transactions
+func (b Batching) computeLimit() uint64 {
+	if b.ComputeLimit == 0 {
+		return DefaultComputeLimit
+	}
+	return b.ComputeLimit
+}
+
+// Returns the number of NFTs a batch transaction can bridge within the
+// compute limit
+func (b Batching) Size() int {
+	perNFT, limit := b.ComputationPerNFT, b.computeLimit()
+	if perNFT == 0 {
+		perNFT = DefaultComputationPerNFT
+	}
+	size := int(float64(limit) * batchComputeShare / float64(perNFT))
+	if b.MaxBatchSize > 0 && size > b.MaxBatchSize {
+		size = b.MaxBatchSize
+	}
+	return max(size, 1)
+}
+
+// Splits ids into batches of at most size, in order
+func SplitIDs(ids []*big.Int, size int) [][]*big.Int {
+	batches := [][]*big.Int{}
+	for start := 0; start < len(ids); start += size {
+		batches = append(batches, ids[start:min(start+size, len(ids))])
+	}
+	return batches
+}
+
+// BatchResult is the outcome of the transaction sent for a batch
+type BatchResult struct {
+	IDs []*big.Int
+	// Zero if the transaction could not be sent
+	TransactionID flow.Identifier
+	// Nil if the NFTs were bridged
+	Error error
+}
+
+// BatchTransfer is the outcome of bridging NFTs in batches
+type BatchTransfer struct {
+	// Batches sent, retries included, in order
+	Batches   []BatchResult
+	Succeeded []*big.Int
+	// NFTs not bridged once retries are exhausted. Sending them again with
+	// BridgeNFTsInBatches resumes the transfer
+	Failed []*big.Int
+}
+
+// Returns the batch transactions bridging the NFTs of request, each planned
+// with Plan. Only the first plan onboards the asset if needed
+func (c *BridgeClient) PlanBatches(ctx context.Context, request TransferRequest, batching Batching) ([]*Plan, error) {
+	if len(request.IDs) == 0 {
+		return nil, fmt.Errorf("Cannot bridge NFTs: no IDs given")
+	}
+	plans := []*Plan{}
+	for _, ids := range SplitIDs(request.IDs, batching.Size()) {
+		batch := request
+		batch.IDs = ids
+		plan, err := c.Plan(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if plan.Kind != AssetNFT {
+			return nil, fmt.Errorf("Cannot bridge %s in batches: not an NFT", request.Asset)
+		}
+		if len(plans) > 0 {
+			// The first batch onboards the asset, or is preceded by its onboarding
+			plan.Onboarding = nil
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// Bridges the NFTs of request in batches sized by batching, signed by
+// signer and sent with its compute limit, which requires a LimitedSender.
+// Batches failing with a retryable error are sent again up to
+// batching.Retries times, only with the NFTs that were not bridged. Batches
+// exceeding the compute limit are halved when sent again. Batches failing
+// otherwise, such as for NFTs the signer does not own, are not retried.
+// Errors are returned when no batch could be planned or the asset could not
+// be onboarded, outcomes of the batches are reported in the BatchTransfer
+func (c *BridgeClient) BridgeNFTsInBatches(ctx context.Context, signer string, request TransferRequest, batching Batching) (*BatchTransfer, error) {
+	transfer := &BatchTransfer{}
+	remaining, failed := request.IDs, []*big.Int{}
+	for attempt := 0; attempt <= batching.Retries && len(remaining) > 0; attempt++ {
+		round := request
+		round.IDs = remaining
+		plans, err := c.PlanBatches(ctx, round, batching)
+		if err != nil {
+			return transfer, err
+		}
+		if onboarding := plans[0].Onboarding; onboarding != nil && !onboarding.Inline {
+			if _, err := c.send(ctx, signer, onboarding.Path, onboarding.Code, onboarding.Arguments); err != nil {
+				return transfer, err
+			}
+		}
+
+		batches := SplitIDs(round.IDs, batching.Size())
+		remaining = nil
+		exceeded := false
+		for i, plan := range plans {
+			batch := batches[i]
+			result := BatchResult{IDs: batch}
+			sent, err := c.sendPlanWithLimit(ctx, signer, plan, batching.computeLimit())
+			if sent != nil {
+				result.TransactionID = sent.TransactionID
+			}
+			result.Error = err
+			transfer.Batches = append(transfer.Batches, result)
+			if err != nil {
+				overLimit := errors.Is(txerrors.Classify(err), txerrors.ErrComputationLimit)
+				if overLimit || txerrors.IsRetryable(err) {
+					remaining = append(remaining, batch...)
+				} else {
+					failed = append(failed, batch...)
+				}
+				exceeded = exceeded || overLimit
+				continue
+			}
+			transfer.Succeeded = append(transfer.Succeeded, batch...)
+		}
+		if exceeded {
+			batching.MaxBatchSize = max(batching.Size()/2, 1)
+		}
+	}
+	transfer.Failed = append(failed, remaining...)
+	return transfer, nil
+}
@@ -0,0 +1,122 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/client"
+)
+
+func TestBatchingSize(t *testing.T) {
+	assert.Equal(t, 31, client.Batching{}.Size())
+	assert.Equal(t, 79, client.Batching{ComputationPerNFT: 100}.Size())
+	assert.Equal(t, 10, client.Batching{ComputationPerNFT: 100, MaxBatchSize: 10}.Size())
+	assert.Equal(t, 1, client.Batching{ComputationPerNFT: 100_000}.Size())
+
+	batches := client.SplitIDs(ids(1, 2, 3, 4, 5), 2)
+	assert.Equal(t, [][]*big.Int{ids(1, 2), ids(3, 4), ids(5)}, batches)
+}
+
+// Returns the IDs of an NFT bridge transaction
+func transactionIDs(arguments []cadence.Value) []uint64 {
+	if array, ok := arguments[1].(cadence.Array); ok {
+		result := []uint64{}
+		for _, id := range array.Values {
+			result = append(result, uint64(id.(cadence.UInt64)))
+		}
+		return result
+	}
+	return []uint64{uint64(arguments[1].(cadence.UInt64))}
+}
+
+func TestBridgeNFTsInBatches(t *testing.T) {
+	c, fake := newPlannerClient(t)
+	ctx := context.Background()
+	// Batches with NFT 1 fail once while the bridge is paused, batches with
+	// NFT 5 exceed the compute limit once, batches with NFT 9 always fail
+	paused, exceeded := false, false
+	fake.OnTransactionFunc("FlowEVMBridge", func(arguments []cadence.Value) flow.TransactionResult {
+		for _, id := range transactionIDs(arguments) {
+			switch {
+			case id == 1 && !paused:
+				paused = true
+				return flow.TransactionResult{Error: errors.New("Bridge operations are currently paused")}
+			case id == 5 && !exceeded:
+				exceeded = true
+				return flow.TransactionResult{Error: errors.New("[Error Code: 1110] computation exceeds limit (9999)")}
+			case id == 9:
+				return flow.TransactionResult{Error: errors.New("Named owner is not the owner of the ERC721")}
+			}
+		}
+		return flow.TransactionResult{}
+	})
+
+	request := client.TransferRequest{
+		Asset:     exampleNFT,
+		IDs:       ids(1, 2, 3, 4, 5, 6, 7, 8, 9, 10),
+		From:      client.VMCadence,
+		Recipient: aliceCOA,
+		COA:       aliceCOA,
+	}
+	transfer, err := c.BridgeNFTsInBatches(ctx, "alice", request, client.Batching{MaxBatchSize: 4, Retries: 1})
+	require.Nil(t, err)
+
+	batches := [][]*big.Int{}
+	for _, batch := range transfer.Batches {
+		batches = append(batches, batch.IDs)
+	}
+	// Retryable batches are halved after exceeding the compute limit, the
+	// batch with an NFT the signer does not own is not retried
+	assert.Equal(t, [][]*big.Int{ids(1, 2, 3, 4), ids(5, 6, 7, 8), ids(9, 10), ids(1, 2), ids(3, 4), ids(5, 6), ids(7, 8)}, batches)
+	assert.ErrorContains(t, transfer.Batches[1].Error, "computation exceeds limit")
+	assert.NotEqual(t, flow.EmptyID, transfer.Batches[0].TransactionID)
+	assert.Equal(t, ids(1, 2, 3, 4, 5, 6, 7, 8), transfer.Succeeded)
+	assert.Equal(t, ids(9, 10), transfer.Failed)
+
+	calls := fake.Calls()
+	last := calls[len(calls)-1]
+	assert.Equal(t, "alice", last.Signer)
+	assert.Equal(t, uint64(client.DefaultComputeLimit), last.ComputeLimit)
+
+	// Sending the failed NFTs again resumes the transfer
+	request.IDs = transfer.Failed
+	transfer, err = c.BridgeNFTsInBatches(ctx, "alice", request, client.Batching{})
+	require.Nil(t, err)
+	assert.Len(t, transfer.Batches, 1)
+	assert.Equal(t, ids(9, 10), transfer.Failed)
+}
+
+func TestBridgeNFTsInBatchesOnboarding(t *testing.T) {
+	c, fake := newPlannerClient(t)
+	fake.OnScript("FlowEVMBridge.typeRequiresOnboarding", cadence.NewOptional(cadence.Bool(true)))
+
+	// NFTs bridged from EVM are onboarded once before the batches
+	transfer, err := c.BridgeNFTsInBatches(context.Background(), "alice", client.TransferRequest{
+		Asset:     exampleNFT,
+		IDs:       ids(1, 2, 3),
+		From:      client.VMEVM,
+		Recipient: alice,
+		Signer:    flow.HexToAddress(alice),
+	}, client.Batching{MaxBatchSize: 2})
+	require.Nil(t, err)
+	assert.Empty(t, transfer.Failed)
+
+	calls := fake.Calls()
+	transactions := []client.Call{}
+	for _, call := range calls {
+		if call.Signer != "" {
+			transactions = append(transactions, call)
+		}
+	}
+	require.Len(t, transactions, 3)
+	assert.Equal(t, []cadence.Value{cadence.String(exampleNFT)}, transactions[0].Arguments)
+	assert.Contains(t, string(transactions[1].Code), "ids: [UInt256]")
+	assert.Equal(t, cadence.NewUInt256(3), transactions[2].Arguments[1])
+}
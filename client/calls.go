@@ -0,0 +1,122 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/onflow/cadence"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/compose"
+)
+
+// EVMCall is a call of an EVM contract from the signer's COA
+type EVMCall struct {
+	// EVM address of the contract
+	To string
+	// ABI-encoded calldata
+	Data     []byte
+	GasLimit uint64
+	// Attoflow sent with the call, none if nil
+	Value *big.Int
+	// Whether a failed call reverts the transaction. Otherwise the failure is
+	// logged and the transaction goes on
+	RevertOnFailure bool
+}
+
+// Returns plan with EVM calls from the signer's COA made before and after
+// the transfer, all in one transaction. Calls run in the execute phase, so
+// calls after bridging to EVM can spend the bridged assets and calls before
+// bridging from EVM can produce the assets bridged
+func (c *BridgeClient) WithEVMCalls(plan *Plan, before []EVMCall, after []EVMCall) (*Plan, error) {
+	steps := []compose.Step{{Code: plan.Code, Arguments: plan.Arguments}}
+	if len(before) > 0 {
+		step, err := c.evmCallsStep("before", before)
+		if err != nil {
+			return nil, err
+		}
+		steps = append([]compose.Step{step}, steps...)
+	}
+	if len(after) > 0 {
+		step, err := c.evmCallsStep("after", after)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 1 {
+		return plan, nil
+	}
+	composed, err := compose.Compose(steps...)
+	if err != nil {
+		return nil, err
+	}
+	withCalls := *plan
+	withCalls.Code = composed.Code
+	withCalls.Arguments = composed.Arguments
+	return &withCalls, nil
+}
+
+// Returns a transaction making the calls in order from the signer's COA. Its
+// parameters, fields and declarations are prefixed to compose with other
+// transactions
+func (c *BridgeClient) evmCallsStep(prefix string, calls []EVMCall) (compose.Step, error) {
+	parameters := []string{}
+	arguments := []cadence.Value{}
+	var execute strings.Builder
+	for i, call := range calls {
+		if !evmAddress.MatchString(call.To) {
+			return compose.Step{}, fmt.Errorf("Cannot call %s: not an EVM address", call.To)
+		}
+		value := big.NewInt(0)
+		if call.Value != nil {
+			value = call.Value
+		}
+		attoflow, err := cadence.NewUIntFromBig(value)
+		if err != nil {
+			return compose.Step{}, fmt.Errorf("Cannot call %s with value %s: %w", call.To, value, err)
+		}
+
+		to, data, gasLimit, attoflowValue, revert, result :=
+			fmt.Sprintf("%sTo%d", prefix, i), fmt.Sprintf("%sData%d", prefix, i), fmt.Sprintf("%sGasLimit%d", prefix, i),
+			fmt.Sprintf("%sValue%d", prefix, i), fmt.Sprintf("%sRevertOnFailure%d", prefix, i), fmt.Sprintf("%sResult%d", prefix, i)
+		parameters = append(parameters,
+			to+": String", data+": String", gasLimit+": UInt64", attoflowValue+": UInt", revert+": Bool")
+		arguments = append(arguments,
+			cadence.String(call.To), cadence.String(hex.EncodeToString(call.Data)), cadence.UInt64(call.GasLimit), attoflow, cadence.Bool(call.RevertOnFailure))
+
+		fmt.Fprintf(&execute, `        let %[1]s = self.%[2]sCOA.call(
+            to: EVM.addressFromString(%[3]s),
+            data: %[4]s.decodeHex(),
+            gasLimit: %[5]s,
+            value: EVM.Balance(attoflow: %[6]s)
+        )
+        if %[1]s.status != EVM.Status.successful {
+            let message = "EVM call %[8]d to ".concat(%[3]s).concat(" failed with error code ").concat(%[1]s.errorCode.toString())
+            if %[7]s {
+                panic(message)
+            }
+            log(message)
+        }
+`, result, prefix, to, data, gasLimit, attoflowValue, revert, i)
+	}
+
+	code := fmt.Sprintf(`import "EVM"
+
+transaction(%[1]s) {
+
+    let %[2]sCOA: auth(EVM.Call) &EVM.CadenceOwnedAccount
+
+    prepare(signer: auth(BorrowValue) &Account) {
+        self.%[2]sCOA = signer.storage.borrow<auth(EVM.Call) &EVM.CadenceOwnedAccount>(from: /storage/evm)
+            ?? panic("Could not borrow COA from the signer's account at path /storage/evm")
+    }
+
+    execute {
+%[3]s    }
+}
+`, strings.Join(parameters, ", "), prefix, execute.String())
+	return compose.Step{Code: []byte(bridge.ReplaceAddresses(code, c.BridgeEnv, c.CoreEnv)), Arguments: arguments}, nil
+}
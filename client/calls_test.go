@@ -0,0 +1,64 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/parser"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/client"
+)
+
+func TestWithEVMCalls(t *testing.T) {
+	c, _ := newPlannerClient(t)
+	ctx := context.Background()
+	vault := "A.0ae53cb6e3f42a79.ExampleToken.Vault"
+	plan, err := c.Plan(ctx, client.TransferRequest{Asset: vault, Amount: "2.5", From: client.VMCadence, Recipient: aliceCOA, COA: aliceCOA})
+	require.Nil(t, err)
+
+	// Bridge tokens to the COA, then approve and deposit them
+	withCalls, err := c.WithEVMCalls(plan, nil, []client.EVMCall{
+		{To: "0x" + erc20, Data: []byte{0x09, 0x5e, 0xa7, 0xb3}, GasLimit: 100_000, RevertOnFailure: true},
+		{To: bobEVM, Data: []byte{0xb6, 0xb5, 0x5f, 0x25}, GasLimit: 200_000, Value: big.NewInt(1_000)},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, plan.Path, withCalls.Path)
+	assert.Equal(t, []cadence.Value{
+		cadence.String(vault), cadence.UFix64(250_000_000),
+		cadence.String("0x" + erc20), cadence.String("095ea7b3"), cadence.UInt64(100_000), cadence.NewUInt(0), cadence.Bool(true),
+		cadence.String(bobEVM), cadence.String("b6b55f25"), cadence.UInt64(200_000), cadence.NewUInt(1_000), cadence.Bool(false),
+	}, withCalls.Arguments)
+
+	code := string(withCalls.Code)
+	_, err = parser.ParseProgram(nil, withCalls.Code, parser.Config{})
+	require.Nil(t, err, code)
+	assert.Equal(t, 1, strings.Count(code, "import EVM from 0xe467b9dd11fa00df"))
+	// The calls run after the tokens are deposited to the COA
+	require.Contains(t, code, "self.coa.depositTokens(")
+	assert.Less(t, strings.Index(code, "self.coa.depositTokens("), strings.Index(code, "let afterResult0 = self.afterCOA.call("))
+	assert.Less(t, strings.Index(code, "afterResult0"), strings.Index(code, "afterResult1"))
+
+	// Calls before pulling an NFT from EVM
+	plan, err = c.Plan(ctx, client.TransferRequest{Asset: exampleNFT, IDs: ids(42), From: client.VMEVM, Recipient: alice, Signer: flow.HexToAddress(alice)})
+	require.Nil(t, err)
+	withCalls, err = c.WithEVMCalls(plan, []client.EVMCall{{To: erc721, GasLimit: 100_000}}, nil)
+	require.Nil(t, err)
+	assert.Equal(t, cadence.String(erc721), withCalls.Arguments[0])
+	assert.Equal(t, cadence.String(exampleNFT), withCalls.Arguments[5])
+	code = string(withCalls.Code)
+	require.Contains(t, code, "self.coa.withdrawNFT(")
+	assert.Less(t, strings.Index(code, "beforeResult0"), strings.Index(code, "self.coa.withdrawNFT("))
+
+	unchanged, err := c.WithEVMCalls(plan, nil, nil)
+	require.Nil(t, err)
+	assert.Same(t, plan, unchanged)
+
+	_, err = c.WithEVMCalls(plan, []client.EVMCall{{To: "0x1234"}}, nil)
+	assert.ErrorContains(t, err, "Cannot call 0x1234: not an EVM address")
+}
@@ -0,0 +1,214 @@
+// Package client executes the bridge scripts and transactions against a
+// network. BridgeClient renders the templates for an Environment and runs them
+// through pluggable executors: AccessNode for an access node, or Fake in tests.
+// Results are decoded with the results package.
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/onflow/cadence"
+	coreContracts "github.com/onflow/flow-core-contracts/lib/go/templates"
+	"github.com/onflow/flow-go-sdk"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/results"
+)
+
+const (
+	pathIsPaused                     = "cadence/scripts/bridge/is_paused.cdc"
+	pathIsTypePaused                 = "cadence/scripts/bridge/is_type_paused.cdc"
+	pathTypeRequiresOnboarding       = "cadence/scripts/bridge/type_requires_onboarding_by_identifier.cdc"
+	pathEVMAddressRequiresOnboarding = "cadence/scripts/bridge/evm_address_requires_onboarding.cdc"
+	pathGetAssociatedEVMAddress      = "cadence/scripts/bridge/get_associated_evm_address.cdc"
+	pathGetAssociatedType            = "cadence/scripts/bridge/get_associated_type.cdc"
+	pathCalculateBridgeFee           = "cadence/scripts/bridge/calculate_bridge_fee.cdc"
+	pathGetBaseFee                   = "cadence/scripts/config/get_base_fee.cdc"
+	pathGetOnboardFee                = "cadence/scripts/config/get_onboard_fee.cdc"
+
+	pathOnboardByTypeIdentifier = "cadence/transactions/bridge/onboarding/onboard_by_type_identifier.cdc"
+	pathOnboardByEVMAddress     = "cadence/transactions/bridge/onboarding/onboard_by_evm_address.cdc"
+	pathBridgeNFTToEVM          = "cadence/transactions/bridge/nft/bridge_nft_to_evm.cdc"
+	pathBridgeNFTFromEVM        = "cadence/transactions/bridge/nft/bridge_nft_from_evm.cdc"
+	pathBridgeTokensToEVM       = "cadence/transactions/bridge/tokens/bridge_tokens_to_evm.cdc"
+	pathBridgeTokensFromEVM     = "cadence/transactions/bridge/tokens/bridge_tokens_from_evm.cdc"
+)
+
+// BridgeClient runs the bridge templates rendered for an Environment
+type BridgeClient struct {
+	BridgeEnv    bridge.Environment
+	CoreEnv      coreContracts.Environment
+	Scripts      ScriptExecutor
+	Transactions TransactionSender
+	// Simulator of transactions, nil unless set
+	Simulator Simulator
+}
+
+// Returns a client for the environment of a network, such as
+// bridge.NetworkMainnet. Transactions may be nil for a read-only client
+func New(network string, scripts ScriptExecutor, transactions TransactionSender) (*BridgeClient, error) {
+	bridgeEnv, coreEnv, err := bridge.NetworkEnvironment(network)
+	if err != nil {
+		return nil, err
+	}
+	return &BridgeClient{BridgeEnv: bridgeEnv, CoreEnv: coreEnv, Scripts: scripts, Transactions: transactions}, nil
+}
+
+// Renders and executes the script at path
+func (c *BridgeClient) ExecuteScript(ctx context.Context, path string, arguments ...cadence.Value) (cadence.Value, error) {
+	code, err := bridge.GetCadenceScriptCode(path, c.BridgeEnv, c.CoreEnv)
+	if err != nil {
+		return nil, err
+	}
+	value, err := c.Scripts.ExecuteScript(ctx, code, arguments)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", path, err)
+	}
+	return value, nil
+}
+
+// Renders and sends the transaction at path signed by signer. A failed
+// transaction is returned with its error
+func (c *BridgeClient) SendTransaction(ctx context.Context, signer string, path string, arguments ...cadence.Value) (*flow.TransactionResult, error) {
+	code, err := bridge.GetCadenceTransactionCode(path, c.BridgeEnv, c.CoreEnv)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(ctx, signer, path, code, arguments)
+}
+
+// Sends the code of the transaction rendered from path
+func (c *BridgeClient) send(ctx context.Context, signer string, path string, code []byte, arguments []cadence.Value) (*flow.TransactionResult, error) {
+	return c.sendWithLimit(ctx, signer, path, code, arguments, 0)
+}
+
+// Sends the code of the transaction rendered from path with the given compute
+// limit, the sender's own if zero
+func (c *BridgeClient) sendWithLimit(ctx context.Context, signer string, path string, code []byte, arguments []cadence.Value, computeLimit uint64) (*flow.TransactionResult, error) {
+	if c.Transactions == nil {
+		return nil, fmt.Errorf("Cannot send %s: client has no transaction sender", path)
+	}
+	var result *flow.TransactionResult
+	var err error
+	if limited, ok := c.Transactions.(LimitedSender); ok && computeLimit > 0 {
+		result, err = limited.SendTransactionWithLimit(ctx, signer, code, arguments, computeLimit)
+	} else if computeLimit > 0 {
+		return nil, fmt.Errorf("Cannot send %s with compute limit %d: transaction sender does not support compute limits", path, computeLimit)
+	} else {
+		result, err = c.Transactions.SendTransaction(ctx, signer, code, arguments)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", path, err)
+	}
+	if result.Error != nil {
+		return result, fmt.Errorf("%s failed: %w", path, result.Error)
+	}
+	return result, nil
+}
+
+// Returns whether the bridge is paused
+func (c *BridgeClient) IsPaused(ctx context.Context) (bool, error) {
+	return query(ctx, c, results.Bool, pathIsPaused)
+}
+
+// Returns whether the type with the given identifier is paused, nil if it
+// has not been onboarded
+func (c *BridgeClient) IsTypePaused(ctx context.Context, typeIdentifier string) (*bool, error) {
+	return query(ctx, c, results.OptionalBool, pathIsTypePaused, cadence.String(typeIdentifier))
+}
+
+// Returns whether the type with the given identifier requires onboarding,
+// nil if it cannot be bridged or does not exist
+func (c *BridgeClient) TypeRequiresOnboarding(ctx context.Context, typeIdentifier string) (*bool, error) {
+	return query(ctx, c, results.OptionalBool, pathTypeRequiresOnboarding, cadence.String(typeIdentifier))
+}
+
+// Returns whether the EVM contract requires onboarding, nil if it is neither
+// an ERC20 nor an ERC721
+func (c *BridgeClient) EVMAddressRequiresOnboarding(ctx context.Context, evmAddress string) (*bool, error) {
+	return query(ctx, c, results.OptionalBool, pathEVMAddressRequiresOnboarding, cadence.String(evmAddress))
+}
+
+// Returns the EVM address associated with the type as hex without 0x prefix,
+// nil if there is none
+func (c *BridgeClient) AssociatedEVMAddress(ctx context.Context, typeIdentifier string) (*string, error) {
+	return query(ctx, c, results.OptionalString, pathGetAssociatedEVMAddress, cadence.String(typeIdentifier))
+}
+
+// Returns the identifier of the type associated with the EVM address, nil if
+// there is none
+func (c *BridgeClient) AssociatedType(ctx context.Context, evmAddress string) (*string, error) {
+	return query(ctx, c, results.OptionalType, pathGetAssociatedType, cadence.String(evmAddress))
+}
+
+// Returns the base fee charged for bridging
+func (c *BridgeClient) BaseFee(ctx context.Context) (cadence.UFix64, error) {
+	return query(ctx, c, results.UFix64, pathGetBaseFee)
+}
+
+// Returns the fee charged for onboarding
+func (c *BridgeClient) OnboardFee(ctx context.Context) (cadence.UFix64, error) {
+	return query(ctx, c, results.UFix64, pathGetOnboardFee)
+}
+
+// Returns the fee charged for bridging an asset using the given storage bytes
+func (c *BridgeClient) BridgeFee(ctx context.Context, bytes uint64) (cadence.UFix64, error) {
+	return query(ctx, c, results.UFix64, pathCalculateBridgeFee, cadence.UInt64(bytes))
+}
+
+// Onboards the type with the given identifier
+func (c *BridgeClient) OnboardByTypeIdentifier(ctx context.Context, signer string, typeIdentifier string) (*flow.TransactionResult, error) {
+	return c.SendTransaction(ctx, signer, pathOnboardByTypeIdentifier, cadence.String(typeIdentifier))
+}
+
+// Onboards the EVM contract at the given address
+func (c *BridgeClient) OnboardByEVMAddress(ctx context.Context, signer string, evmAddress string) (*flow.TransactionResult, error) {
+	return c.SendTransaction(ctx, signer, pathOnboardByEVMAddress, cadence.String(evmAddress))
+}
+
+// Bridges an NFT from the signer's collection to the signer's COA,
+// onboarding its type if necessary
+func (c *BridgeClient) BridgeNFTToEVM(ctx context.Context, signer string, nftIdentifier string, id uint64) (*flow.TransactionResult, error) {
+	return c.SendTransaction(ctx, signer, pathBridgeNFTToEVM, cadence.String(nftIdentifier), cadence.UInt64(id))
+}
+
+// Bridges an NFT from the signer's COA to the signer's collection, where id
+// is the ERC721 token ID
+func (c *BridgeClient) BridgeNFTFromEVM(ctx context.Context, signer string, nftIdentifier string, id *big.Int) (*flow.TransactionResult, error) {
+	evmID, err := cadence.NewUInt256FromBig(id)
+	if err != nil {
+		return nil, err
+	}
+	return c.SendTransaction(ctx, signer, pathBridgeNFTFromEVM, cadence.String(nftIdentifier), evmID)
+}
+
+// Bridges tokens from the signer's vault to the signer's COA
+func (c *BridgeClient) BridgeTokensToEVM(ctx context.Context, signer string, vaultIdentifier string, amount cadence.UFix64) (*flow.TransactionResult, error) {
+	return c.SendTransaction(ctx, signer, pathBridgeTokensToEVM, cadence.String(vaultIdentifier), amount)
+}
+
+// Bridges tokens from the signer's COA to the signer's vault, where amount
+// is in the ERC20's smallest unit
+func (c *BridgeClient) BridgeTokensFromEVM(ctx context.Context, signer string, vaultIdentifier string, amount *big.Int) (*flow.TransactionResult, error) {
+	evmAmount, err := cadence.NewUInt256FromBig(amount)
+	if err != nil {
+		return nil, err
+	}
+	return c.SendTransaction(ctx, signer, pathBridgeTokensFromEVM, cadence.String(vaultIdentifier), evmAmount)
+}
+
+// Executes the script at path and decodes its result
+func query[T any](ctx context.Context, c *BridgeClient, decode func(cadence.Value) (T, error), path string, arguments ...cadence.Value) (T, error) {
+	value, err := c.ExecuteScript(ctx, path, arguments...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	decoded, err := decode(value)
+	if err != nil {
+		return decoded, fmt.Errorf("Unexpected result of %s: %w", path, err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,150 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/client"
+)
+
+const exampleNFT = "A.0ae53cb6e3f42a79.ExampleNFT.NFT"
+
+func newClient(t *testing.T) (*client.BridgeClient, *client.Fake) {
+	fake := client.NewFake()
+	c, err := client.New(bridge.NetworkMainnet, fake, fake)
+	require.Nil(t, err)
+	return c, fake
+}
+
+func TestScripts(t *testing.T) {
+	c, fake := newClient(t)
+	ctx := context.Background()
+	fake.OnScript("FlowEVMBridgeConfig.isPaused()", cadence.Bool(true))
+	fake.OnScriptFunc("FlowEVMBridge.typeRequiresOnboarding", func(arguments []cadence.Value) (cadence.Value, error) {
+		if arguments[0] == cadence.String(exampleNFT) {
+			return cadence.NewOptional(cadence.Bool(false)), nil
+		}
+		return cadence.NewOptional(nil), nil
+	})
+	fake.OnScript("FlowEVMBridgeConfig.baseFee", cadence.UFix64(100_000))
+
+	paused, err := c.IsPaused(ctx)
+	require.Nil(t, err)
+	assert.True(t, paused)
+
+	requires, err := c.TypeRequiresOnboarding(ctx, exampleNFT)
+	require.Nil(t, err)
+	require.NotNil(t, requires)
+	assert.False(t, *requires)
+	requires, err = c.TypeRequiresOnboarding(ctx, "A.0ae53cb6e3f42a79.Unknown.NFT")
+	require.Nil(t, err)
+	assert.Nil(t, requires)
+
+	fee, err := c.BaseFee(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, "0.00100000", fee.String())
+
+	// Scripts are rendered for the environment
+	calls := fake.Calls()
+	require.Len(t, calls, 4)
+	assert.Contains(t, string(calls[0].Code), "import FlowEVMBridgeConfig from 0x1e4aa0b87d10b141")
+
+	_, err = c.OnboardFee(ctx)
+	assert.ErrorContains(t, err, "get_onboard_fee.cdc failed")
+}
+
+func TestTransactions(t *testing.T) {
+	c, fake := newClient(t)
+	ctx := context.Background()
+
+	result, err := c.BridgeNFTToEVM(ctx, "alice", exampleNFT, 42)
+	require.Nil(t, err)
+	assert.Equal(t, flow.TransactionStatusSealed, result.Status)
+
+	fake.OnTransaction("coa.withdrawNFT", flow.TransactionResult{Error: errors.New("bridge is paused")})
+	_, err = c.BridgeNFTFromEVM(ctx, "alice", exampleNFT, big.NewInt(42))
+	assert.ErrorContains(t, err, "bridge is paused")
+
+	calls := fake.Calls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "alice", calls[0].Signer)
+	assert.Equal(t, []cadence.Value{cadence.String(exampleNFT), cadence.UInt64(42)}, calls[0].Arguments)
+	assert.Equal(t, cadence.NewUInt256(42), calls[1].Arguments[1])
+}
+
+// accessAPI is an access node sealing transactions on the second poll
+type accessAPI struct {
+	sent  []flow.Transaction
+	polls int
+}
+
+func (a *accessAPI) GetLatestBlockHeader(ctx context.Context, isSealed bool) (*flow.BlockHeader, error) {
+	return &flow.BlockHeader{ID: flow.Identifier{7}}, nil
+}
+
+func (a *accessAPI) GetAccountAtLatestBlock(ctx context.Context, address flow.Address) (*flow.Account, error) {
+	return &flow.Account{Address: address, Keys: []*flow.AccountKey{{Index: 0, SequenceNumber: 3}, {Index: 1, SequenceNumber: 9}}}, nil
+}
+
+func (a *accessAPI) SendTransaction(ctx context.Context, tx flow.Transaction) error {
+	a.sent = append(a.sent, tx)
+	return nil
+}
+
+func (a *accessAPI) GetTransactionResult(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error) {
+	a.polls++
+	if a.polls < 2 {
+		return &flow.TransactionResult{Status: flow.TransactionStatusExecuted}, nil
+	}
+	return &flow.TransactionResult{Status: flow.TransactionStatusSealed, TransactionID: txID}, nil
+}
+
+func (a *accessAPI) ExecuteScriptAtLatestBlock(ctx context.Context, script []byte, arguments []cadence.Value) (cadence.Value, error) {
+	return cadence.Bool(false), nil
+}
+
+// signer returns a fixed signature
+type signer struct{}
+
+func (signer) Sign(message []byte) ([]byte, error) {
+	return []byte{1}, nil
+}
+
+func (signer) PublicKey() crypto.PublicKey {
+	return nil
+}
+
+func TestAccessNode(t *testing.T) {
+	api := &accessAPI{}
+	node := client.NewAccessNode(api)
+	node.PollInterval = 1
+	address := flow.HexToAddress("0000000000000001")
+	node.Signers["alice"] = client.Signer{Address: address, KeyIndex: 1, Signer: signer{}}
+
+	c, err := client.New(bridge.NetworkMainnet, node, node)
+	require.Nil(t, err)
+	result, err := c.BridgeNFTToEVM(context.Background(), "alice", exampleNFT, 1)
+	require.Nil(t, err)
+
+	require.Len(t, api.sent, 1)
+	tx := api.sent[0]
+	assert.Equal(t, tx.ID(), result.TransactionID)
+	assert.Equal(t, uint64(9), tx.ProposalKey.SequenceNumber)
+	assert.Equal(t, flow.Identifier{7}, tx.ReferenceBlockID)
+	assert.Equal(t, []flow.Address{address}, tx.Authorizers)
+	require.Len(t, tx.EnvelopeSignatures, 1)
+	assert.Len(t, tx.Arguments, 2)
+	assert.Equal(t, 2, api.polls)
+
+	_, err = c.BridgeNFTToEVM(context.Background(), "bob", exampleNFT, 1)
+	assert.ErrorContains(t, err, "unknown signer bob")
+}
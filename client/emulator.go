@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultEmulatorAdmin is the address of the emulator's admin API
+const DefaultEmulatorAdmin = "http://localhost:8080"
+
+var snapshotCount atomic.Uint64
+
+// Emulator simulates transactions on a Flow emulator started with
+// --snapshot, for example forked from the state of a network. A sandbox
+// snapshots the emulator through its admin API and jumps back to the
+// snapshot when closed. One sandbox is open at a time, Sandbox waits until
+// the open one is closed
+type Emulator struct {
+	// Executor of the emulator's access API
+	Node *AccessNode
+	// Address of the admin API, DefaultEmulatorAdmin if empty
+	Admin  string
+	Client *http.Client
+
+	// Held from Sandbox until the sandbox is closed
+	mu sync.Mutex
+}
+
+type emulatorSandbox struct {
+	*AccessNode
+	emulator *Emulator
+	snapshot string
+	closed   sync.Once
+}
+
+// Snapshots the emulator and returns a sandbox reverting to the snapshot,
+// waiting until the open sandbox is closed
+func (e *Emulator) Sandbox(ctx context.Context) (Sandbox, error) {
+	e.mu.Lock()
+	name := fmt.Sprintf("simulation-%d-%d", time.Now().UnixNano(), snapshotCount.Add(1))
+	form := url.Values{"name": {name}}
+	if err := e.request(ctx, http.MethodPost, "/emulator/snapshots", form.Encode()); err != nil {
+		e.mu.Unlock()
+		return nil, fmt.Errorf("Cannot snapshot emulator: %w", err)
+	}
+	return &emulatorSandbox{AccessNode: e.Node, emulator: e, snapshot: name}, nil
+}
+
+// Jumps back to the snapshot taken when the sandbox was opened and lets the
+// next sandbox open. Closing a closed sandbox does nothing
+func (s *emulatorSandbox) Close(ctx context.Context) error {
+	var err error
+	s.closed.Do(func() {
+		defer s.emulator.mu.Unlock()
+		if err = s.emulator.request(ctx, http.MethodPut, "/emulator/snapshots/"+url.PathEscape(s.snapshot), ""); err != nil {
+			err = fmt.Errorf("Cannot revert emulator to snapshot %s: %w", s.snapshot, err)
+		}
+	})
+	return err
+}
+
+func (e *Emulator) request(ctx context.Context, method string, path string, form string) error {
+	admin := e.Admin
+	if admin == "" {
+		admin = DefaultEmulatorAdmin
+	}
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(admin, "/")+path, strings.NewReader(form))
+	if err != nil {
+		return err
+	}
+	if form != "" {
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("admin API responded %s: %s", response.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+// ScriptExecutor executes a script and returns its result
+type ScriptExecutor interface {
+	ExecuteScript(ctx context.Context, code []byte, arguments []cadence.Value) (cadence.Value, error)
+}
+
+// TransactionSender sends a transaction signed by the named signer and waits
+// for its result
+type TransactionSender interface {
+	SendTransaction(ctx context.Context, signer string, code []byte, arguments []cadence.Value) (*flow.TransactionResult, error)
+}
+
+// LimitedSender is a TransactionSender that can send a transaction with a
+// given compute limit instead of its own
+type LimitedSender interface {
+	SendTransactionWithLimit(ctx context.Context, signer string, code []byte, arguments []cadence.Value, computeLimit uint64) (*flow.TransactionResult, error)
+}
+
+// Simulator executes transactions against a copy of the network state whose
+// effects are discarded, see BridgeClient.Simulate
+type Simulator interface {
+	// Returns a sandbox of the current state
+	Sandbox(ctx context.Context) (Sandbox, error)
+}
+
+// Sandbox is a copy of the network state. Scripts executed in a sandbox see
+// the effects of the transactions sent to it, which are discarded on Close
+type Sandbox interface {
+	ScriptExecutor
+	TransactionSender
+	Close(ctx context.Context) error
+}
+
+// AccessAPI is the subset of the flow-go-sdk access.Client used by AccessNode,
+// satisfied by the gRPC and HTTP clients
+type AccessAPI interface {
+	GetLatestBlockHeader(ctx context.Context, isSealed bool) (*flow.BlockHeader, error)
+	GetAccountAtLatestBlock(ctx context.Context, address flow.Address) (*flow.Account, error)
+	SendTransaction(ctx context.Context, tx flow.Transaction) error
+	GetTransactionResult(ctx context.Context, txID flow.Identifier) (*flow.TransactionResult, error)
+	ExecuteScriptAtLatestBlock(ctx context.Context, script []byte, arguments []cadence.Value) (cadence.Value, error)
+}
+
+// Signer is an account key signing transactions as proposer, payer and
+// single authorizer
+type Signer struct {
+	Address  flow.Address
+	KeyIndex uint32
+	Signer   crypto.Signer
+}
+
+const (
+	DefaultComputeLimit = 9999
+	DefaultPollInterval = time.Second
+)
+
+// AccessNode executes scripts and sends transactions through an access node
+type AccessNode struct {
+	Client AccessAPI
+	// Signers by name
+	Signers map[string]Signer
+	// Compute limit of transactions, DefaultComputeLimit if zero
+	ComputeLimit uint64
+	// Interval between transaction result polls, DefaultPollInterval if zero
+	PollInterval time.Duration
+}
+
+// Returns an access node executor for the client without signers
+func NewAccessNode(client AccessAPI) *AccessNode {
+	return &AccessNode{Client: client, Signers: map[string]Signer{}}
+}
+
+// Executes a script against the latest sealed block
+func (a *AccessNode) ExecuteScript(ctx context.Context, code []byte, arguments []cadence.Value) (cadence.Value, error) {
+	return a.Client.ExecuteScriptAtLatestBlock(ctx, code, arguments)
+}
+
+// Signs and sends a transaction, then waits until it is sealed. A transaction
+// that was sealed but failed is returned with its error in the result
+func (a *AccessNode) SendTransaction(ctx context.Context, signer string, code []byte, arguments []cadence.Value) (*flow.TransactionResult, error) {
+	return a.SendTransactionWithLimit(ctx, signer, code, arguments, a.ComputeLimit)
+}
+
+// Sends a transaction like SendTransaction with the given compute limit,
+// DefaultComputeLimit if zero
+func (a *AccessNode) SendTransactionWithLimit(ctx context.Context, signer string, code []byte, arguments []cadence.Value, computeLimit uint64) (*flow.TransactionResult, error) {
+	s, ok := a.Signers[signer]
+	if !ok {
+		return nil, fmt.Errorf("Cannot send transaction: unknown signer %s", signer)
+	}
+	account, err := a.Client.GetAccountAtLatestBlock(ctx, s.Address)
+	if err != nil {
+		return nil, err
+	}
+	var sequenceNumber uint64
+	found := false
+	for _, key := range account.Keys {
+		if key.Index == s.KeyIndex {
+			sequenceNumber, found = key.SequenceNumber, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("Cannot send transaction: account %s has no key %d", s.Address, s.KeyIndex)
+	}
+	header, err := a.Client.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if computeLimit == 0 {
+		computeLimit = DefaultComputeLimit
+	}
+	tx := flow.NewTransaction().
+		SetScript(code).
+		SetComputeLimit(computeLimit).
+		SetReferenceBlockID(header.ID).
+		SetProposalKey(s.Address, s.KeyIndex, sequenceNumber).
+		SetPayer(s.Address).
+		AddAuthorizer(s.Address)
+	for _, argument := range arguments {
+		if err := tx.AddArgument(argument); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.SignEnvelope(s.Address, s.KeyIndex, s.Signer); err != nil {
+		return nil, err
+	}
+	if err := a.Client.SendTransaction(ctx, *tx); err != nil {
+		return nil, err
+	}
+	return a.waitForSeal(ctx, tx.ID())
+}
+
+func (a *AccessNode) waitForSeal(ctx context.Context, id flow.Identifier) (*flow.TransactionResult, error) {
+	interval := a.PollInterval
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+	for {
+		result, err := a.Client.GetTransactionResult(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if result.Status == flow.TransactionStatusSealed {
+			return result, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Call is a script or transaction received by a Fake
+type Call struct {
+	// Signer of a transaction, empty for scripts
+	Signer    string
+	Code      []byte
+	Arguments []cadence.Value
+	// Compute limit a transaction was sent with, zero for the sender's own
+	ComputeLimit uint64
+}
+
+type scriptResponse struct {
+	match   string
+	respond func(arguments []cadence.Value) (cadence.Value, error)
+}
+
+type transactionResponse struct {
+	match   string
+	respond func(arguments []cadence.Value) flow.TransactionResult
+}
+
+// Fake is an in-memory ScriptExecutor and TransactionSender answering with
+// canned results, matched by a fragment of the code such as a contract call.
+// The most recently registered matching response wins
+type Fake struct {
+	mu           sync.Mutex
+	scripts      []scriptResponse
+	transactions []transactionResponse
+	calls        []Call
+}
+
+// Returns a fake without responses. Transactions succeed unless a response
+// is registered for them, scripts fail
+func NewFake() *Fake {
+	return &Fake{}
+}
+
+// Answers scripts containing match with the result
+func (f *Fake) OnScript(match string, result cadence.Value) {
+	f.OnScriptFunc(match, func([]cadence.Value) (cadence.Value, error) {
+		return result, nil
+	})
+}
+
+// Answers scripts containing match by calling respond with their arguments
+func (f *Fake) OnScriptFunc(match string, respond func(arguments []cadence.Value) (cadence.Value, error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scripts = append(f.scripts, scriptResponse{match: match, respond: respond})
+}
+
+// Answers transactions containing match with the result. Its transaction ID
+// and status are set when sent
+func (f *Fake) OnTransaction(match string, result flow.TransactionResult) {
+	f.OnTransactionFunc(match, func([]cadence.Value) flow.TransactionResult {
+		return result
+	})
+}
+
+// Answers transactions containing match by calling respond with their
+// arguments. Its transaction ID and status are set when sent
+func (f *Fake) OnTransactionFunc(match string, respond func(arguments []cadence.Value) flow.TransactionResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.transactions = append(f.transactions, transactionResponse{match: match, respond: respond})
+}
+
+// Returns the scripts and transactions received so far, in order
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call{}, f.calls...)
+}
+
+// Returns the fake itself as a sandbox, so responses registered on the fake
+// answer the simulated transactions
+func (f *Fake) Sandbox(ctx context.Context) (Sandbox, error) {
+	return fakeSandbox{f}, nil
+}
+
+type fakeSandbox struct {
+	*Fake
+}
+
+func (fakeSandbox) Close(ctx context.Context) error {
+	return nil
+}
+
+func (f *Fake) ExecuteScript(ctx context.Context, code []byte, arguments []cadence.Value) (cadence.Value, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Code: code, Arguments: arguments})
+	var respond func([]cadence.Value) (cadence.Value, error)
+	for i := len(f.scripts) - 1; i >= 0; i-- {
+		if strings.Contains(string(code), f.scripts[i].match) {
+			respond = f.scripts[i].respond
+			break
+		}
+	}
+	f.mu.Unlock()
+	if respond == nil {
+		return nil, errors.New("Fake has no result for script")
+	}
+	return respond(arguments)
+}
+
+func (f *Fake) SendTransaction(ctx context.Context, signer string, code []byte, arguments []cadence.Value) (*flow.TransactionResult, error) {
+	return f.SendTransactionWithLimit(ctx, signer, code, arguments, 0)
+}
+
+func (f *Fake) SendTransactionWithLimit(ctx context.Context, signer string, code []byte, arguments []cadence.Value, computeLimit uint64) (*flow.TransactionResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Signer: signer, Code: code, Arguments: arguments, ComputeLimit: computeLimit})
+	result := flow.TransactionResult{}
+	for i := len(f.transactions) - 1; i >= 0; i-- {
+		if strings.Contains(string(code), f.transactions[i].match) {
+			result = f.transactions[i].respond(arguments)
+			break
+		}
+	}
+	binary.BigEndian.PutUint64(result.TransactionID[:8], uint64(len(f.calls)))
+	result.Status = flow.TransactionStatusSealed
+	return &result, nil
+}
@@ -0,0 +1,431 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/results"
+)
+
+const (
+	pathGetTokenDecimals = "cadence/scripts/utils/get_token_decimals.cdc"
+
+	pathBatchBridgeNFTToEVM               = "cadence/transactions/bridge/nft/batch_bridge_nft_to_evm.cdc"
+	pathBatchBridgeNFTFromEVM             = "cadence/transactions/bridge/nft/batch_bridge_nft_from_evm.cdc"
+	pathBridgeNFTToAnyEVMAddress          = "cadence/transactions/bridge/nft/bridge_nft_to_any_evm_address.cdc"
+	pathBatchBridgeNFTToAnyEVMAddress     = "cadence/transactions/bridge/nft/batch_bridge_nft_to_any_evm_address.cdc"
+	pathBridgeNFTToAnyCadenceAddress      = "cadence/transactions/bridge/nft/bridge_nft_to_any_cadence_address.cdc"
+	pathBatchBridgeNFTToAnyCadenceAddress = "cadence/transactions/bridge/nft/batch_bridge_nft_to_any_cadence_address.cdc"
+	pathBridgeTokensToAnyEVMAddress       = "cadence/transactions/bridge/tokens/bridge_tokens_to_any_evm_address.cdc"
+	pathBridgeTokensToAnyCadenceAddress   = "cadence/transactions/bridge/tokens/bridge_tokens_to_any_cadence_address.cdc"
+	pathTransferFlowToCadenceOrEVM        = "cadence/transactions/flow-token/transfer_flow_to_cadence_or_evm.cdc"
+	pathWithdrawFlowFromCOA               = "cadence/transactions/evm/withdraw.cdc"
+)
+
+// Upper bound on the storage used by a single bridge transaction, as
+// assumed by the bridge transactions when capping their fee
+const maxBridgedBytes = 400_000
+
+var flowAddress = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{16}$`)
+
+// VM is a virtual machine of Flow
+type VM string
+
+const (
+	VMCadence VM = "cadence"
+	VMEVM     VM = "evm"
+)
+
+// AssetKind is the kind of asset transferred
+type AssetKind string
+
+const (
+	AssetNFT   AssetKind = "nft"
+	AssetToken AssetKind = "token"
+	AssetFLOW  AssetKind = "flow"
+)
+
+// TransferRequest describes a transfer of assets between the VMs
+type TransferRequest struct {
+	// Cadence type identifier of an NFT or Vault, EVM contract address of an
+	// ERC721 or ERC20, or FLOW
+	Asset string
+	// IDs of the NFTs: Cadence IDs from Cadence, ERC721 token IDs from EVM
+	IDs []*big.Int
+	// Amount of tokens in decimal notation, such as 1.5
+	Amount string
+	// VM the assets are transferred from
+	From VM
+	// Flow address or EVM address of the recipient
+	Recipient string
+	// Flow address of the signer and EVM address of its COA. Transfers to
+	// them use the templates bridging to the signer
+	Signer flow.Address
+	COA    string
+}
+
+// Plan is the transaction carrying out a TransferRequest
+type Plan struct {
+	Kind AssetKind
+	// Cadence type identifier of the asset, empty for FLOW
+	Type string
+	// EVM contract address of the asset, nil if it has not been onboarded
+	EVMAddress *string
+	Path       string
+	// Setup transaction composed before the template in Code, empty if none
+	Setup     string
+	Code      []byte
+	Arguments []cadence.Value
+	// Maximum bridge fee the transaction withdraws from the signer, onboarding
+	// included if the transaction onboards the asset
+	Fee cadence.UFix64
+	// Onboarding of the asset, nil if it has been onboarded
+	Onboarding *Onboarding
+}
+
+// Onboarding is the onboarding an asset needs before it can be transferred
+type Onboarding struct {
+	// Whether the transfer transaction onboards the asset itself. Otherwise
+	// Path must be sent first
+	Inline    bool
+	Path      string
+	Code      []byte
+	Arguments []cadence.Value
+	Fee       cadence.UFix64
+}
+
+// Returns the transaction transferring the requested assets with its
+// arguments and fee, and the onboarding the asset needs
+func (c *BridgeClient) Plan(ctx context.Context, request TransferRequest) (*Plan, error) {
+	toEVM := evmAddress.MatchString(request.Recipient)
+	switch {
+	case request.From != VMCadence && request.From != VMEVM:
+		return nil, fmt.Errorf("Cannot plan transfer from VM %q", request.From)
+	case !toEVM && !flowAddress.MatchString(request.Recipient):
+		return nil, fmt.Errorf("Cannot plan transfer to %s: neither a Flow address nor an EVM address", request.Recipient)
+	}
+
+	plan := &Plan{}
+	var err error
+	if err = c.resolveAsset(ctx, request.Asset, plan); err != nil {
+		return nil, err
+	}
+	if plan.Kind == AssetFLOW {
+		err = c.planFLOW(request, toEVM, plan)
+	} else if (request.From == VMEVM) == toEVM {
+		return nil, fmt.Errorf("Cannot bridge %s to %s: recipient is in the source VM", request.Asset, request.Recipient)
+	} else if request.From == VMCadence {
+		err = c.planToEVM(ctx, request, plan)
+	} else {
+		err = c.planFromEVM(ctx, request, plan)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if plan.Code, err = bridge.GetCadenceTransactionCode(plan.Path, c.BridgeEnv, c.CoreEnv); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Sends the transaction of plan signed by signer. The onboarding of a plan
+// that is not inline has to be sent first
+func (c *BridgeClient) SendPlan(ctx context.Context, signer string, plan *Plan) (*flow.TransactionResult, error) {
+	return c.send(ctx, signer, plan.Path, plan.Code, plan.Arguments)
+}
+
+// Sends the transaction of plan like SendPlan with the given compute limit
+func (c *BridgeClient) sendPlanWithLimit(ctx context.Context, signer string, plan *Plan, computeLimit uint64) (*flow.TransactionResult, error) {
+	return c.sendWithLimit(ctx, signer, plan.Path, plan.Code, plan.Arguments, computeLimit)
+}
+
+// Sets the kind, type and EVM address of the asset in plan
+func (c *BridgeClient) resolveAsset(ctx context.Context, asset string, plan *Plan) error {
+	flowTokenVault := "A." + strings.TrimPrefix(c.CoreEnv.FlowTokenAddress, "0x") + ".FlowToken.Vault"
+	switch {
+	case asset == "FLOW" || asset == flowTokenVault:
+		plan.Kind = AssetFLOW
+		return nil
+	case cadenceTypeIdentifier.MatchString(asset):
+		plan.Type = asset
+		switch {
+		case strings.HasSuffix(asset, ".NFT"):
+			plan.Kind = AssetNFT
+		case strings.HasSuffix(asset, ".Vault"):
+			plan.Kind = AssetToken
+		default:
+			return fmt.Errorf("Cannot bridge %s: neither an NFT nor a Vault", asset)
+		}
+		requiresOnboarding, err := c.TypeRequiresOnboarding(ctx, asset)
+		if err != nil {
+			return err
+		}
+		if requiresOnboarding == nil {
+			return fmt.Errorf("Cannot bridge %s: type does not exist or is not supported by the bridge", asset)
+		}
+		if plan.EVMAddress, err = c.AssociatedEVMAddress(ctx, asset); err != nil {
+			return err
+		}
+		if *requiresOnboarding {
+			plan.Onboarding = &Onboarding{Path: pathOnboardByTypeIdentifier, Arguments: []cadence.Value{cadence.String(asset)}}
+		}
+		return nil
+	case evmAddress.MatchString(asset):
+		address := strings.ToLower(strings.TrimPrefix(asset, "0x"))
+		plan.EVMAddress = &address
+		isERC721, err := query(ctx, c, results.Bool, pathIsERC721, cadence.String(address))
+		if err != nil {
+			return err
+		}
+		plan.Kind = AssetToken
+		if isERC721 {
+			plan.Kind = AssetNFT
+		}
+		associated, err := c.AssociatedType(ctx, address)
+		if err != nil {
+			return err
+		}
+		if associated != nil {
+			plan.Type = *associated
+			return nil
+		}
+		requiresOnboarding, err := c.EVMAddressRequiresOnboarding(ctx, address)
+		if err != nil {
+			return err
+		}
+		if requiresOnboarding == nil {
+			return fmt.Errorf("Cannot bridge %s: neither an ERC721 nor an ERC20", asset)
+		}
+		plan.Onboarding = &Onboarding{Path: pathOnboardByEVMAddress, Arguments: []cadence.Value{cadence.String(address)}}
+		return c.deriveType(ctx, address, plan)
+	default:
+		return fmt.Errorf("Cannot bridge %s: neither a Cadence type identifier nor an EVM address", asset)
+	}
+}
+
+// Sets the type of an EVM asset that has not been onboarded: the type its
+// contract declares, or the type the bridge will define for it
+func (c *BridgeClient) deriveType(ctx context.Context, address string, plan *Plan) error {
+	declared, err := query(ctx, c, results.OptionalType, pathGetDeclaredCadenceType, cadence.String(address))
+	if err != nil {
+		return err
+	}
+	if declared != nil {
+		plan.Type = *declared
+		return nil
+	}
+	path, resource := pathDeriveBridgedTokenContractName, "Vault"
+	if plan.Kind == AssetNFT {
+		path, resource = pathDeriveBridgedNFTContractName, "NFT"
+	}
+	name, err := query(ctx, c, results.String, path, cadence.String(address))
+	if err != nil {
+		return err
+	}
+	plan.Type = "A." + strings.TrimPrefix(c.BridgeEnv.FlowEVMBridgeAddress, "0x") + "." + name + "." + resource
+	return nil
+}
+
+func (c *BridgeClient) planFLOW(request TransferRequest, toEVM bool, plan *Plan) error {
+	amount, err := parseUFix64(request.Amount)
+	if err != nil {
+		return fmt.Errorf("Cannot transfer %s FLOW: %w", request.Amount, err)
+	}
+	if request.From == VMCadence {
+		plan.Path = pathTransferFlowToCadenceOrEVM
+		plan.Arguments = []cadence.Value{cadence.String(request.Recipient), amount}
+		return nil
+	}
+	if toEVM || flow.HexToAddress(request.Recipient) != request.Signer {
+		return fmt.Errorf("Cannot transfer FLOW from EVM to %s: FLOW can only be withdrawn from a COA to its owner", request.Recipient)
+	}
+	plan.Path = pathWithdrawFlowFromCOA
+	plan.Arguments = []cadence.Value{amount}
+	return nil
+}
+
+func (c *BridgeClient) planToEVM(ctx context.Context, request TransferRequest, plan *Plan) error {
+	recipient := strings.ToLower(strings.TrimPrefix(request.Recipient, "0x"))
+	toSigner := recipient == strings.ToLower(strings.TrimPrefix(request.COA, "0x"))
+	batch := len(request.IDs) > 1
+
+	var amount cadence.Value
+	switch plan.Kind {
+	case AssetNFT:
+		ids, err := uint64IDs(request.IDs)
+		if err != nil {
+			return err
+		}
+		amount = ids
+		if !batch {
+			amount = ids.Values[0]
+		}
+		plan.Path = selectPath(toSigner, batch,
+			pathBridgeNFTToEVM, pathBatchBridgeNFTToEVM, pathBridgeNFTToAnyEVMAddress, pathBatchBridgeNFTToAnyEVMAddress)
+	case AssetToken:
+		ufix64, err := parseUFix64(request.Amount)
+		if err != nil {
+			return fmt.Errorf("Cannot bridge %s of %s: %w", request.Amount, plan.Type, err)
+		}
+		amount = ufix64
+		plan.Path = selectPath(toSigner, false, pathBridgeTokensToEVM, "", pathBridgeTokensToAnyEVMAddress, "")
+	}
+	plan.Arguments = []cadence.Value{cadence.String(plan.Type), amount}
+	if !toSigner {
+		plan.Arguments = append(plan.Arguments, cadence.String(recipient))
+	}
+
+	if plan.Onboarding != nil {
+		// The transactions bridging to EVM onboard the asset themselves
+		plan.Onboarding.Inline = true
+	}
+	return c.setFee(ctx, plan, batch, len(request.IDs))
+}
+
+func (c *BridgeClient) planFromEVM(ctx context.Context, request TransferRequest, plan *Plan) error {
+	if plan.EVMAddress == nil {
+		return fmt.Errorf("Cannot bridge %s from EVM: %s must be onboarded before bridging from EVM", request.Asset, plan.Type)
+	}
+	recipient := flow.HexToAddress(request.Recipient)
+	toSigner := recipient == request.Signer
+	batch := len(request.IDs) > 1
+
+	var amount cadence.Value
+	switch plan.Kind {
+	case AssetNFT:
+		ids, err := uint256IDs(request.IDs)
+		if err != nil {
+			return err
+		}
+		amount = ids
+		if !batch {
+			amount = ids.Values[0]
+		}
+		plan.Path = selectPath(toSigner, batch,
+			pathBridgeNFTFromEVM, pathBatchBridgeNFTFromEVM, pathBridgeNFTToAnyCadenceAddress, pathBatchBridgeNFTToAnyCadenceAddress)
+	case AssetToken:
+		decimals, err := query(ctx, c, results.UInt8, pathGetTokenDecimals, cadence.String(*plan.EVMAddress))
+		if err != nil {
+			return err
+		}
+		units, err := parseUnits(request.Amount, decimals)
+		if err != nil {
+			return fmt.Errorf("Cannot bridge %s of %s: %w", request.Amount, plan.Type, err)
+		}
+		if amount, err = cadence.NewUInt256FromBig(units); err != nil {
+			return err
+		}
+		plan.Path = selectPath(toSigner, false, pathBridgeTokensFromEVM, "", pathBridgeTokensToAnyCadenceAddress, "")
+	}
+	plan.Arguments = []cadence.Value{cadence.String(plan.Type), amount}
+	if !toSigner {
+		plan.Arguments = append(plan.Arguments, cadence.NewAddress(recipient))
+	}
+	return c.setFee(ctx, plan, batch, len(request.IDs))
+}
+
+// Sets the fee of plan and its onboarding like the bridge transactions cap
+// theirs: the bridge fee of the storage they may use, the base fee of each
+// NFT in batches, and the onboarding fee if they onboard the asset
+func (c *BridgeClient) setFee(ctx context.Context, plan *Plan, batch bool, count int) error {
+	fee, err := c.BridgeFee(ctx, maxBridgedBytes)
+	if err != nil {
+		return err
+	}
+	if batch {
+		baseFee, err := c.BaseFee(ctx)
+		if err != nil {
+			return err
+		}
+		fee += baseFee * cadence.UFix64(count)
+	}
+	if plan.Onboarding != nil {
+		if plan.Onboarding.Fee, err = c.OnboardFee(ctx); err != nil {
+			return err
+		}
+		if plan.Onboarding.Inline {
+			fee += plan.Onboarding.Fee
+		} else if plan.Onboarding.Code, err = bridge.GetCadenceTransactionCode(plan.Onboarding.Path, c.BridgeEnv, c.CoreEnv); err != nil {
+			return err
+		}
+	}
+	plan.Fee = fee
+	return nil
+}
+
+// Returns the template bridging to the signer or any recipient, batched or
+// not
+func selectPath(toSigner bool, batch bool, single, batchSingle, anyRecipient, batchAnyRecipient string) string {
+	switch {
+	case toSigner && batch:
+		return batchSingle
+	case toSigner:
+		return single
+	case batch:
+		return batchAnyRecipient
+	default:
+		return anyRecipient
+	}
+}
+
+func uint64IDs(ids []*big.Int) (cadence.Array, error) {
+	if len(ids) == 0 {
+		return cadence.Array{}, fmt.Errorf("Cannot bridge NFTs: no IDs given")
+	}
+	values := make([]cadence.Value, len(ids))
+	for i, id := range ids {
+		if id.Sign() < 0 || !id.IsUint64() {
+			return cadence.Array{}, fmt.Errorf("Cannot bridge NFT %s: Cadence IDs are UInt64", id)
+		}
+		values[i] = cadence.UInt64(id.Uint64())
+	}
+	return cadence.NewArray(values), nil
+}
+
+func uint256IDs(ids []*big.Int) (cadence.Array, error) {
+	if len(ids) == 0 {
+		return cadence.Array{}, fmt.Errorf("Cannot bridge NFTs: no IDs given")
+	}
+	values := make([]cadence.Value, len(ids))
+	for i, id := range ids {
+		value, err := cadence.NewUInt256FromBig(id)
+		if err != nil {
+			return cadence.Array{}, fmt.Errorf("Cannot bridge NFT %s: %w", id, err)
+		}
+		values[i] = value
+	}
+	return cadence.NewArray(values), nil
+}
+
+// Parses a decimal amount of a Cadence token, such as 1.5
+func parseUFix64(amount string) (cadence.UFix64, error) {
+	units, err := parseUnits(amount, bridge.UFix64Decimals)
+	if err != nil {
+		return 0, err
+	}
+	if !units.IsUint64() {
+		return 0, fmt.Errorf("amount does not fit in UFix64")
+	}
+	return cadence.UFix64(units.Uint64()), nil
+}
+
+// Parses a decimal amount, such as 1.5, into the smallest unit of a token
+// with the given decimals
+func parseUnits(amount string, decimals uint8) (*big.Int, error) {
+	integer, fraction, _ := strings.Cut(amount, ".")
+	if len(fraction) > int(decimals) {
+		return nil, fmt.Errorf("more than %d decimals", decimals)
+	}
+	units, ok := new(big.Int).SetString(integer+fraction+strings.Repeat("0", int(decimals)-len(fraction)), 10)
+	if !ok || units.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount")
+	}
+	return units, nil
+}
@@ -0,0 +1,199 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/client"
+)
+
+const (
+	alice    = "0000000000000a11"
+	aliceCOA = "000000000000000000000002b87c966bc00bc2c4"
+	bob      = "0000000000000b0b"
+	bobEVM   = "00000000000000000000000000000000000000b0"
+	erc20    = "00000000000000000000000000000000000000cd"
+)
+
+// Returns a fake bridge charging 0.001 FLOW per transaction, 0.0001 FLOW per
+// NFT and 1 FLOW for onboarding, where ExampleNFT is onboarded
+func newPlannerClient(t *testing.T) (*client.BridgeClient, *client.Fake) {
+	c, fake := newClient(t)
+	fake.OnScript("calculateBridgeFee", cadence.UFix64(100_000))
+	fake.OnScript("FlowEVMBridgeConfig.baseFee", cadence.UFix64(10_000))
+	fake.OnScript("FlowEVMBridgeConfig.onboardFee", cadence.UFix64(100_000_000))
+	fake.OnScript("FlowEVMBridge.typeRequiresOnboarding", cadence.NewOptional(cadence.Bool(false)))
+	fake.OnScript("getEVMAddressAssociated", cadence.NewOptional(cadence.String(erc721)))
+	fake.OnScript("getTypeAssociated", cadence.NewOptional(nil))
+	fake.OnScript("getDeclaredCadenceTypeFromCrossVM", cadence.NewOptional(nil))
+	fake.OnScript("isERC721", cadence.Bool(false))
+	return c, fake
+}
+
+func ids(values ...int64) []*big.Int {
+	result := make([]*big.Int, len(values))
+	for i, value := range values {
+		result[i] = big.NewInt(value)
+	}
+	return result
+}
+
+func TestPlanNFT(t *testing.T) {
+	c, _ := newPlannerClient(t)
+	ctx := context.Background()
+	request := client.TransferRequest{
+		Asset:     exampleNFT,
+		IDs:       ids(42),
+		From:      client.VMCadence,
+		Recipient: "0x" + aliceCOA,
+		Signer:    flow.HexToAddress(alice),
+		COA:       aliceCOA,
+	}
+
+	plan, err := c.Plan(ctx, request)
+	require.Nil(t, err)
+	assert.Equal(t, client.AssetNFT, plan.Kind)
+	assert.Equal(t, "cadence/transactions/bridge/nft/bridge_nft_to_evm.cdc", plan.Path)
+	assert.Equal(t, []cadence.Value{cadence.String(exampleNFT), cadence.UInt64(42)}, plan.Arguments)
+	assert.Equal(t, erc721, *plan.EVMAddress)
+	assert.Equal(t, "0.00100000", plan.Fee.String())
+	assert.Nil(t, plan.Onboarding)
+	assert.Contains(t, string(plan.Code), "0x1e4aa0b87d10b141")
+
+	request.Recipient = bobEVM
+	request.IDs = ids(1, 2, 3)
+	plan, err = c.Plan(ctx, request)
+	require.Nil(t, err)
+	assert.Equal(t, "cadence/transactions/bridge/nft/batch_bridge_nft_to_any_evm_address.cdc", plan.Path)
+	assert.Equal(t, []cadence.Value{
+		cadence.String(exampleNFT),
+		cadence.NewArray([]cadence.Value{cadence.UInt64(1), cadence.UInt64(2), cadence.UInt64(3)}),
+		cadence.String(bobEVM),
+	}, plan.Arguments)
+	assert.Equal(t, "0.00130000", plan.Fee.String())
+
+	request.From = client.VMEVM
+	request.Recipient = "0x" + alice
+	request.IDs = ids(42)
+	plan, err = c.Plan(ctx, request)
+	require.Nil(t, err)
+	assert.Equal(t, "cadence/transactions/bridge/nft/bridge_nft_from_evm.cdc", plan.Path)
+	assert.Equal(t, []cadence.Value{cadence.String(exampleNFT), cadence.NewUInt256(42)}, plan.Arguments)
+
+	request.Recipient = bob
+	request.IDs = ids(1, 2)
+	plan, err = c.Plan(ctx, request)
+	require.Nil(t, err)
+	assert.Equal(t, "cadence/transactions/bridge/nft/batch_bridge_nft_to_any_cadence_address.cdc", plan.Path)
+	assert.Equal(t, cadence.NewAddress(flow.HexToAddress(bob)), plan.Arguments[2])
+
+	request.Recipient = bobEVM
+	_, err = c.Plan(ctx, request)
+	assert.ErrorContains(t, err, "recipient is in the source VM")
+}
+
+func TestPlanOnboarding(t *testing.T) {
+	c, fake := newPlannerClient(t)
+	ctx := context.Background()
+	fake.OnScript("FlowEVMBridge.typeRequiresOnboarding", cadence.NewOptional(cadence.Bool(true)))
+	fake.OnScript("getEVMAddressAssociated", cadence.NewOptional(nil))
+
+	// Bridging to EVM onboards the type in the same transaction
+	plan, err := c.Plan(ctx, client.TransferRequest{
+		Asset:     exampleNFT,
+		IDs:       ids(42),
+		From:      client.VMCadence,
+		Recipient: bobEVM,
+	})
+	require.Nil(t, err)
+	assert.Nil(t, plan.EVMAddress)
+	require.NotNil(t, plan.Onboarding)
+	assert.True(t, plan.Onboarding.Inline)
+	assert.Equal(t, "1.00100000", plan.Fee.String())
+
+	// Cadence-native assets without an EVM contract cannot come from EVM
+	_, err = c.Plan(ctx, client.TransferRequest{
+		Asset:     "A.0ae53cb6e3f42a79.ExampleToken.Vault",
+		Amount:    "1.5",
+		From:      client.VMEVM,
+		Recipient: alice,
+		Signer:    flow.HexToAddress(alice),
+	})
+	assert.ErrorContains(t, err, "must be onboarded before bridging from EVM")
+	_, err = c.Plan(ctx, client.TransferRequest{Asset: exampleNFT, IDs: ids(42), From: client.VMEVM, Recipient: alice})
+	assert.ErrorContains(t, err, "must be onboarded before bridging from EVM")
+
+	// EVM-native ERC20s have to be onboarded before bridging from EVM
+	fake.OnScript("FlowEVMBridge.evmAddressRequiresOnboarding", cadence.NewOptional(cadence.Bool(true)))
+	fake.OnScript("deriveBridgedTokenContractName", cadence.String("EVMVMBridgedToken_"+erc20))
+	fake.OnScript("getTokenDecimals", cadence.UInt8(18))
+	plan, err = c.Plan(ctx, client.TransferRequest{
+		Asset:     "0x" + erc20,
+		Amount:    "1.5",
+		From:      client.VMEVM,
+		Recipient: alice,
+		Signer:    flow.HexToAddress(alice),
+	})
+	require.Nil(t, err)
+	assert.Equal(t, client.AssetToken, plan.Kind)
+	assert.Equal(t, "A.1e4aa0b87d10b141.EVMVMBridgedToken_"+erc20+".Vault", plan.Type)
+	assert.Equal(t, "cadence/transactions/bridge/tokens/bridge_tokens_from_evm.cdc", plan.Path)
+	amount, _ := new(big.Int).SetString("1500000000000000000", 10)
+	evmAmount, err := cadence.NewUInt256FromBig(amount)
+	require.Nil(t, err)
+	assert.Equal(t, []cadence.Value{cadence.String(plan.Type), evmAmount}, plan.Arguments)
+	require.NotNil(t, plan.Onboarding)
+	assert.False(t, plan.Onboarding.Inline)
+	assert.Equal(t, "cadence/transactions/bridge/onboarding/onboard_by_evm_address.cdc", plan.Onboarding.Path)
+	assert.Equal(t, []cadence.Value{cadence.String(erc20)}, plan.Onboarding.Arguments)
+	assert.NotEmpty(t, plan.Onboarding.Code)
+	assert.Equal(t, "1.00000000", plan.Onboarding.Fee.String())
+	assert.Equal(t, "0.00100000", plan.Fee.String())
+}
+
+func TestPlanTokens(t *testing.T) {
+	c, _ := newPlannerClient(t)
+	ctx := context.Background()
+	vault := "A.0ae53cb6e3f42a79.ExampleToken.Vault"
+
+	plan, err := c.Plan(ctx, client.TransferRequest{Asset: vault, Amount: "2.5", From: client.VMCadence, Recipient: bobEVM})
+	require.Nil(t, err)
+	assert.Equal(t, "cadence/transactions/bridge/tokens/bridge_tokens_to_any_evm_address.cdc", plan.Path)
+	assert.Equal(t, []cadence.Value{cadence.String(vault), cadence.UFix64(250_000_000), cadence.String(bobEVM)}, plan.Arguments)
+
+	_, err = c.Plan(ctx, client.TransferRequest{Asset: vault, Amount: "2.5.1", From: client.VMCadence, Recipient: bobEVM})
+	assert.ErrorContains(t, err, "Cannot bridge 2.5.1")
+}
+
+func TestPlanFLOW(t *testing.T) {
+	c, _ := newPlannerClient(t)
+	ctx := context.Background()
+
+	plan, err := c.Plan(ctx, client.TransferRequest{Asset: "FLOW", Amount: "10", From: client.VMCadence, Recipient: "0x" + bobEVM})
+	require.Nil(t, err)
+	assert.Equal(t, client.AssetFLOW, plan.Kind)
+	assert.Equal(t, "cadence/transactions/flow-token/transfer_flow_to_cadence_or_evm.cdc", plan.Path)
+	assert.Equal(t, []cadence.Value{cadence.String("0x" + bobEVM), cadence.UFix64(1_000_000_000)}, plan.Arguments)
+	assert.Zero(t, plan.Fee)
+
+	request := client.TransferRequest{
+		Asset:     "A.1654653399040a61.FlowToken.Vault",
+		Amount:    "10",
+		From:      client.VMEVM,
+		Recipient: alice,
+		Signer:    flow.HexToAddress(alice),
+	}
+	plan, err = c.Plan(ctx, request)
+	require.Nil(t, err)
+	assert.Equal(t, "cadence/transactions/evm/withdraw.cdc", plan.Path)
+
+	request.Recipient = bob
+	_, err = c.Plan(ctx, request)
+	assert.ErrorContains(t, err, "FLOW can only be withdrawn from a COA to its owner")
+}
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+
+	"github.com/onflow/flow-evm-bridge/results"
+)
+
+const (
+	pathGetCOAAddress                = "cadence/scripts/evm/get_evm_address_string.cdc"
+	pathGetAllVaultInfo              = "cadence/scripts/tokens/get_all_vault_info_from_storage.cdc"
+	pathGetNFTIDs                    = "cadence/scripts/nft/get_ids.cdc"
+	pathBatchGetAssociatedEVMAddress = "cadence/scripts/bridge/batch_get_associated_evm_address.cdc"
+	pathBatchTypeRequiresOnboarding  = "cadence/scripts/bridge/batch_type_requires_onboarding.cdc"
+)
+
+// NFTCollection is a collection an account may store, listed by Portfolio
+type NFTCollection struct {
+	// Cadence type identifier of the NFT
+	Type string `json:"type"`
+	// Identifier of the storage path of the collection, such as
+	// exampleNFTCollection
+	StoragePath string `json:"storagePath"`
+}
+
+// PortfolioAsset is an asset held in the storage of an account or by its COA
+type PortfolioAsset struct {
+	Kind AssetKind `json:"kind"`
+	// Cadence type identifier of the Vault or NFT
+	Type string `json:"type"`
+	// Display name and symbol of fungible tokens
+	Name   string `json:"name,omitempty"`
+	Symbol string `json:"symbol,omitempty"`
+	// EVM contract associated with the type, nil if there is none
+	EVMAddress *string `json:"evmAddress,omitempty"`
+	// Whether the bridge supports the asset, onboarded or not
+	Bridgeable bool `json:"bridgeable"`
+	Onboarded  bool `json:"onboarded"`
+	// Decimals of the EVM balance of fungible assets, zero for NFTs
+	Decimals uint8 `json:"decimals"`
+	// Balance of the Vaults of the type in the account's storage, zero for
+	// NFTs
+	CadenceBalance cadence.UFix64 `json:"cadenceBalance"`
+	// IDs of the NFTs in the account's collection, nil for fungible assets
+	CadenceIDs []uint64 `json:"cadenceIDs,omitempty"`
+	// Balance of the COA: attoflow, ERC20 smallest units or ERC721 count
+	EVMBalance *big.Int `json:"evmBalance"`
+}
+
+// Portfolio is the assets of an account and its COA side by side
+type Portfolio struct {
+	Address flow.Address `json:"address"`
+	// EVM address of the account's COA, nil if it has none
+	COA *string `json:"coa,omitempty"`
+	// FLOW first, then the other assets by type
+	Assets []PortfolioAsset `json:"assets"`
+}
+
+// Returns the FLOW and fungible tokens in the storage of the account, the
+// NFTs of the given collections it stores, and the balances of its COA of
+// each asset onboarded to the bridge. Assets only held by the COA are not
+// discovered
+func (c *BridgeClient) Portfolio(ctx context.Context, address flow.Address, collections ...NFTCollection) (*Portfolio, error) {
+	account := cadence.NewAddress(address)
+	portfolio := &Portfolio{Address: address}
+	var err error
+	if portfolio.COA, err = query(ctx, c, results.OptionalString, pathGetCOAAddress, account); err != nil {
+		return nil, err
+	}
+	vaults, err := query(ctx, c, results.VaultInfos, pathGetAllVaultInfo, account)
+	if err != nil {
+		return nil, err
+	}
+
+	flowTokenVault := "A." + strings.TrimPrefix(c.CoreEnv.FlowTokenAddress, "0x") + ".FlowToken.Vault"
+	flowToken := PortfolioAsset{
+		Kind:       AssetFLOW,
+		Type:       flowTokenVault,
+		Name:       "FLOW",
+		Symbol:     "FLOW",
+		Bridgeable: true,
+		Onboarded:  true,
+		Decimals:   attoflowDecimals,
+		EVMBalance: big.NewInt(0),
+	}
+	if info, ok := vaults[flowTokenVault]; ok {
+		flowToken.CadenceBalance = info.Balance
+	}
+	if portfolio.COA != nil {
+		if flowToken.EVMBalance, err = query(ctx, c, results.BigInt, pathGetAttoflowBalance, cadence.String(*portfolio.COA)); err != nil {
+			return nil, err
+		}
+	}
+
+	assets := []PortfolioAsset{}
+	for typ, info := range vaults {
+		if typ == flowTokenVault {
+			continue
+		}
+		assets = append(assets, PortfolioAsset{Kind: AssetToken, Type: typ, Name: info.Name, Symbol: info.Symbol, CadenceBalance: info.Balance})
+	}
+	for _, collection := range collections {
+		if !cadenceTypeIdentifier.MatchString(collection.Type) {
+			return nil, fmt.Errorf("Cannot list collection of %s: not a Cadence type identifier", collection.Type)
+		}
+		ids, err := query(ctx, c, results.OptionalUInt64s, pathGetNFTIDs, account, cadence.String(collection.StoragePath))
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, PortfolioAsset{Kind: AssetNFT, Type: collection.Type, CadenceIDs: ids})
+	}
+	slices.SortFunc(assets, func(a, b PortfolioAsset) int { return strings.Compare(a.Type, b.Type) })
+
+	if err := c.addEVMBalances(ctx, address, portfolio.COA, assets); err != nil {
+		return nil, err
+	}
+	portfolio.Assets = append([]PortfolioAsset{flowToken}, assets...)
+	return portfolio, nil
+}
+
+// Sets the association, onboarding and COA balance of the assets
+func (c *BridgeClient) addEVMBalances(ctx context.Context, address flow.Address, coa *string, assets []PortfolioAsset) error {
+	if len(assets) == 0 {
+		return nil
+	}
+	identifiers := make([]cadence.Value, len(assets))
+	types := make([]cadence.Value, len(assets))
+	for i, asset := range assets {
+		identifiers[i] = cadence.String(asset.Type)
+		typ, err := typeValue(asset.Type)
+		if err != nil {
+			return err
+		}
+		types[i] = typ
+	}
+	associations, err := query(ctx, c, results.StringMap, pathBatchGetAssociatedEVMAddress, cadence.NewArray(identifiers))
+	if err != nil {
+		return err
+	}
+	onboarding, err := query(ctx, c, results.BoolMap, pathBatchTypeRequiresOnboarding, cadence.NewArray(types))
+	if err != nil {
+		return err
+	}
+
+	for i := range assets {
+		asset := &assets[i]
+		asset.EVMAddress = associations[asset.Type]
+		asset.EVMBalance = big.NewInt(0)
+		requiresOnboarding := onboarding[asset.Type]
+		asset.Bridgeable = requiresOnboarding != nil
+		asset.Onboarded = requiresOnboarding != nil && !*requiresOnboarding
+		if coa == nil || asset.EVMAddress == nil {
+			continue
+		}
+
+		if asset.Kind == AssetNFT {
+			if asset.EVMBalance, err = query(ctx, c, results.BigInt, pathBalanceOf, cadence.String(*coa), cadence.String(*asset.EVMAddress)); err != nil {
+				return err
+			}
+			continue
+		}
+		balance, err := query(ctx, c, results.DecodeCadenceEVMBalance, pathGetFullCadenceEVMBalance,
+			cadence.NewAddress(address), cadence.NewOptional(cadence.String(asset.Type)), cadence.NewOptional(nil))
+		if err != nil {
+			return err
+		}
+		asset.Decimals, asset.EVMBalance = balance.Decimals, balance.EVMBalance
+	}
+	return nil
+}
+
+// Returns the Type argument of the Vault or NFT resource with the identifier
+func typeValue(identifier string) (cadence.TypeValue, error) {
+	if !cadenceTypeIdentifier.MatchString(identifier) {
+		return cadence.TypeValue{}, fmt.Errorf("Cannot pass %s as a Type: not a Cadence type identifier", identifier)
+	}
+	parts := strings.SplitN(identifier, ".", 4)
+	address, err := common.HexToAddress(parts[1])
+	if err != nil {
+		return cadence.TypeValue{}, err
+	}
+	location := common.NewAddressLocation(nil, address, parts[2])
+	return cadence.NewTypeValue(cadence.NewResourceType(location, parts[2]+"."+parts[3], nil, nil)), nil
+}
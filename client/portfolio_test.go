@@ -0,0 +1,131 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/client"
+)
+
+const unsupportedToken = "A.0000000000000001.Unsupported.Vault"
+
+// Returns an entry of get_all_vault_info_from_storage.cdc
+func vaultInfo(address string, contract string, symbol string, balance cadence.UFix64) cadence.KeyValuePair {
+	location := common.NewAddressLocation(nil, common.MustBytesToAddress(flow.HexToAddress(address).Bytes()), contract)
+	storage, _ := cadence.NewPath(common.PathDomainStorage, symbol+"Vault")
+	receiver, _ := cadence.NewPath(common.PathDomainPublic, symbol+"Receiver")
+	fields := []cadence.Field{}
+	for _, name := range []string{"name", "symbol", "balance", "tokenContractAddress", "tokenContractName", "storagePath", "receiverPath"} {
+		fields = append(fields, cadence.Field{Identifier: name, Type: cadence.AnyStructType})
+	}
+	info := cadence.NewStruct([]cadence.Value{
+		cadence.String(contract), cadence.String(symbol), balance, cadence.NewAddress(flow.HexToAddress(address)),
+		cadence.String(contract), storage, receiver,
+	}).WithType(cadence.NewStructType(location, "FTVaultInfo", fields, nil))
+	return cadence.KeyValuePair{
+		Key:   cadence.NewTypeValue(cadence.NewResourceType(location, contract+".Vault", nil, nil)),
+		Value: info,
+	}
+}
+
+func TestPortfolio(t *testing.T) {
+	c, fake := newPlannerClient(t)
+	fake.OnScript("?.address()", cadence.NewOptional(cadence.String(aliceCOA)))
+	fake.OnScript("forEachStored", cadence.NewDictionary([]cadence.KeyValuePair{
+		vaultInfo("1654653399040a61", "FlowToken", "FLOW", cadence.UFix64(500_000_000)),
+		vaultInfo("0ae53cb6e3f42a79", "ExampleToken", "EXAMPLE", cadence.UFix64(1_000_000_000)),
+		vaultInfo("0000000000000001", "Unsupported", "UNSUPPORTED", cadence.UFix64(100_000_000)),
+	}))
+	attoflow, _ := new(big.Int).SetString("2000000000000000000", 10)
+	attoflowBalance, _ := cadence.NewUIntFromBig(attoflow)
+	fake.OnScript(".balance().attoflow", attoflowBalance)
+	fake.OnScript("?.getIDs()", cadence.NewOptional(cadence.NewArray([]cadence.Value{cadence.UInt64(1), cadence.UInt64(2)})))
+	fake.OnScript("res.insert(key: identifier, address.toString())", cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: cadence.String(exampleToken), Value: cadence.NewOptional(cadence.String(erc20))},
+		{Key: cadence.String(exampleNFT), Value: cadence.NewOptional(cadence.String(erc721))},
+	}))
+	fake.OnScriptFunc("results.insert(key: type, FlowEVMBridge.typeRequiresOnboarding(type))", func(arguments []cadence.Value) (cadence.Value, error) {
+		pairs := []cadence.KeyValuePair{}
+		for _, typ := range arguments[0].(cadence.Array).Values {
+			requiresOnboarding := cadence.NewOptional(cadence.Bool(false))
+			if typ.(cadence.TypeValue).StaticType.ID() == unsupportedToken {
+				requiresOnboarding = cadence.NewOptional(nil)
+			}
+			pairs = append(pairs, cadence.KeyValuePair{Key: typ, Value: requiresOnboarding})
+		}
+		return cadence.NewDictionary(pairs), nil
+	})
+	fake.OnScript("FlowEVMBridgeUtils.balanceOf(", cadence.NewUInt256(3))
+	evmTokens, _ := cadence.NewUInt256FromBig(attoflow)
+	fake.OnScript("convertCadenceAmountToERC20Amount", cadence.NewArray([]cadence.Value{
+		cadence.UInt8(18), cadence.UFix64(1_000_000_000), evmTokens, evmTokens,
+	}))
+
+	portfolio, err := c.Portfolio(context.Background(), flow.HexToAddress(alice),
+		client.NFTCollection{Type: exampleNFT, StoragePath: "exampleNFTCollection"})
+	require.Nil(t, err)
+	require.NotNil(t, portfolio.COA)
+	assert.Equal(t, aliceCOA, *portfolio.COA)
+
+	types := []string{}
+	for _, asset := range portfolio.Assets {
+		types = append(types, asset.Type)
+	}
+	assert.Equal(t, []string{"A.1654653399040a61.FlowToken.Vault", unsupportedToken, exampleNFT, exampleToken}, types)
+
+	flowToken := portfolio.Assets[0]
+	assert.Equal(t, client.AssetFLOW, flowToken.Kind)
+	assert.Equal(t, cadence.UFix64(500_000_000), flowToken.CadenceBalance)
+	assert.Equal(t, attoflow, flowToken.EVMBalance)
+	assert.True(t, flowToken.Onboarded)
+
+	unsupported := portfolio.Assets[1]
+	assert.False(t, unsupported.Bridgeable)
+	assert.Nil(t, unsupported.EVMAddress)
+	assert.Equal(t, int64(0), unsupported.EVMBalance.Int64())
+
+	nft := portfolio.Assets[2]
+	assert.Equal(t, client.AssetNFT, nft.Kind)
+	assert.Equal(t, []uint64{1, 2}, nft.CadenceIDs)
+	assert.Equal(t, int64(3), nft.EVMBalance.Int64())
+	assert.True(t, nft.Onboarded)
+
+	token := portfolio.Assets[3]
+	assert.Equal(t, "EXAMPLE", token.Symbol)
+	assert.Equal(t, erc20, *token.EVMAddress)
+	assert.Equal(t, cadence.UFix64(1_000_000_000), token.CadenceBalance)
+	assert.Equal(t, uint8(18), token.Decimals)
+	assert.Equal(t, attoflow, token.EVMBalance)
+	assert.True(t, token.Bridgeable)
+	assert.True(t, token.Onboarded)
+}
+
+func TestPortfolioWithoutCOA(t *testing.T) {
+	c, fake := newPlannerClient(t)
+	fake.OnScript("?.address()", cadence.NewOptional(nil))
+	fake.OnScript("forEachStored", cadence.NewDictionary([]cadence.KeyValuePair{
+		vaultInfo("0ae53cb6e3f42a79", "ExampleToken", "EXAMPLE", cadence.UFix64(1_000_000_000)),
+	}))
+	fake.OnScript("res.insert(key: identifier, address.toString())", cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: cadence.String(exampleToken), Value: cadence.NewOptional(cadence.String(erc20))},
+	}))
+
+	fake.OnScript("results.insert(key: type, FlowEVMBridge.typeRequiresOnboarding(type))", cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: cadence.String(exampleToken), Value: cadence.NewOptional(cadence.Bool(false))},
+	}))
+
+	portfolio, err := c.Portfolio(context.Background(), flow.HexToAddress(alice))
+	require.Nil(t, err)
+	assert.Nil(t, portfolio.COA)
+	require.Len(t, portfolio.Assets, 2)
+	assert.Equal(t, cadence.UFix64(0), portfolio.Assets[0].CadenceBalance)
+	assert.Equal(t, int64(0), portfolio.Assets[1].EVMBalance.Int64())
+	assert.Equal(t, erc20, *portfolio.Assets[1].EVMAddress)
+}
@@ -0,0 +1,318 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+
+	"github.com/onflow/flow-evm-bridge/results"
+)
+
+const (
+	pathIsCadenceTypeBlocked                = "cadence/scripts/bridge/is_cadence_type_blocked.cdc"
+	pathIsEVMAddressBlocked                 = "cadence/scripts/bridge/is_evm_address_blocked.cdc"
+	pathGetEVMPointer                       = "cadence/scripts/nft/get_evm_pointer_from_identifier.cdc"
+	pathGetDeclaredCadenceType              = "cadence/scripts/utils/get_declared_cadence_type.cdc"
+	pathGetDeclaredCadenceAddress           = "cadence/scripts/utils/get_declared_cadence_address.cdc"
+	pathIsERC721                            = "cadence/scripts/utils/is_erc721.cdc"
+	pathSupportsCadenceNativeNFTInterfaces  = "cadence/scripts/utils/supports_cadence_native_nft_evm_interfaces.cdc"
+	pathSupportsICrossVMBridgeCallable      = "cadence/scripts/utils/supports_icross_vm_bridge_callable.cdc"
+	pathSupportsICrossVMBridgeERC721Fulfill = "cadence/scripts/utils/supports_icross_vm_bridge_erc721_fulfillment.cdc"
+	pathDeriveBridgedNFTContractName        = "cadence/scripts/utils/derive_bridged_nft_contract_name.cdc"
+	pathDeriveBridgedTokenContractName      = "cadence/scripts/utils/derive_bridged_token_contract_name.cdc"
+)
+
+var (
+	cadenceTypeIdentifier = regexp.MustCompile(`^A\.[0-9a-fA-F]{16}\.\w+\.\w+$`)
+	evmAddress            = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{40}$`)
+)
+
+// TargetKind is the kind of asset checked by a preflight
+type TargetKind string
+
+const (
+	TargetCadenceType TargetKind = "cadence-type"
+	TargetEVMContract TargetKind = "evm-contract"
+)
+
+// CrossVMSupport reports the cross-VM declarations and interfaces of an EVM
+// contract
+type CrossVMSupport struct {
+	EVMContractAddress string `json:"evmContractAddress"`
+	// Cadence type and address declared through ICrossVM, nil if none
+	DeclaredCadenceType    *string       `json:"declaredCadenceType"`
+	DeclaredCadenceAddress *flow.Address `json:"declaredCadenceAddress"`
+	ICrossVMBridgeCallable bool          `json:"iCrossVMBridgeCallable"`
+	// ICrossVMBridgeERC721Fulfillment, required with ICrossVMBridgeCallable
+	// for Cadence-native cross-VM NFTs
+	ICrossVMBridgeERC721Fulfillment bool `json:"iCrossVMBridgeERC721Fulfillment"`
+	CadenceNativeNFTInterfaces      bool `json:"cadenceNativeNFTInterfaces"`
+}
+
+// Report is the onboarding preflight of a Cadence type or EVM contract
+type Report struct {
+	// Cadence type identifier, or EVM address as hex without 0x prefix
+	Target string     `json:"target"`
+	Kind   TargetKind `json:"kind"`
+	// Nil if the asset cannot be bridged
+	RequiresOnboarding *bool `json:"requiresOnboarding"`
+	Blocked            bool  `json:"blocked"`
+	BridgePaused       bool  `json:"bridgePaused"`
+	// Nil unless the asset has been onboarded
+	TypePaused *bool `json:"typePaused"`
+	// Empty if the asset cannot be bridged
+	NativeVM results.NativeVM `json:"nativeVM,omitempty"`
+	// Association of an onboarded asset
+	AssociatedEVMAddress *string `json:"associatedEVMAddress,omitempty"`
+	AssociatedType       *string `json:"associatedType,omitempty"`
+	// EVMPointer view declared by a Cadence NFT type for cross-VM registration
+	EVMPointer *results.EVMPointer `json:"evmPointer,omitempty"`
+	// Cross-VM support of the target EVM contract, or of the EVM contract an
+	// EVMPointer points to
+	CrossVM *CrossVMSupport `json:"crossVM,omitempty"`
+	// Cadence contract the bridge deploys to define an EVM-native asset
+	DerivedContractName string `json:"derivedContractName,omitempty"`
+	// Registered as a custom cross-VM NFT instead of onboarded permissionlessly
+	RegistersCrossVM bool           `json:"registersCrossVM"`
+	OnboardFee       cadence.UFix64 `json:"onboardFee"`
+	// Whether onboarding is expected to succeed. Checks requiring a
+	// transaction, such as the fee payer's balance, are not covered
+	CanOnboard bool `json:"canOnboard"`
+	// Human-readable explanations of the report
+	Reasons []string `json:"reasons"`
+}
+
+func (r *Report) reason(format string, arguments ...any) {
+	r.Reasons = append(r.Reasons, fmt.Sprintf(format, arguments...))
+}
+
+// Reports whether a Cadence type identifier, such as
+// A.0ae53cb6e3f42a79.ExampleNFT.NFT, or EVM contract address needs
+// onboarding, can be onboarded and at which fee
+func (c *BridgeClient) Preflight(ctx context.Context, target string) (*Report, error) {
+	report := &Report{Target: target}
+	switch {
+	case cadenceTypeIdentifier.MatchString(target):
+		report.Kind = TargetCadenceType
+	case evmAddress.MatchString(target):
+		report.Kind = TargetEVMContract
+		report.Target = strings.ToLower(strings.TrimPrefix(target, "0x"))
+	default:
+		return nil, fmt.Errorf("Cannot preflight %s: neither a Cadence type identifier nor an EVM address", target)
+	}
+
+	var err error
+	if report.BridgePaused, err = c.IsPaused(ctx); err != nil {
+		return nil, err
+	}
+	if report.OnboardFee, err = c.OnboardFee(ctx); err != nil {
+		return nil, err
+	}
+	if report.BridgePaused {
+		report.reason("The bridge is paused")
+	}
+
+	if report.Kind == TargetCadenceType {
+		err = c.preflightCadenceType(ctx, report)
+	} else {
+		err = c.preflightEVMContract(ctx, report)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report.CanOnboard = report.CanOnboard && !report.BridgePaused && !report.Blocked
+	if report.CanOnboard {
+		report.reason("Onboarding costs %s FLOW", report.OnboardFee)
+	}
+	return report, nil
+}
+
+func (c *BridgeClient) preflightCadenceType(ctx context.Context, report *Report) error {
+	var err error
+	if report.RequiresOnboarding, err = c.TypeRequiresOnboarding(ctx, report.Target); err != nil {
+		return err
+	}
+	if report.RequiresOnboarding == nil {
+		report.reason("%s does not exist or is neither an NFT nor a fungible token Vault", report.Target)
+		return nil
+	}
+	if report.Blocked, err = query(ctx, c, results.Bool, pathIsCadenceTypeBlocked, cadence.String(report.Target)); err != nil {
+		return err
+	}
+	if report.Blocked {
+		report.reason("%s is blocked from onboarding", report.Target)
+	}
+	if report.TypePaused, err = c.IsTypePaused(ctx, report.Target); err != nil {
+		return err
+	}
+	if report.AssociatedEVMAddress, err = c.AssociatedEVMAddress(ctx, report.Target); err != nil {
+		return err
+	}
+	if report.EVMPointer, err = query(ctx, c, results.OptionalEVMPointer, pathGetEVMPointer, cadence.String(report.Target)); err != nil {
+		return err
+	}
+
+	switch {
+	case report.EVMPointer != nil:
+		report.NativeVM = report.EVMPointer.NativeVM
+	case c.BridgeEnv.IsBridgeDefinedType(report.Target):
+		report.NativeVM = results.NativeVMEVM
+	default:
+		report.NativeVM = results.NativeVMCadence
+	}
+
+	if !*report.RequiresOnboarding {
+		if report.AssociatedEVMAddress != nil {
+			report.reason("Already onboarded, associated with EVM contract %s", *report.AssociatedEVMAddress)
+		} else {
+			report.reason("Bridged through a token handler, onboarding is not needed")
+		}
+		if report.TypePaused != nil && *report.TypePaused {
+			report.reason("Bridging %s is paused", report.Target)
+		}
+		return nil
+	}
+	if report.NativeVM == results.NativeVMEVM && report.EVMPointer == nil {
+		report.reason("Defined by the bridge for an EVM-native asset, onboard its EVM contract instead")
+		return nil
+	}
+
+	report.CanOnboard = true
+	if report.EVMPointer == nil {
+		report.reason("Cadence-native asset, onboarding deploys a bridged EVM contract")
+		return nil
+	}
+
+	// Onboarding a type declaring an EVMPointer registers it as a custom
+	// cross-VM NFT, see FlowEVMBridge.registerCrossVMNFT
+	report.RegistersCrossVM = true
+	pointed := report.EVMPointer.EVMContractAddress
+	report.reason("Declares EVM contract %s through its EVMPointer view, onboarding registers it as a %s-native cross-VM NFT", pointed, vmName(report.NativeVM))
+	if report.CrossVM, err = c.crossVMSupport(ctx, pointed); err != nil {
+		return err
+	}
+	blocked, err := query(ctx, c, results.Bool, pathIsEVMAddressBlocked, cadence.String(pointed))
+	if err != nil {
+		return err
+	}
+	if blocked {
+		report.CanOnboard = false
+		report.reason("EVM contract %s is blocked from onboarding", pointed)
+	}
+	if report.CrossVM.DeclaredCadenceType == nil || *report.CrossVM.DeclaredCadenceType != report.Target {
+		report.CanOnboard = false
+		report.reason("EVM contract %s must declare %s through ICrossVM", pointed, report.Target)
+	}
+	switch report.NativeVM {
+	case results.NativeVMCadence:
+		if !report.CrossVM.CadenceNativeNFTInterfaces {
+			report.CanOnboard = false
+			report.reason("EVM contract %s must implement ICrossVMBridgeERC721Fulfillment and ICrossVMBridgeCallable for a Cadence-native NFT", pointed)
+		}
+	case results.NativeVMEVM:
+		report.reason("EVM-native cross-VM NFTs need an NFTFulfillmentMinter, register them with register_cross_vm_nft.cdc")
+	}
+	return nil
+}
+
+func (c *BridgeClient) preflightEVMContract(ctx context.Context, report *Report) error {
+	target := cadence.String(report.Target)
+	var err error
+	if report.Blocked, err = query(ctx, c, results.Bool, pathIsEVMAddressBlocked, target); err != nil {
+		return err
+	}
+	if report.Blocked {
+		report.reason("EVM contract %s is blocked from onboarding", report.Target)
+	}
+	if report.AssociatedType, err = c.AssociatedType(ctx, report.Target); err != nil {
+		return err
+	}
+	if report.AssociatedType != nil {
+		report.RequiresOnboarding = new(bool)
+		if report.TypePaused, err = c.IsTypePaused(ctx, *report.AssociatedType); err != nil {
+			return err
+		}
+		if c.BridgeEnv.IsBridgeDefinedType(*report.AssociatedType) {
+			report.NativeVM = results.NativeVMEVM
+		} else {
+			report.NativeVM = results.NativeVMCadence
+		}
+		report.reason("Already onboarded, associated with %s", *report.AssociatedType)
+		if report.TypePaused != nil && *report.TypePaused {
+			report.reason("Bridging %s is paused", *report.AssociatedType)
+		}
+		return nil
+	}
+
+	// Contracts declaring a Cadence type are registered as custom cross-VM
+	// NFTs, see FlowEVMBridge.onboardByEVMAddress
+	if report.CrossVM, err = c.crossVMSupport(ctx, report.Target); err != nil {
+		return err
+	}
+	if report.CrossVM.DeclaredCadenceType != nil && report.CrossVM.DeclaredCadenceAddress != nil {
+		report.RequiresOnboarding = new(bool)
+		*report.RequiresOnboarding = true
+		report.RegistersCrossVM = true
+		report.CanOnboard = true
+		report.reason("Declares %s through ICrossVM, onboarding registers it as a cross-VM NFT", *report.CrossVM.DeclaredCadenceType)
+		return nil
+	}
+
+	if report.RequiresOnboarding, err = c.EVMAddressRequiresOnboarding(ctx, report.Target); err != nil {
+		return err
+	}
+	if report.RequiresOnboarding == nil {
+		report.reason("%s is neither an ERC721 nor an ERC20", report.Target)
+		return nil
+	}
+	report.NativeVM = results.NativeVMEVM
+	report.CanOnboard = *report.RequiresOnboarding
+	isERC721, err := query(ctx, c, results.Bool, pathIsERC721, target)
+	if err != nil {
+		return err
+	}
+	path, standard := pathDeriveBridgedTokenContractName, "ERC20"
+	if isERC721 {
+		path, standard = pathDeriveBridgedNFTContractName, "ERC721"
+	}
+	if report.DerivedContractName, err = query(ctx, c, results.String, path, target); err != nil {
+		return err
+	}
+	report.reason("EVM-native %s, onboarding deploys Cadence contract %s", standard, report.DerivedContractName)
+	return nil
+}
+
+// Returns the cross-VM declarations and interfaces of an EVM contract
+func (c *BridgeClient) crossVMSupport(ctx context.Context, evmContractAddress string) (*CrossVMSupport, error) {
+	address := cadence.String(evmContractAddress)
+	support := &CrossVMSupport{EVMContractAddress: evmContractAddress}
+	var err error
+	if support.DeclaredCadenceType, err = query(ctx, c, results.OptionalType, pathGetDeclaredCadenceType, address); err != nil {
+		return nil, err
+	}
+	if support.DeclaredCadenceAddress, err = query(ctx, c, results.OptionalAddress, pathGetDeclaredCadenceAddress, address); err != nil {
+		return nil, err
+	}
+	if support.ICrossVMBridgeCallable, err = query(ctx, c, results.Bool, pathSupportsICrossVMBridgeCallable, address); err != nil {
+		return nil, err
+	}
+	if support.ICrossVMBridgeERC721Fulfillment, err = query(ctx, c, results.Bool, pathSupportsICrossVMBridgeERC721Fulfill, address); err != nil {
+		return nil, err
+	}
+	if support.CadenceNativeNFTInterfaces, err = query(ctx, c, results.Bool, pathSupportsCadenceNativeNFTInterfaces, address); err != nil {
+		return nil, err
+	}
+	return support, nil
+}
+
+func vmName(vm results.NativeVM) string {
+	if vm == results.NativeVMEVM {
+		return "EVM"
+	}
+	return "Cadence"
+}
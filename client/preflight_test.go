@@ -0,0 +1,135 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/client"
+	"github.com/onflow/flow-evm-bridge/results"
+)
+
+const erc721 = "00000000000000000000000000000000000000ab"
+
+// Returns a fake bridge that is not paused, charges 1 FLOW for onboarding
+// and knows no asset
+func newPreflightClient(t *testing.T) (*client.BridgeClient, *client.Fake) {
+	c, fake := newClient(t)
+	fake.OnScript("FlowEVMBridgeConfig.isPaused()", cadence.Bool(false))
+	fake.OnScript("FlowEVMBridgeConfig.onboardFee", cadence.UFix64(100_000_000))
+	fake.OnScript("FlowEVMBridge.typeRequiresOnboarding", cadence.NewOptional(nil))
+	fake.OnScript("FlowEVMBridge.evmAddressRequiresOnboarding", cadence.NewOptional(nil))
+	for _, match := range []string{"isCadenceTypeBlocked", "isEVMAddressBlocked", "isERC721", "supportsICrossVMBridgeCallable",
+		"supportsICrossVMBridgeERC721Fulfillment", "supportsCadenceNativeNFTEVMInterfaces"} {
+		fake.OnScript(match, cadence.Bool(false))
+	}
+	for _, match := range []string{"isTypePaused", "getEVMAddressAssociated", "getTypeAssociated", "getEVMPointerView",
+		"getDeclaredCadenceTypeFromCrossVM", "getDeclaredCadenceAddressFromCrossVM"} {
+		fake.OnScript(match, cadence.NewOptional(nil))
+	}
+	return c, fake
+}
+
+func exampleNFTType() cadence.TypeValue {
+	address, _ := common.HexToAddress("0ae53cb6e3f42a79")
+	return cadence.NewTypeValue(cadence.NewResourceType(common.NewAddressLocation(nil, address, "ExampleNFT"), "ExampleNFT.NFT", nil, nil))
+}
+
+func TestPreflightCadenceType(t *testing.T) {
+	c, fake := newPreflightClient(t)
+	fake.OnScript("FlowEVMBridge.typeRequiresOnboarding", cadence.NewOptional(cadence.Bool(true)))
+
+	report, err := c.Preflight(context.Background(), exampleNFT)
+	require.Nil(t, err)
+	assert.Equal(t, client.TargetCadenceType, report.Kind)
+	assert.True(t, *report.RequiresOnboarding)
+	assert.Equal(t, results.NativeVMCadence, report.NativeVM)
+	assert.True(t, report.CanOnboard)
+	assert.Equal(t, "1.00000000", report.OnboardFee.String())
+	assert.Contains(t, report.Reasons, "Onboarding costs 1.00000000 FLOW")
+
+	fake.OnScript("FlowEVMBridgeConfig.isPaused()", cadence.Bool(true))
+	fake.OnScript("isCadenceTypeBlocked", cadence.Bool(true))
+	report, err = c.Preflight(context.Background(), exampleNFT)
+	require.Nil(t, err)
+	assert.False(t, report.CanOnboard)
+	assert.Contains(t, report.Reasons, "The bridge is paused")
+	assert.Contains(t, report.Reasons, exampleNFT+" is blocked from onboarding")
+}
+
+func TestPreflightCadenceTypeWithEVMPointer(t *testing.T) {
+	c, fake := newPreflightClient(t)
+	fake.OnScript("FlowEVMBridge.typeRequiresOnboarding", cadence.NewOptional(cadence.Bool(true)))
+	fake.OnScript("getDeclaredCadenceTypeFromCrossVM", cadence.NewOptional(exampleNFTType()))
+
+	bytes := make([]cadence.Value, 20)
+	for i := range bytes {
+		bytes[i] = cadence.UInt8(0)
+	}
+	bytes[19] = cadence.UInt8(0xab)
+	location := common.NewAddressLocation(nil, common.Address{0x1}, "CrossVMMetadataViews")
+	evmAddress := cadence.NewStruct([]cadence.Value{cadence.NewArray(bytes)}).WithType(cadence.NewStructType(location, "EVM.EVMAddress",
+		[]cadence.Field{{Identifier: "bytes", Type: cadence.AnyStructType}}, nil))
+	vm := cadence.NewEnum([]cadence.Value{cadence.UInt8(0)}).WithType(cadence.NewEnumType(location, "CrossVMMetadataViews.VM", cadence.UInt8Type,
+		[]cadence.Field{{Identifier: "rawValue", Type: cadence.UInt8Type}}, nil))
+	pointer := cadence.NewStruct([]cadence.Value{exampleNFTType(), cadence.Address{0x1}, evmAddress, vm}).WithType(cadence.NewStructType(location, results.ViewEVMPointer, []cadence.Field{
+		{Identifier: "cadenceType", Type: cadence.AnyStructType},
+		{Identifier: "cadenceContractAddress", Type: cadence.AnyStructType},
+		{Identifier: "evmContractAddress", Type: cadence.AnyStructType},
+		{Identifier: "nativeVM", Type: cadence.AnyStructType},
+	}, nil))
+	fake.OnScript("getEVMPointerView", cadence.NewOptional(pointer))
+
+	report, err := c.Preflight(context.Background(), exampleNFT)
+	require.Nil(t, err)
+	assert.True(t, report.RegistersCrossVM)
+	assert.Equal(t, erc721, report.CrossVM.EVMContractAddress)
+	// A Cadence-native cross-VM NFT must implement the fulfillment interfaces
+	assert.False(t, report.CanOnboard)
+	assert.Contains(t, report.Reasons, "EVM contract "+erc721+" must implement ICrossVMBridgeERC721Fulfillment and ICrossVMBridgeCallable for a Cadence-native NFT")
+
+	fake.OnScript("supportsCadenceNativeNFTEVMInterfaces", cadence.Bool(true))
+	report, err = c.Preflight(context.Background(), exampleNFT)
+	require.Nil(t, err)
+	assert.True(t, report.CanOnboard)
+}
+
+func TestPreflightEVMContract(t *testing.T) {
+	c, fake := newPreflightClient(t)
+	fake.OnScript("FlowEVMBridge.evmAddressRequiresOnboarding", cadence.NewOptional(cadence.Bool(true)))
+	fake.OnScript("isERC721", cadence.Bool(true))
+	fake.OnScript("deriveBridgedNFTContractName", cadence.String("EVMVMBridgedNFT_"+erc721))
+
+	report, err := c.Preflight(context.Background(), "0x"+erc721)
+	require.Nil(t, err)
+	assert.Equal(t, client.TargetEVMContract, report.Kind)
+	assert.Equal(t, erc721, report.Target)
+	assert.Equal(t, results.NativeVMEVM, report.NativeVM)
+	assert.Equal(t, "EVMVMBridgedNFT_"+erc721, report.DerivedContractName)
+	assert.True(t, report.CanOnboard)
+
+	// Contracts declaring a Cadence type register as cross-VM NFTs
+	fake.OnScript("getDeclaredCadenceTypeFromCrossVM", cadence.NewOptional(exampleNFTType()))
+	fake.OnScript("getDeclaredCadenceAddressFromCrossVM", cadence.NewOptional(cadence.Address{0x1}))
+	report, err = c.Preflight(context.Background(), erc721)
+	require.Nil(t, err)
+	assert.True(t, report.RegistersCrossVM)
+	assert.Empty(t, report.DerivedContractName)
+
+	// Onboarded contracts
+	fake.OnScript("getTypeAssociated", cadence.NewOptional(exampleNFTType()))
+	fake.OnScript("isTypePaused", cadence.NewOptional(cadence.Bool(true)))
+	report, err = c.Preflight(context.Background(), erc721)
+	require.Nil(t, err)
+	assert.False(t, *report.RequiresOnboarding)
+	assert.False(t, report.CanOnboard)
+	assert.Equal(t, exampleNFT, *report.AssociatedType)
+	assert.Contains(t, report.Reasons, "Bridging "+exampleNFT+" is paused")
+
+	_, err = c.Preflight(context.Background(), "ExampleNFT")
+	assert.ErrorContains(t, err, "neither a Cadence type identifier nor an EVM address")
+}
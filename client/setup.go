@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/compose"
+	"github.com/onflow/flow-evm-bridge/results"
+)
+
+const (
+	pathHasCollectionConfigured = "cadence/scripts/nft/has_collection_configured.cdc"
+	pathHasVaultConfigured      = "cadence/scripts/tokens/has_vault_configured.cdc"
+
+	pathSetupGenericNFTCollection = "cadence/transactions/example-assets/setup/setup_generic_nft_collection.cdc"
+	pathSetupGenericVault         = "cadence/transactions/example-assets/setup/setup_generic_vault.cdc"
+)
+
+// Returns whether account has a collection or vault of the asset of plan
+// configured. FLOW vaults are always configured
+func (c *BridgeClient) IsConfigured(ctx context.Context, plan *Plan, account flow.Address) (bool, error) {
+	path := pathHasVaultConfigured
+	switch plan.Kind {
+	case AssetFLOW:
+		return true, nil
+	case AssetNFT:
+		path = pathHasCollectionConfigured
+	}
+	return query(ctx, c, results.Bool, path, cadence.String(plan.Type), cadence.NewAddress(account))
+}
+
+// Returns plan with the setup of the collection or vault of its asset
+// composed before the transfer if account, which must sign the transaction,
+// has none configured. Otherwise plan is returned as is
+func (c *BridgeClient) WithSetup(ctx context.Context, plan *Plan, account flow.Address) (*Plan, error) {
+	configured, err := c.IsConfigured(ctx, plan, account)
+	if err != nil || configured {
+		return plan, err
+	}
+	setupPath := pathSetupGenericVault
+	if plan.Kind == AssetNFT {
+		setupPath = pathSetupGenericNFTCollection
+	}
+	setup, err := bridge.GetCadenceTransactionCode(setupPath, c.BridgeEnv, c.CoreEnv)
+	if err != nil {
+		return nil, err
+	}
+	// The setup and bridge templates share the parameter naming the asset
+	composed, err := compose.Compose(
+		compose.Step{Code: setup, Arguments: []cadence.Value{cadence.String(plan.Type)}},
+		compose.Step{Code: plan.Code, Arguments: plan.Arguments},
+	)
+	if err != nil {
+		return nil, err
+	}
+	withSetup := *plan
+	withSetup.Setup = setupPath
+	withSetup.Code = composed.Code
+	withSetup.Arguments = composed.Arguments
+	return &withSetup, nil
+}
@@ -0,0 +1,45 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/client"
+)
+
+func TestWithSetup(t *testing.T) {
+	c, fake := newPlannerClient(t)
+	ctx := context.Background()
+	plan, err := c.Plan(ctx, client.TransferRequest{
+		Asset:     exampleNFT,
+		IDs:       ids(42),
+		From:      client.VMEVM,
+		Recipient: alice,
+		Signer:    flow.HexToAddress(alice),
+	})
+	require.Nil(t, err)
+
+	fake.OnScript("capabilities.exists(collectionData.publicPath)", cadence.Bool(true))
+	configured, err := c.WithSetup(ctx, plan, flow.HexToAddress(alice))
+	require.Nil(t, err)
+	assert.Same(t, plan, configured)
+
+	fake.OnScript("capabilities.exists(collectionData.publicPath)", cadence.Bool(false))
+	withSetup, err := c.WithSetup(ctx, plan, flow.HexToAddress(alice))
+	require.Nil(t, err)
+	assert.Equal(t, "cadence/transactions/example-assets/setup/setup_generic_nft_collection.cdc", withSetup.Setup)
+	assert.Equal(t, plan.Path, withSetup.Path)
+	assert.Equal(t, plan.Arguments, withSetup.Arguments)
+	assert.Contains(t, string(withSetup.Code), "step0()")
+	assert.Contains(t, string(withSetup.Code), "self.coa.withdrawNFT(")
+	assert.Empty(t, plan.Setup)
+
+	calls := fake.Calls()
+	last := calls[len(calls)-1]
+	assert.Equal(t, []cadence.Value{cadence.String(exampleNFT), cadence.NewAddress(flow.HexToAddress(alice))}, last.Arguments)
+}
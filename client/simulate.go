@@ -0,0 +1,260 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/results"
+	"github.com/onflow/flow-evm-bridge/txerrors"
+)
+
+const (
+	pathGetTokenBalance          = "cadence/scripts/tokens/get_balance.cdc"
+	pathGetFullCadenceEVMBalance = "cadence/scripts/tokens/get_full_cadence_evm_balance.cdc"
+	pathGetAttoflowBalance       = "cadence/scripts/evm/get_attoflow_balance.cdc"
+	pathBalanceOf                = "cadence/scripts/utils/balance_of.cdc"
+)
+
+// Storage path identifier of the FlowToken vault of accounts
+const flowTokenVaultPath = "flowTokenVault"
+
+// Decimals of FLOW in EVM
+const attoflowDecimals = 18
+
+// Holding is a balance of an asset measured by a simulation
+type Holding struct {
+	// Flow address of an account, or EVM address of a COA or EVM account
+	Owner string `json:"owner"`
+	// Cadence type identifier of a Vault, or FLOW
+	Asset string `json:"asset"`
+}
+
+// BalanceDelta is the change of a holding caused by a simulated transaction,
+// in the smallest unit of the asset in the VM of the owner
+type BalanceDelta struct {
+	Holding
+	Decimals uint8    `json:"decimals"`
+	Before   *big.Int `json:"before"`
+	After    *big.Int `json:"after"`
+	Delta    *big.Int `json:"delta"`
+}
+
+// Simulation is the preview of a transaction executed in a sandbox
+type Simulation struct {
+	// Result of the transfer, or of the onboarding sent before it if that failed
+	Result *flow.TransactionResult `json:"-"`
+	// Classified error of the failed transaction, nil if it succeeded
+	Error *txerrors.Error `json:"error,omitempty"`
+	// Events emitted by the onboarding and the transfer
+	Events []flow.Event `json:"-"`
+	// Fees deducted from the payer for executing the transactions
+	TransactionFee cadence.UFix64 `json:"transactionFee"`
+	// FLOW deposited into the bridge account, onboarding fees included
+	BridgeFee cadence.UFix64 `json:"bridgeFee"`
+	// Changes of the holdings, nil if a transaction failed
+	Deltas []BalanceDelta `json:"deltas,omitempty"`
+}
+
+// Returns whether the simulated transactions succeeded
+func (s *Simulation) Succeeded() bool {
+	return s.Error == nil
+}
+
+// Executes the transaction of plan signed by signer in a sandbox of the
+// Simulator and returns its events, fees and the changes of the holdings,
+// leaving the network untouched. The onboarding of a plan that is not inline
+// is sent first. A failed transaction is reported by the simulation, not as
+// an error
+func (c *BridgeClient) Simulate(ctx context.Context, signer string, plan *Plan, holdings ...Holding) (*Simulation, error) {
+	if c.Simulator == nil {
+		return nil, fmt.Errorf("Cannot simulate %s: client has no simulator", plan.Path)
+	}
+	for _, holding := range holdings {
+		if !flowAddress.MatchString(holding.Owner) && !evmAddress.MatchString(holding.Owner) {
+			return nil, fmt.Errorf("Cannot measure %s of %s: neither a Flow address nor an EVM address", holding.Asset, holding.Owner)
+		}
+	}
+	sandbox, err := c.Simulator.Sandbox(ctx)
+	if err != nil {
+		return nil, err
+	}
+	simulation, err := c.simulate(ctx, sandbox, signer, plan, holdings)
+	if closeErr := sandbox.Close(ctx); err == nil && closeErr != nil {
+		return nil, closeErr
+	}
+	return simulation, err
+}
+
+func (c *BridgeClient) simulate(ctx context.Context, sandbox Sandbox, signer string, plan *Plan, holdings []Holding) (*Simulation, error) {
+	forked := &BridgeClient{BridgeEnv: c.BridgeEnv, CoreEnv: c.CoreEnv, Scripts: sandbox, Transactions: sandbox}
+	before := make([]BalanceDelta, len(holdings))
+	for i, holding := range holdings {
+		var err error
+		if before[i], err = forked.measure(ctx, holding); err != nil {
+			return nil, err
+		}
+	}
+
+	simulation := &Simulation{}
+	bridgeAccount := flow.HexToAddress(c.BridgeEnv.FlowEVMBridgeAddress)
+	if plan.Onboarding != nil && !plan.Onboarding.Inline {
+		result, err := forked.send(ctx, signer, plan.Onboarding.Path, plan.Onboarding.Code, plan.Onboarding.Arguments)
+		if result == nil {
+			return nil, err
+		}
+		if !simulation.add(result, bridgeAccount) {
+			return simulation, nil
+		}
+	}
+	result, err := forked.SendPlan(ctx, signer, plan)
+	if result == nil {
+		return nil, err
+	}
+	if !simulation.add(result, bridgeAccount) {
+		return simulation, nil
+	}
+
+	for _, delta := range before {
+		after, err := forked.measure(ctx, delta.Holding)
+		if err != nil {
+			return nil, err
+		}
+		delta.Decimals, delta.After = after.Decimals, after.After
+		delta.Delta = new(big.Int).Sub(delta.After, delta.Before)
+		simulation.Deltas = append(simulation.Deltas, delta)
+	}
+	return simulation, nil
+}
+
+// Adds the events and fees of a transaction result, where bridgeAccount
+// collects the bridge fees. Returns whether the transaction succeeded
+func (s *Simulation) add(result *flow.TransactionResult, bridgeAccount flow.Address) bool {
+	s.Result = result
+	s.Events = append(s.Events, result.Events...)
+	for _, event := range result.Events {
+		fields := event.Value.FieldsMappedByName()
+		switch {
+		case strings.HasSuffix(event.Type, ".FlowFees.FeesDeducted"):
+			if amount, err := results.UFix64(fields["amount"]); err == nil {
+				s.TransactionFee += amount
+			}
+		case strings.HasSuffix(event.Type, ".FungibleToken.Deposited"):
+			s.BridgeFee += bridgeFeeDeposit(fields, bridgeAccount)
+		}
+	}
+	if result.Error != nil {
+		errors.As(txerrors.Classify(result.Error), &s.Error)
+		return false
+	}
+	return true
+}
+
+// Returns the amount of a FungibleToken.Deposited event depositing FLOW
+// into the bridge account, zero for any other deposit
+func bridgeFeeDeposit(fields map[string]cadence.Value, bridgeAccount flow.Address) cadence.UFix64 {
+	typ, _ := results.String(fields["type"])
+	to, _ := results.OptionalAddress(fields["to"])
+	if !strings.HasSuffix(typ, ".FlowToken.Vault") || to == nil || *to != bridgeAccount {
+		return 0
+	}
+	amount, _ := results.UFix64(fields["amount"])
+	return amount
+}
+
+// Returns the balance of a holding as the Before of a delta
+func (c *BridgeClient) measure(ctx context.Context, holding Holding) (BalanceDelta, error) {
+	delta := BalanceDelta{Holding: holding, Before: big.NewInt(0)}
+	owner := strings.TrimPrefix(holding.Owner, "0x")
+	onEVM := evmAddress.MatchString(holding.Owner)
+
+	switch {
+	case holding.Asset == "FLOW" && onEVM:
+		balance, err := query(ctx, c, results.BigInt, pathGetAttoflowBalance, cadence.String(owner))
+		if err != nil {
+			return delta, err
+		}
+		delta.Decimals, delta.Before = attoflowDecimals, balance
+	case holding.Asset == "FLOW":
+		balance, err := query(ctx, c, results.OptionalUFix64, pathGetTokenBalance,
+			cadence.NewAddress(flow.HexToAddress(owner)), cadence.String(flowTokenVaultPath))
+		if err != nil {
+			return delta, err
+		}
+		delta.Decimals = bridge.UFix64Decimals
+		if balance != nil {
+			delta.Before.SetUint64(uint64(*balance))
+		}
+	case onEVM:
+		erc20, err := c.AssociatedEVMAddress(ctx, holding.Asset)
+		if err != nil {
+			return delta, err
+		}
+		if erc20 == nil {
+			// Assets that have not been onboarded have no EVM balance
+			break
+		}
+		if delta.Decimals, err = query(ctx, c, results.UInt8, pathGetTokenDecimals, cadence.String(*erc20)); err != nil {
+			return delta, err
+		}
+		if delta.Before, err = query(ctx, c, results.BigInt, pathBalanceOf, cadence.String(owner), cadence.String(*erc20)); err != nil {
+			return delta, err
+		}
+	default:
+		balance, err := query(ctx, c, results.DecodeCadenceEVMBalance, pathGetFullCadenceEVMBalance,
+			cadence.NewAddress(flow.HexToAddress(owner)), cadence.NewOptional(cadence.String(holding.Asset)), cadence.NewOptional(nil))
+		if err != nil {
+			return delta, err
+		}
+		delta.Decimals = bridge.UFix64Decimals
+		delta.Before.SetUint64(uint64(balance.CadenceBalance))
+	}
+	delta.After = delta.Before
+	return delta, nil
+}
+
+// Plans the request and simulates it, measuring the FLOW of the signer and
+// its COA, and for fungible assets the balances of the signer, its COA and
+// the recipient. Moved NFTs are reported by the bridge events
+func (c *BridgeClient) SimulateTransfer(ctx context.Context, signer string, request TransferRequest) (*Plan, *Simulation, error) {
+	plan, err := c.Plan(ctx, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	owners := []string{}
+	if request.Signer != flow.EmptyAddress {
+		owners = append(owners, request.Signer.Hex())
+	}
+	if request.COA != "" {
+		owners = append(owners, strings.ToLower(strings.TrimPrefix(request.COA, "0x")))
+	}
+	holdings := []Holding{}
+	for _, owner := range owners {
+		holdings = append(holdings, Holding{Owner: owner, Asset: "FLOW"})
+	}
+	if plan.Kind != AssetNFT {
+		asset := plan.Type
+		if plan.Kind == AssetFLOW {
+			asset = "FLOW"
+		}
+		owners = append(owners, strings.ToLower(strings.TrimPrefix(request.Recipient, "0x")))
+		for _, owner := range owners {
+			holding := Holding{Owner: owner, Asset: asset}
+			if !slices.Contains(holdings, holding) {
+				holdings = append(holdings, holding)
+			}
+		}
+	}
+	simulation, err := c.Simulate(ctx, signer, plan, holdings...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plan, simulation, nil
+}
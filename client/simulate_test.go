@@ -0,0 +1,173 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/client"
+	"github.com/onflow/flow-evm-bridge/txerrors"
+)
+
+const exampleToken = "A.0ae53cb6e3f42a79.ExampleToken.Vault"
+
+func newEvent(address string, qualified string, fields map[string]cadence.Value) flow.Event {
+	location := common.NewAddressLocation(nil, common.MustBytesToAddress(flow.HexToAddress(address).Bytes()), "")
+	eventFields := []cadence.Field{}
+	values := []cadence.Value{}
+	for name, value := range fields {
+		eventFields = append(eventFields, cadence.Field{Identifier: name, Type: value.Type()})
+		values = append(values, value)
+	}
+	event := cadence.NewEvent(values).WithType(cadence.NewEventType(location, qualified, eventFields, nil))
+	return flow.Event{Type: "A." + address + "." + qualified, Value: event}
+}
+
+// Returns a client simulating transfers of ExampleToken where alice holds
+// 10 tokens and 5 FLOW, and transactions charge a 0.001 FLOW bridge fee and a
+// 0.0001 FLOW transaction fee
+func newSimulationClient(t *testing.T) (*client.BridgeClient, *client.Fake) {
+	c, fake := newPlannerClient(t)
+	c.Simulator = fake
+	cadenceTokens, evmTokens := uint64(1_000_000_000), big.NewInt(0)
+	flowBalance := uint64(500_000_000)
+
+	fake.OnScript(".balance().attoflow", cadence.NewUInt(7))
+	fake.OnScriptFunc("storage.borrow<&{FungibleToken.Vault}>", func(arguments []cadence.Value) (cadence.Value, error) {
+		return cadence.NewOptional(cadence.UFix64(flowBalance)), nil
+	})
+	fake.OnScript("FlowEVMBridgeUtils.getTokenDecimals(", cadence.UInt8(18))
+	fake.OnScriptFunc("FlowEVMBridgeUtils.balanceOf(", func(arguments []cadence.Value) (cadence.Value, error) {
+		return cadence.NewUInt256FromBig(new(big.Int).Set(evmTokens))
+	})
+	fake.OnScriptFunc("convertCadenceAmountToERC20Amount", func(arguments []cadence.Value) (cadence.Value, error) {
+		evm, _ := cadence.NewUInt256FromBig(new(big.Int).Set(evmTokens))
+		return cadence.NewArray([]cadence.Value{cadence.UInt8(18), cadence.UFix64(cadenceTokens), evm, evm}), nil
+	})
+
+	fake.OnTransactionFunc("FlowEVMBridge", func(arguments []cadence.Value) flow.TransactionResult {
+		amount := uint64(arguments[1].(cadence.UFix64))
+		if amount > cadenceTokens {
+			return flow.TransactionResult{Error: errors.New("Caller does not have sufficient balance to bridge requested tokens")}
+		}
+		cadenceTokens -= amount
+		evmTokens.Add(evmTokens, new(big.Int).Mul(new(big.Int).SetUint64(amount), big.NewInt(10_000_000_000)))
+		flowBalance -= 110_000
+		return flow.TransactionResult{Events: []flow.Event{
+			newEvent("f233dcee88fe0abe", "FungibleToken.Deposited", map[string]cadence.Value{
+				"type":   cadence.String("A.1654653399040a61.FlowToken.Vault"),
+				"amount": cadence.UFix64(100_000),
+				"to":     cadence.NewOptional(cadence.NewAddress(flow.HexToAddress("1e4aa0b87d10b141"))),
+			}),
+			newEvent("f233dcee88fe0abe", "FungibleToken.Deposited", map[string]cadence.Value{
+				"type":   cadence.String(exampleToken),
+				"amount": cadence.UFix64(amount),
+				"to":     cadence.NewOptional(cadence.NewAddress(flow.HexToAddress("1e4aa0b87d10b141"))),
+			}),
+			newEvent("f919ee77447b7497", "FlowFees.FeesDeducted", map[string]cadence.Value{
+				"amount": cadence.UFix64(10_000),
+			}),
+		}}
+	})
+	return c, fake
+}
+
+func TestSimulateTransfer(t *testing.T) {
+	c, _ := newSimulationClient(t)
+	request := client.TransferRequest{
+		Asset:     exampleToken,
+		Amount:    "1.5",
+		From:      client.VMCadence,
+		Recipient: "0x" + aliceCOA,
+		Signer:    flow.HexToAddress(alice),
+		COA:       aliceCOA,
+	}
+	plan, simulation, err := c.SimulateTransfer(context.Background(), "alice", request)
+	require.Nil(t, err)
+	assert.Equal(t, "cadence/transactions/bridge/tokens/bridge_tokens_to_evm.cdc", plan.Path)
+	require.True(t, simulation.Succeeded())
+	assert.Len(t, simulation.Events, 3)
+	assert.Equal(t, cadence.UFix64(10_000), simulation.TransactionFee)
+	assert.Equal(t, cadence.UFix64(100_000), simulation.BridgeFee)
+
+	deltas := map[client.Holding]string{}
+	for _, delta := range simulation.Deltas {
+		deltas[delta.Holding] = delta.Delta.String()
+	}
+	assert.Equal(t, map[client.Holding]string{
+		{Owner: alice, Asset: "FLOW"}:          "-110000",
+		{Owner: aliceCOA, Asset: "FLOW"}:       "0",
+		{Owner: alice, Asset: exampleToken}:    "-150000000",
+		{Owner: aliceCOA, Asset: exampleToken}: "1500000000000000000",
+	}, deltas)
+	assert.Equal(t, uint8(18), simulation.Deltas[3].Decimals)
+
+	request.Amount = "11"
+	_, simulation, err = c.SimulateTransfer(context.Background(), "alice", request)
+	require.Nil(t, err)
+	assert.False(t, simulation.Succeeded())
+	assert.ErrorIs(t, simulation.Error, txerrors.ErrInsufficientBalance)
+	assert.Nil(t, simulation.Deltas)
+}
+
+func TestSimulateWithoutSimulator(t *testing.T) {
+	c, _ := newSimulationClient(t)
+	c.Simulator = nil
+	plan, err := c.Plan(context.Background(), client.TransferRequest{Asset: exampleNFT, IDs: ids(1), From: client.VMCadence, Recipient: aliceCOA, COA: aliceCOA})
+	require.Nil(t, err)
+	_, err = c.Simulate(context.Background(), "alice", plan)
+	assert.ErrorContains(t, err, "client has no simulator")
+}
+
+func TestEmulatorSandbox(t *testing.T) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+r.PostForm.Get("name"))
+	}))
+	defer server.Close()
+
+	emulator := &client.Emulator{Admin: server.URL}
+	sandbox, err := emulator.Sandbox(context.Background())
+	require.Nil(t, err)
+	require.Nil(t, sandbox.Close(context.Background()))
+	require.Len(t, requests, 2)
+	assert.Regexp(t, `^POST /emulator/snapshots simulation-\d+-\d+$`, requests[0])
+	assert.Equal(t, "PUT /emulator/snapshots/"+requests[0][len("POST /emulator/snapshots "):]+" ", requests[1])
+
+	// A sandbox opens once the open one is closed
+	first, err := emulator.Sandbox(context.Background())
+	require.Nil(t, err)
+	opened := make(chan client.Sandbox)
+	go func() {
+		second, _ := emulator.Sandbox(context.Background())
+		opened <- second
+	}()
+	select {
+	case <-opened:
+		t.Fatal("sandbox opened while another one is open")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.Nil(t, first.Close(context.Background()))
+	require.Nil(t, first.Close(context.Background()))
+	second := <-opened
+	require.NotNil(t, second)
+	require.Nil(t, second.Close(context.Background()))
+	assert.Len(t, requests, 6)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "snapshot is not enabled", http.StatusBadRequest)
+	})
+	_, err = emulator.Sandbox(context.Background())
+	assert.ErrorContains(t, err, "Cannot snapshot emulator: admin API responded 400 Bad Request: snapshot is not enabled")
+}
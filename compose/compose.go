@@ -0,0 +1,367 @@
+// Package compose merges rendered transactions into a single transaction,
+// such as the setup of a collection followed by bridging NFTs into it.
+//
+// The steps of a composed transaction share its parameters by name and its
+// authorizers by position, whose entitlements are the union of the steps'.
+// The prepare and execute blocks of the steps run in order. Steps declaring
+// fields are inlined so they can initialize them, any other step runs in a
+// closure keeping its local declarations and early returns to itself
+package compose
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/parser"
+)
+
+// Step is a rendered transaction and its arguments
+type Step struct {
+	Code      []byte
+	Arguments []cadence.Value
+}
+
+// Transaction is the composition of steps
+type Transaction struct {
+	Code      []byte
+	Arguments []cadence.Value
+}
+
+type parameter struct {
+	name     string
+	typ      string
+	argument cadence.Value
+}
+
+type authorizer struct {
+	name         string
+	entitlements []string
+}
+
+// Block of a step, in its own closure unless inlined
+type block struct {
+	step    int
+	inlined bool
+	// Names of the step's authorizers differing from the composed ones
+	aliases map[string]string
+	body    string
+}
+
+type composer struct {
+	imports     []string
+	parameters  []parameter
+	fields      []string
+	fieldNames  map[string]int
+	authorizers []authorizer
+	prepare     []block
+	pre         []string
+	execute     []block
+	post        []string
+	// Top-level declarations of inlined blocks, by block kind
+	declarations map[string]map[string]int
+}
+
+// Returns the transaction running the steps in order
+func Compose(steps ...Step) (*Transaction, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("Cannot compose a transaction without steps")
+	}
+	c := &composer{
+		fieldNames:   map[string]int{},
+		declarations: map[string]map[string]int{"prepare": {}, "execute": {}},
+	}
+	for i, step := range steps {
+		if err := c.add(i, step); err != nil {
+			return nil, fmt.Errorf("Cannot compose step %d: %w", i, err)
+		}
+	}
+	arguments := make([]cadence.Value, len(c.parameters))
+	for i, p := range c.parameters {
+		arguments[i] = p.argument
+	}
+	return &Transaction{Code: []byte(c.render()), Arguments: arguments}, nil
+}
+
+func (c *composer) add(step int, s Step) error {
+	program, err := parser.ParseProgram(nil, s.Code, parser.Config{})
+	if err != nil {
+		return err
+	}
+	transactions := program.TransactionDeclarations()
+	if len(transactions) != 1 {
+		return fmt.Errorf("expected one transaction, found %d", len(transactions))
+	}
+	transaction := transactions[0]
+	code := string(s.Code)
+
+	for _, declaration := range program.ImportDeclarations() {
+		text := strings.Join(strings.Fields(source(code, declaration.StartPos, declaration.EndPos)), " ")
+		if !slices.Contains(c.imports, text) {
+			c.imports = append(c.imports, text)
+		}
+	}
+	if err := c.addParameters(transaction, s.Arguments); err != nil {
+		return err
+	}
+	for _, field := range transaction.Fields {
+		name := field.Identifier.Identifier
+		if previous, ok := c.fieldNames[name]; ok {
+			return fmt.Errorf("field %s is also declared by step %d", name, previous)
+		}
+		c.fieldNames[name] = step
+		c.fields = append(c.fields, source(code, field.StartPos, field.EndPos))
+	}
+	inlined := len(transaction.Fields) > 0
+
+	if transaction.Prepare != nil {
+		aliases, err := c.addAuthorizers(transaction.Prepare.FunctionDeclaration)
+		if err != nil {
+			return err
+		}
+		if inlined && len(aliases) > 0 {
+			return fmt.Errorf("authorizers are named differently than in previous steps")
+		}
+		prepare, err := c.block(code, step, "prepare", inlined, transaction.Prepare.FunctionDeclaration)
+		if err != nil {
+			return err
+		}
+		prepare.aliases = aliases
+		c.prepare = append(c.prepare, prepare)
+	}
+	c.pre = append(c.pre, conditions(code, transaction.PreConditions)...)
+	if transaction.Execute != nil {
+		execute, err := c.block(code, step, "execute", inlined, transaction.Execute.FunctionDeclaration)
+		if err != nil {
+			return err
+		}
+		c.execute = append(c.execute, execute)
+	}
+	c.post = append(c.post, conditions(code, transaction.PostConditions)...)
+	return nil
+}
+
+// Adds the parameters of the transaction, shared with previous steps by name
+func (c *composer) addParameters(transaction *ast.TransactionDeclaration, arguments []cadence.Value) error {
+	parameters := []*ast.Parameter{}
+	if transaction.ParameterList != nil {
+		parameters = transaction.ParameterList.Parameters
+	}
+	if len(arguments) != len(parameters) {
+		return fmt.Errorf("expected %d arguments, got %d", len(parameters), len(arguments))
+	}
+	for i, p := range parameters {
+		added := parameter{name: p.Identifier.Identifier, typ: p.TypeAnnotation.Type.String(), argument: arguments[i]}
+		index := slices.IndexFunc(c.parameters, func(existing parameter) bool { return existing.name == added.name })
+		if index < 0 {
+			c.parameters = append(c.parameters, added)
+			continue
+		}
+		existing := c.parameters[index]
+		if existing.typ != added.typ {
+			return fmt.Errorf("parameter %s has type %s, previous steps declare %s", added.name, added.typ, existing.typ)
+		}
+		if existing.argument.String() != added.argument.String() {
+			return fmt.Errorf("argument %s is %s, previous steps pass %s", added.name, added.argument, existing.argument)
+		}
+	}
+	return nil
+}
+
+// Merges the entitlements of the authorizers of prepare into the composed
+// ones. Returns the names the step gives authorizers that differ from the
+// composed names
+func (c *composer) addAuthorizers(prepare *ast.FunctionDeclaration) (map[string]string, error) {
+	parameters := prepare.ParameterList.Parameters
+	if len(c.authorizers) > 0 && len(parameters) != len(c.authorizers) {
+		return nil, fmt.Errorf("expected %d authorizers, found %d", len(c.authorizers), len(parameters))
+	}
+	aliases := map[string]string{}
+	for i, p := range parameters {
+		reference, ok := p.TypeAnnotation.Type.(*ast.ReferenceType)
+		if !ok || reference.Type.String() != "Account" {
+			return nil, fmt.Errorf("authorizer %s is not an Account reference", p.Identifier.Identifier)
+		}
+		entitlements := []string{}
+		switch authorization := reference.Authorization.(type) {
+		case nil:
+		case *ast.ConjunctiveEntitlementSet:
+			for _, entitlement := range authorization.Elements {
+				entitlements = append(entitlements, entitlement.String())
+			}
+		default:
+			return nil, fmt.Errorf("authorizer %s has unsupported authorization %s", p.Identifier.Identifier, reference.Authorization)
+		}
+
+		if i == len(c.authorizers) {
+			c.authorizers = append(c.authorizers, authorizer{name: p.Identifier.Identifier})
+		}
+		composed := &c.authorizers[i]
+		for _, entitlement := range entitlements {
+			if !slices.Contains(composed.entitlements, entitlement) {
+				composed.entitlements = append(composed.entitlements, entitlement)
+			}
+		}
+		if p.Identifier.Identifier != composed.name {
+			aliases[p.Identifier.Identifier] = composed.name
+		}
+	}
+	return aliases, nil
+}
+
+func (c *composer) block(code string, step int, kind string, inlined bool, function *ast.FunctionDeclaration) (block, error) {
+	functionBlock := function.FunctionBlock
+	if !functionBlock.PreConditions.IsEmpty() || !functionBlock.PostConditions.IsEmpty() {
+		return block{}, fmt.Errorf("%s declares conditions", kind)
+	}
+	if inlined {
+		for _, statement := range functionBlock.Block.Statements {
+			declaration, ok := statement.(*ast.VariableDeclaration)
+			if !ok {
+				continue
+			}
+			name := declaration.Identifier.Identifier
+			if previous, ok := c.declarations[kind][name]; ok {
+				return block{}, fmt.Errorf("%s declares %s, also declared by step %d", kind, name, previous)
+			}
+			c.declarations[kind][name] = step
+		}
+	}
+	body := source(code, functionBlock.Block.StartPos, functionBlock.Block.EndPos)
+	return block{step: step, inlined: inlined, body: body[1 : len(body)-1]}, nil
+}
+
+func conditions(code string, conditions *ast.Conditions) []string {
+	if conditions.IsEmpty() {
+		return nil
+	}
+	texts := []string{}
+	for _, condition := range *conditions {
+		// Start at the beginning of the line to keep the indentation of the
+		// lines following the first
+		start := strings.LastIndex(code[:condition.StartPosition().Offset], "\n") + 1
+		texts = append(texts, code[start:condition.EndPosition(nil).Offset+1])
+	}
+	return texts
+}
+
+func (c *composer) render() string {
+	var b strings.Builder
+	for _, text := range c.imports {
+		b.WriteString(text + "\n")
+	}
+	if len(c.imports) > 0 {
+		b.WriteString("\n")
+	}
+
+	parameters := []string{}
+	for _, p := range c.parameters {
+		parameters = append(parameters, p.name+": "+p.typ)
+	}
+	b.WriteString("transaction(" + strings.Join(parameters, ", ") + ") {\n")
+
+	if len(c.fields) > 0 {
+		b.WriteString("\n")
+	}
+	for _, field := range c.fields {
+		b.WriteString("    " + field + "\n")
+	}
+	if len(c.prepare) > 0 {
+		authorizers := []string{}
+		for _, a := range c.authorizers {
+			typ := "&Account"
+			if len(a.entitlements) > 0 {
+				typ = "auth(" + strings.Join(a.entitlements, ", ") + ") &Account"
+			}
+			authorizers = append(authorizers, a.name+": "+typ)
+		}
+		b.WriteString("\n    prepare(" + strings.Join(authorizers, ", ") + ") {\n")
+		renderBlocks(&b, c.prepare)
+		b.WriteString("    }\n")
+	}
+	renderConditions(&b, "pre", c.pre)
+	if len(c.execute) > 0 {
+		b.WriteString("\n    execute {\n")
+		renderBlocks(&b, c.execute)
+		b.WriteString("    }\n")
+	}
+	renderConditions(&b, "post", c.post)
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func renderBlocks(b *strings.Builder, blocks []block) {
+	for i, block := range blocks {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(fmt.Sprintf("        // Step %d\n", block.step))
+		if block.inlined {
+			b.WriteString(indent(block.body, "        "))
+			continue
+		}
+		closure := fmt.Sprintf("step%d", block.step)
+		b.WriteString("        let " + closure + " = fun () {\n")
+		aliases := []string{}
+		for alias := range block.aliases {
+			aliases = append(aliases, alias)
+		}
+		slices.Sort(aliases)
+		for _, alias := range aliases {
+			b.WriteString("            let " + alias + " = " + block.aliases[alias] + "\n")
+		}
+		b.WriteString(indent(block.body, "            "))
+		b.WriteString("        }\n")
+		b.WriteString("        " + closure + "()\n")
+	}
+}
+
+func renderConditions(b *strings.Builder, keyword string, conditions []string) {
+	if len(conditions) == 0 {
+		return
+	}
+	b.WriteString("\n    " + keyword + " {\n")
+	for _, condition := range conditions {
+		b.WriteString(indent(condition, "        "))
+	}
+	b.WriteString("    }\n")
+}
+
+// Returns the code between start and end, both included
+func source(code string, start, end ast.Position) string {
+	return code[start.Offset : end.Offset+1]
+}
+
+// Re-indents the lines of text, removing their common indentation and
+// surrounding blank lines
+func indent(text string, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	common := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if common < 0 || width < common {
+			common = width
+		}
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			b.WriteString("\n")
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		b.WriteString(prefix + line[min(width, max(common, 0)):] + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,189 @@
+package compose_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/compose"
+)
+
+const exampleNFT = "A.0ae53cb6e3f42a79.ExampleNFT.NFT"
+
+func mainnetTransaction(t *testing.T, path string) []byte {
+	bridgeEnv, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	code, err := bridge.GetCadenceTransactionCode(path, bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	return code
+}
+
+func TestComposeSetupAndBridge(t *testing.T) {
+	setup := mainnetTransaction(t, "cadence/transactions/example-assets/setup/setup_generic_nft_collection.cdc")
+	bridgeNFT := mainnetTransaction(t, "cadence/transactions/bridge/nft/bridge_nft_from_evm.cdc")
+
+	composed, err := compose.Compose(
+		compose.Step{Code: setup, Arguments: []cadence.Value{cadence.String(exampleNFT)}},
+		compose.Step{Code: bridgeNFT, Arguments: []cadence.Value{cadence.String(exampleNFT), cadence.NewUInt256(42)}},
+	)
+	require.Nil(t, err)
+	assert.Equal(t, []cadence.Value{cadence.String(exampleNFT), cadence.NewUInt256(42)}, composed.Arguments)
+
+	program, err := parser.ParseProgram(nil, composed.Code, parser.Config{})
+	require.Nil(t, err, string(composed.Code))
+	transaction := program.TransactionDeclarations()[0]
+	require.Len(t, transaction.ParameterList.Parameters, 2)
+	assert.Equal(t, "nftIdentifier", transaction.ParameterList.Parameters[0].Identifier.Identifier)
+	assert.Len(t, transaction.Fields, 4)
+	assert.Equal(t,
+		"auth(BorrowValue, SaveValue, IssueStorageCapabilityController, PublishCapability, UnpublishCapability, CopyValue) &Account",
+		transaction.Prepare.FunctionDeclaration.ParameterList.Parameters[0].TypeAnnotation.Type.String(),
+	)
+	// Imports shared by both steps are declared once
+	assert.Len(t, program.ImportDeclarations(), 10)
+
+	code := string(composed.Code)
+	// The setup returns early if the collection exists, which must not skip
+	// the bridge step
+	assert.Contains(t, code, "        let step0 = fun () {\n")
+	assert.Contains(t, code, "        step0()\n")
+	assert.Less(t, strings.Index(code, "step0()"), strings.Index(code, "self.coa = signer.storage.borrow"))
+}
+
+// The Cadence tests send the composed transaction from a fixture, which must
+// match what Compose returns for the templates
+func TestComposedTransactionFixture(t *testing.T) {
+	setup, err := os.ReadFile("../cadence/transactions/example-assets/setup/setup_generic_nft_collection.cdc")
+	require.Nil(t, err)
+	bridgeNFT, err := os.ReadFile("../cadence/transactions/bridge/nft/bridge_nft_from_evm.cdc")
+	require.Nil(t, err)
+	fixture, err := os.ReadFile("../cadence/tests/transactions/setup_and_bridge_nft_from_evm.cdc")
+	require.Nil(t, err)
+
+	composed, err := compose.Compose(
+		compose.Step{Code: setup, Arguments: []cadence.Value{cadence.String(exampleNFT)}},
+		compose.Step{Code: bridgeNFT, Arguments: []cadence.Value{cadence.String(exampleNFT), cadence.NewUInt256(42)}},
+	)
+	require.Nil(t, err)
+	_, code, found := strings.Cut(string(fixture), "\n\n")
+	require.True(t, found)
+	assert.Equal(t, code, string(composed.Code))
+}
+
+func TestComposeConditionsAndAliases(t *testing.T) {
+	first := []byte(`
+import "FungibleToken"
+
+transaction(amount: UFix64) {
+    let balance: UFix64
+
+    prepare(signer: auth(BorrowValue) &Account) {
+        self.balance = 1.0
+    }
+
+    pre {
+        amount > 0.0: "Amount must be positive"
+    }
+
+    execute {
+        log(self.balance)
+    }
+}
+`)
+	second := []byte(`
+import "FungibleToken"
+import "EVM"
+
+transaction(amount: UFix64, recipient: String) {
+    prepare(acct: auth(Storage) &Account) {
+        let name = recipient
+        log(acct.address)
+    }
+
+    post {
+        amount < 10.0:
+            "Amount must be below 10"
+    }
+}
+`)
+	composed, err := compose.Compose(
+		compose.Step{Code: first, Arguments: []cadence.Value{cadence.UFix64(100_000_000)}},
+		compose.Step{Code: second, Arguments: []cadence.Value{cadence.UFix64(100_000_000), cadence.String("ab")}},
+	)
+	require.Nil(t, err)
+	expected := `import "FungibleToken"
+import "EVM"
+
+transaction(amount: UFix64, recipient: String) {
+
+    let balance: UFix64
+
+    prepare(signer: auth(BorrowValue, Storage) &Account) {
+        // Step 0
+        self.balance = 1.0
+
+        // Step 1
+        let step1 = fun () {
+            let acct = signer
+            let name = recipient
+            log(acct.address)
+        }
+        step1()
+    }
+
+    pre {
+        amount > 0.0: "Amount must be positive"
+    }
+
+    execute {
+        // Step 0
+        log(self.balance)
+    }
+
+    post {
+        amount < 10.0:
+            "Amount must be below 10"
+    }
+}
+`
+	assert.Equal(t, expected, string(composed.Code))
+	_, err = parser.ParseProgram(nil, composed.Code, parser.Config{})
+	require.Nil(t, err)
+}
+
+func TestComposeConflicts(t *testing.T) {
+	transaction := []byte(`
+transaction(amount: UFix64) {
+    let balance: UFix64
+    prepare(signer: &Account) {
+        self.balance = amount
+    }
+}
+`)
+	_, err := compose.Compose(
+		compose.Step{Code: transaction, Arguments: []cadence.Value{cadence.UFix64(1)}},
+		compose.Step{Code: transaction, Arguments: []cadence.Value{cadence.UFix64(2)}},
+	)
+	assert.ErrorContains(t, err, "Cannot compose step 1: argument amount is 0.00000002, previous steps pass 0.00000001")
+
+	_, err = compose.Compose(
+		compose.Step{Code: transaction, Arguments: []cadence.Value{cadence.UFix64(1)}},
+		compose.Step{Code: transaction, Arguments: []cadence.Value{cadence.UFix64(1)}},
+	)
+	assert.ErrorContains(t, err, "field balance is also declared by step 0")
+
+	_, err = compose.Compose(
+		compose.Step{Code: transaction, Arguments: []cadence.Value{cadence.UFix64(1)}},
+		compose.Step{Code: []byte(`transaction(amount: UInt256) {}`), Arguments: []cadence.Value{cadence.NewUInt256(1)}},
+	)
+	assert.ErrorContains(t, err, "parameter amount has type UInt256, previous steps declare UFix64")
+
+	_, err = compose.Compose(compose.Step{Code: transaction})
+	assert.ErrorContains(t, err, "expected 1 arguments, got 0")
+}
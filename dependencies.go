@@ -0,0 +1,335 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const contractsDir = "cadence/contracts"
+
+// Matches both the placeholder form `import "Name"` and the
+// address form `import Name from 0x01` of a Cadence import
+var importPattern = regexp.MustCompile(`(?m)^\s*import\s+(?:"(\w+)"|(\w+)\s+from\s+\S+)`)
+
+// ContractGraph is the import graph of the bridge Cadence contracts.
+// Imports of contracts that are not part of the bridge, such as EVM or
+// NonFungibleToken, are tracked separately as external imports
+type ContractGraph struct {
+	contracts []string
+	paths     map[string]string
+	imports   map[string][]string
+	external  map[string][]string
+}
+
+// CycleError is returned when the contract imports form a cycle
+// and no deployment order exists
+type CycleError struct {
+	Cycle []string
+}
+
+func (e *CycleError) Error() string {
+	return "Import cycle between contracts: " + strings.Join(e.Cycle, " -> ")
+}
+
+// Builds the import graph of every embedded bridge Cadence contract
+func DependencyGraph() (*ContractGraph, error) {
+	sources := make(map[string]string)
+	paths := make(map[string]string)
+
+	err := fs.WalkDir(content, contractsDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(filePath) != ".cdc" {
+			return nil
+		}
+		code, err := content.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(path.Base(filePath), ".cdc")
+		sources[name] = string(code)
+		paths[name] = filePath
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	imports := make(map[string][]string, len(sources))
+	for name, code := range sources {
+		imports[name] = ParseImports(code)
+	}
+
+	graph := NewContractGraph(imports)
+	graph.paths = paths
+
+	return graph, nil
+}
+
+// Builds a graph from a map of contract name to the names it imports.
+// Imported names that are not keys of the map are treated as external
+func NewContractGraph(imports map[string][]string) *ContractGraph {
+	graph := &ContractGraph{
+		contracts: make([]string, 0, len(imports)),
+		paths:     make(map[string]string),
+		imports:   make(map[string][]string, len(imports)),
+		external:  make(map[string][]string, len(imports)),
+	}
+
+	for name := range imports {
+		graph.contracts = append(graph.contracts, name)
+	}
+	sort.Strings(graph.contracts)
+
+	for _, name := range graph.contracts {
+		internal := []string{}
+		external := []string{}
+		for _, imported := range imports[name] {
+			if _, ok := imports[imported]; ok {
+				internal = append(internal, imported)
+			} else {
+				external = append(external, imported)
+			}
+		}
+		graph.imports[name] = dedupeSorted(internal)
+		graph.external[name] = dedupeSorted(external)
+	}
+
+	return graph
+}
+
+// Returns the names of the contracts imported by the given Cadence code
+// in the order they are declared
+func ParseImports(code string) []string {
+	names := []string{}
+	for _, match := range importPattern.FindAllStringSubmatch(code, -1) {
+		if match[1] != "" {
+			names = append(names, match[1])
+		} else {
+			names = append(names, match[2])
+		}
+	}
+	return names
+}
+
+// Returns the names of all contracts in the graph in alphabetical order
+func (g *ContractGraph) Contracts() []string {
+	return append([]string{}, g.contracts...)
+}
+
+// Returns the embedded path of a contract, or an empty string
+// if the graph was not built from the embedded contracts
+func (g *ContractGraph) Path(name string) string {
+	return g.paths[name]
+}
+
+// Returns the bridge contracts directly imported by a contract
+func (g *ContractGraph) Imports(name string) []string {
+	return append([]string{}, g.imports[name]...)
+}
+
+// Returns the non-bridge contracts directly imported by a contract
+func (g *ContractGraph) ExternalImports(name string) []string {
+	return append([]string{}, g.external[name]...)
+}
+
+// Returns the order in which the contracts have to be deployed so that every
+// contract is deployed after all of the contracts it imports. Ties are broken
+// alphabetically so the order is stable
+func (g *ContractGraph) DeploymentOrder() ([]string, error) {
+	remaining := make(map[string]int, len(g.contracts))
+	dependents := make(map[string][]string, len(g.contracts))
+	for _, name := range g.contracts {
+		remaining[name] = len(g.imports[name])
+		for _, imported := range g.imports[name] {
+			dependents[imported] = append(dependents[imported], name)
+		}
+	}
+
+	ready := []string{}
+	for _, name := range g.contracts {
+		if remaining[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	order := make([]string, 0, len(g.contracts))
+	for len(ready) > 0 {
+		sort.Strings(ready)
+		next := ready[0]
+		ready = ready[1:]
+		order = append(order, next)
+
+		for _, dependent := range dependents[next] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(order) != len(g.contracts) {
+		return nil, &CycleError{Cycle: g.findCycle()}
+	}
+
+	return order, nil
+}
+
+// Returns the given contracts and every contract that transitively imports
+// any of them, in deployment order. These are the contracts that have to be
+// redeployed or updated when the given contracts change
+func (g *ContractGraph) AffectedBy(changed ...string) ([]string, error) {
+	dependents := make(map[string][]string, len(g.contracts))
+	for _, name := range g.contracts {
+		for _, imported := range g.imports[name] {
+			dependents[imported] = append(dependents[imported], name)
+		}
+	}
+
+	affected := make(map[string]bool)
+	queue := []string{}
+	for _, name := range changed {
+		if _, ok := g.imports[name]; !ok {
+			return nil, fmt.Errorf("Unknown contract %s", name)
+		}
+		if !affected[name] {
+			affected[name] = true
+			queue = append(queue, name)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[current] {
+			if !affected[dependent] {
+				affected[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	order, err := g.DeploymentOrder()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []string{}
+	for _, name := range order {
+		if affected[name] {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+// Returns the graph in Graphviz DOT format with an edge from
+// every contract to each bridge contract it imports
+func (g *ContractGraph) DOT() string {
+	var builder strings.Builder
+	builder.WriteString("digraph FlowEVMBridge {\n")
+	for _, name := range g.contracts {
+		builder.WriteString(fmt.Sprintf("  %q;\n", name))
+	}
+	for _, name := range g.contracts {
+		for _, imported := range g.imports[name] {
+			builder.WriteString(fmt.Sprintf("  %q -> %q;\n", name, imported))
+		}
+	}
+	builder.WriteString("}\n")
+	return builder.String()
+}
+
+type contractNodeJSON struct {
+	Name            string   `json:"name"`
+	Path            string   `json:"path,omitempty"`
+	Imports         []string `json:"imports"`
+	ExternalImports []string `json:"externalImports"`
+}
+
+type contractGraphJSON struct {
+	Contracts       []contractNodeJSON `json:"contracts"`
+	DeploymentOrder []string           `json:"deploymentOrder,omitempty"`
+}
+
+// Encodes the graph with every contract, its imports and, if the
+// graph has no cycle, the deployment order
+func (g *ContractGraph) MarshalJSON() ([]byte, error) {
+	out := contractGraphJSON{
+		Contracts: make([]contractNodeJSON, 0, len(g.contracts)),
+	}
+	for _, name := range g.contracts {
+		out.Contracts = append(out.Contracts, contractNodeJSON{
+			Name:            name,
+			Path:            g.paths[name],
+			Imports:         g.Imports(name),
+			ExternalImports: g.ExternalImports(name),
+		})
+	}
+	if order, err := g.DeploymentOrder(); err == nil {
+		out.DeploymentOrder = order
+	}
+	return json.Marshal(out)
+}
+
+// Finds one import cycle with a depth first search, returned with
+// the first contract repeated at the end
+func (g *ContractGraph) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(g.contracts))
+	stack := []string{}
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, imported := range g.imports[name] {
+			switch state[imported] {
+			case visiting:
+				for i, onStack := range stack {
+					if onStack == imported {
+						return append(append([]string{}, stack[i:]...), imported)
+					}
+				}
+			case unvisited:
+				if cycle := visit(imported); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, name := range g.contracts {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+func dedupeSorted(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,108 @@
+package bridge_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+)
+
+// Returns the position of every name in the order
+func positions(order []string) map[string]int {
+	result := make(map[string]int, len(order))
+	for i, name := range order {
+		result[name] = i
+	}
+	return result
+}
+
+func TestDependencyGraph(t *testing.T) {
+	graph, err := bridge.DependencyGraph()
+	require.Nil(t, err)
+
+	assert.Len(t, graph.Contracts(), 26)
+	assert.Equal(t, "cadence/contracts/bridge/FlowEVMBridge.cdc", graph.Path("FlowEVMBridge"))
+	assert.Contains(t, graph.Imports("FlowEVMBridgeConfig"), "FlowEVMBridgeCustomAssociations")
+	assert.Contains(t, graph.ExternalImports("FlowEVMBridgeConfig"), "EVM")
+	assert.NotContains(t, graph.Imports("FlowEVMBridgeConfig"), "EVM")
+	assert.Empty(t, graph.Imports("IBridgePermissions"))
+
+	order, err := graph.DeploymentOrder()
+	require.Nil(t, err)
+	assert.Len(t, order, 26)
+
+	// Every contract must come after all of the contracts it imports
+	index := positions(order)
+	for _, name := range graph.Contracts() {
+		for _, imported := range graph.Imports(name) {
+			assert.Less(t, index[imported], index[name], "%s imports %s", name, imported)
+		}
+	}
+	assert.Less(t, index["FlowEVMBridge"], index["FlowEVMBridgeAccessor"])
+}
+
+func TestDependencyGraphAffectedBy(t *testing.T) {
+	graph, err := bridge.DependencyGraph()
+	require.Nil(t, err)
+
+	affected, err := graph.AffectedBy("StringUtils")
+	require.Nil(t, err)
+	assert.Equal(t, []string{"StringUtils", "ScopedFTProviders"}, affected)
+
+	affected, err = graph.AffectedBy("FlowEVMBridgeUtils")
+	require.Nil(t, err)
+	assert.Equal(t, "FlowEVMBridgeUtils", affected[0])
+	assert.Contains(t, affected, "FlowEVMBridge")
+	assert.Contains(t, affected, "FlowEVMBridgeAccessor")
+	assert.NotContains(t, affected, "FlowEVMBridgeConfig")
+
+	_, err = graph.AffectedBy("CryptoPunks")
+	assert.NotNil(t, err)
+}
+
+func TestDependencyGraphCycle(t *testing.T) {
+	graph := bridge.NewContractGraph(map[string][]string{
+		"A": {"B", "EVM"},
+		"B": {"C"},
+		"C": {"A"},
+		"D": {},
+	})
+
+	assert.Equal(t, []string{"EVM"}, graph.ExternalImports("A"))
+
+	_, err := graph.DeploymentOrder()
+	require.NotNil(t, err)
+	cycleErr, ok := err.(*bridge.CycleError)
+	require.True(t, ok)
+	assert.Equal(t, []string{"A", "B", "C", "A"}, cycleErr.Cycle)
+}
+
+func TestDependencyGraphExport(t *testing.T) {
+	graph := bridge.NewContractGraph(map[string][]string{
+		"A": {"B"},
+		"B": {"FungibleToken"},
+	})
+
+	dot := graph.DOT()
+	assert.Contains(t, dot, "digraph FlowEVMBridge {")
+	assert.Contains(t, dot, "\"A\" -> \"B\";")
+	assert.NotContains(t, dot, "FungibleToken")
+
+	encoded, err := json.Marshal(graph)
+	require.Nil(t, err)
+	assert.JSONEq(t, `{
+		"contracts": [
+			{"name": "A", "imports": ["B"], "externalImports": []},
+			{"name": "B", "imports": [], "externalImports": ["FungibleToken"]}
+		],
+		"deploymentOrder": ["B", "A"]
+	}`, string(encoded))
+}
+
+func TestParseImports(t *testing.T) {
+	code := "import \"EVM\"\nimport FlowToken from 0x0ae53cb6e3f42a79\n\naccess(all) contract Foo {}"
+	assert.Equal(t, []string{"EVM", "FlowToken"}, bridge.ParseImports(code))
+}
@@ -0,0 +1,477 @@
+package deploy
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/go-ethereum/common"
+	"github.com/onflow/go-ethereum/crypto"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/results"
+)
+
+const (
+	pathGetEVMAddressString         = "cadence/scripts/evm/get_evm_address_string.cdc"
+	pathGetNonce                    = "cadence/scripts/evm/get_nonce.cdc"
+	pathGetAccountContractNames     = "cadence/scripts/utils/get_account_contract_names.cdc"
+	pathGetFactoryAddress           = "cadence/scripts/utils/get_factory_address.cdc"
+	pathGetRegistrarAddress         = "cadence/scripts/utils/get_registrar_address.cdc"
+	pathGetRegistryAddress          = "cadence/scripts/utils/get_registry_address.cdc"
+	pathGetDelegatedDeployerAddress = "cadence/scripts/utils/get_delegated_deployer_address.cdc"
+	pathGetDeployerAddress          = "cadence/scripts/utils/get_deployer_address.cdc"
+	pathGetBridgedAssetContractCode = "cadence/scripts/bridge/get_bridged_asset_contract_code.cdc"
+	pathGetBridgeRouterType         = "cadence/scripts/bridge/get_bridge_router_type.cdc"
+)
+
+// TransactionSender sends a transaction signed by the account with the
+// given signer role, such as SignerBridge, and waits for its result
+type TransactionSender interface {
+	SendTransaction(ctx context.Context, signer string, code []byte, arguments []cadence.Value) (*flow.TransactionResult, error)
+}
+
+// ScriptExecutor executes a script and returns its result
+type ScriptExecutor interface {
+	ExecuteScript(ctx context.Context, code []byte, arguments []cadence.Value) (cadence.Value, error)
+}
+
+// CompletedStep records a step that was executed successfully
+type CompletedStep struct {
+	ID            string    `json:"id"`
+	TransactionID string    `json:"transactionId,omitempty"`
+	CompletedAt   time.Time `json:"completedAt"`
+}
+
+// State is the progress of a deployment, persisted after every step
+// so an interrupted deployment can be resumed
+type State struct {
+	Network   string          `json:"network"`
+	Completed []CompletedStep `json:"completed"`
+	// EVM addresses deployed by the plan by output name, as hex without 0x prefix
+	Outputs map[string]string `json:"outputs"`
+	// Nonce of the bridge COA when it was created, from which the addresses
+	// of the EVM contracts it deployed are derived
+	COANonce uint64 `json:"coaNonce,omitempty"`
+}
+
+// Loads the state file at path, returning an empty state if it does not exist yet
+func LoadState(path string) (*State, error) {
+	state := &State{Outputs: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("Invalid state file %s: %w", path, err)
+	}
+	if state.Outputs == nil {
+		state.Outputs = map[string]string{}
+	}
+	return state, nil
+}
+
+// Writes the state to path, replacing the previous file atomically
+func (s *State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Returns whether the step with the given ID has completed
+func (s *State) IsCompleted(id string) bool {
+	for _, step := range s.Completed {
+		if step.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// StepError is returned when a step of the plan fails
+type StepError struct {
+	StepID string
+	Err    error
+}
+
+func (e *StepError) Error() string {
+	return "Deployment step " + e.StepID + " failed: " + e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Executor runs a Plan step by step, recording progress in a state file.
+// Running it again after a failure resumes from the first incomplete step
+type Executor struct {
+	Config    Config
+	Plan      *Plan
+	Sender    TransactionSender
+	Scripts   ScriptExecutor
+	StatePath string
+}
+
+// Runs every step of the plan that has not completed yet and
+// returns the final state
+func (e *Executor) Run(ctx context.Context) (*State, error) {
+	state, err := LoadState(e.StatePath)
+	if err != nil {
+		return nil, err
+	}
+	if state.Network == "" {
+		state.Network = e.Plan.Network
+	}
+	if state.Network != e.Plan.Network {
+		return nil, fmt.Errorf("State file %s belongs to network %s, not %s", e.StatePath, state.Network, e.Plan.Network)
+	}
+
+	for _, step := range e.Plan.Steps {
+		if state.IsCompleted(step.ID) {
+			continue
+		}
+		if err := e.runStep(ctx, step, state); err != nil {
+			return state, &StepError{StepID: step.ID, Err: err}
+		}
+	}
+
+	return state, nil
+}
+
+func (e *Executor) runStep(ctx context.Context, step Step, state *State) error {
+	// A step may have succeeded without being recorded, e.g. if the process
+	// was killed while waiting for the result. Its effect is checked on chain
+	// first so it is not sent twice
+	check, err := e.postCondition(step)
+	if err != nil {
+		return err
+	}
+	if check != nil {
+		done, err := check(ctx, state)
+		if err != nil {
+			return fmt.Errorf("Cannot check whether the step already ran: %w", err)
+		}
+		if done {
+			return e.complete(step, "", state)
+		}
+	}
+
+	arguments, err := resolveArguments(step.Arguments, state)
+	if err != nil {
+		return err
+	}
+
+	result, err := e.Sender.SendTransaction(ctx, step.Signer, step.Code, arguments)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return errors.New("Cannot send transaction: no result returned")
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if step.Output != "" {
+		address, err := deployedEVMAddress(result.Events)
+		if err != nil {
+			return err
+		}
+		state.Outputs[step.Output] = address
+	}
+
+	if check != nil {
+		done, err := check(ctx, state)
+		if err != nil {
+			return err
+		}
+		if !done {
+			return fmt.Errorf("Transaction %s succeeded but its effect is not visible on chain", result.TransactionID)
+		}
+	}
+
+	return e.complete(step, result.TransactionID.String(), state)
+}
+
+func (e *Executor) complete(step Step, transactionID string, state *State) error {
+	state.Completed = append(state.Completed, CompletedStep{
+		ID:            step.ID,
+		TransactionID: transactionID,
+		CompletedAt:   time.Now().UTC(),
+	})
+	return state.Save(e.StatePath)
+}
+
+// check returns whether the effect of a step is visible on chain. It may
+// record outputs of the step which can be recovered from chain state
+type check func(ctx context.Context, state *State) (bool, error)
+
+// Returns the check verifying the effect of a step, nil if its kind has none
+func (e *Executor) postCondition(step Step) (check, error) {
+	bridgeAddress, err := cadenceAddress(e.Config.BridgeEnv.FlowEVMBridgeAddress)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot check the effect of the step: invalid bridge address: %w", err)
+	}
+	coaHost := Argument{Name: "coaHost", Value: bridgeAddress}
+	switch step.Path {
+	case pathCreateCOA:
+		return e.expectCOA(bridgeAddress), nil
+	case pathEVMDeploy:
+		return e.expectEVMDeployment(step), nil
+	case pathDeployContract, pathDeployBridgeAccessor:
+		return e.expectContract(bridgeAddress, step), nil
+	case pathDeployBridgeUtils:
+		return all(
+			e.expectContract(bridgeAddress, step),
+			e.expectAddress(pathGetFactoryAddress, nil, OutputFactory),
+		), nil
+	case pathSetRegistrar:
+		return e.expectAddress(pathGetRegistrarAddress,
+			[]Argument{coaHost, {Name: "registryEVMAddressHex", Ref: OutputRegistry}}, OutputFactory), nil
+	case pathSetDeploymentRegistry:
+		return e.expectAddress(pathGetRegistryAddress, []Argument{coaHost}, OutputRegistry), nil
+	case pathSetDelegatedDeployer:
+		return e.expectAddress(pathGetDelegatedDeployerAddress,
+			[]Argument{coaHost, step.argument("deployerEVMAddressHex")}, OutputFactory), nil
+	case pathAddDeployer:
+		return e.expectAddress(pathGetDeployerAddress,
+			[]Argument{coaHost, step.argument("deployerTag")}, step.argument("deployerEVMAddressHex").Ref), nil
+	case pathUpsertCodeChunks:
+		return e.expectTemplate(step), nil
+	case pathClaimAccessorAndRouter:
+		return e.expectRouter(bridgeAddress), nil
+	}
+	return nil, nil
+}
+
+// Returns a check passing if every check passes
+func all(checks ...check) check {
+	return func(ctx context.Context, state *State) (bool, error) {
+		for _, check := range checks {
+			done, err := check(ctx, state)
+			if err != nil || !done {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+// Returns a check that the bridge account has a COA, recording its EVM
+// address and, the first time, its nonce. The COA creation is recorded
+// before any later step runs, so the recorded nonce is the one of the first
+// EVM deployment
+func (e *Executor) expectCOA(bridgeAddress cadence.Address) check {
+	return func(ctx context.Context, state *State) (bool, error) {
+		value, err := e.executeScript(ctx, pathGetEVMAddressString, []cadence.Value{bridgeAddress})
+		if err != nil {
+			return false, err
+		}
+		address, err := results.OptionalString(value)
+		if err != nil || address == nil {
+			return false, err
+		}
+		if _, ok := state.Outputs[OutputCOA]; ok {
+			return true, nil
+		}
+		value, err = e.executeScript(ctx, pathGetNonce, []cadence.Value{cadenceString(*address)})
+		if err != nil {
+			return false, err
+		}
+		if state.COANonce, err = results.UInt64(value); err != nil {
+			return false, err
+		}
+		state.Outputs[OutputCOA] = bridge.NormalizeEVMAddress(*address)
+		return true, nil
+	}
+}
+
+// Returns a check that the COA has sent the EVM deployment of the step,
+// recovering the deployed address from the COA's nonce. The plan deploys
+// its EVM contracts with the first transactions of the COA, so the nth
+// deployment is the contract created at the nth nonce of the COA
+func (e *Executor) expectEVMDeployment(step Step) check {
+	deployment := uint64(0)
+	for _, other := range e.Plan.Steps {
+		if other.ID == step.ID {
+			break
+		}
+		if other.Path == pathEVMDeploy {
+			deployment++
+		}
+	}
+	return func(ctx context.Context, state *State) (bool, error) {
+		coa, ok := state.Outputs[OutputCOA]
+		if !ok {
+			return false, errors.New("Missing COA address " + OutputCOA)
+		}
+		value, err := e.executeScript(ctx, pathGetNonce, []cadence.Value{cadenceString(coa)})
+		if err != nil {
+			return false, err
+		}
+		nonce, err := results.UInt64(value)
+		if err != nil {
+			return false, err
+		}
+		deploymentNonce := state.COANonce + deployment
+		if nonce <= deploymentNonce {
+			return false, nil
+		}
+		address := bridge.NormalizeEVMAddress(crypto.CreateAddress(common.HexToAddress(coa), deploymentNonce).Hex())
+		if recorded, ok := state.Outputs[step.Output]; ok && recorded != address {
+			return false, fmt.Errorf("%s was deployed at %s, expected %s from nonce %d of the COA", step.Output, recorded, address, deploymentNonce)
+		}
+		state.Outputs[step.Output] = address
+		return true, nil
+	}
+}
+
+// Returns a check that the account has a contract with the name deployed by the step
+func (e *Executor) expectContract(account cadence.Address, step Step) check {
+	return func(ctx context.Context, state *State) (bool, error) {
+		name, err := results.String(step.argument("name").Value)
+		if err != nil {
+			return false, err
+		}
+		value, err := e.executeScript(ctx, pathGetAccountContractNames, []cadence.Value{account})
+		if err != nil {
+			return false, err
+		}
+		names, err := results.Strings(value)
+		if err != nil {
+			return false, err
+		}
+		return slices.Contains(names, name), nil
+	}
+}
+
+// Returns a check that the script returns the EVM address recorded under output
+func (e *Executor) expectAddress(scriptPath string, arguments []Argument, output string) check {
+	return func(ctx context.Context, state *State) (bool, error) {
+		expected, ok := state.Outputs[output]
+		if !ok {
+			return false, errors.New("Missing deployed address " + output)
+		}
+		values, err := resolveArguments(arguments, state)
+		if err != nil {
+			return false, err
+		}
+		value, err := e.executeScript(ctx, scriptPath, values)
+		if err != nil {
+			return false, err
+		}
+		actual, err := results.String(value)
+		if err != nil {
+			return false, fmt.Errorf("Unexpected result of %s: %w", scriptPath, err)
+		}
+		return bridge.NormalizeEVMAddress(actual) == expected, nil
+	}
+}
+
+// Returns a check that FlowEVMBridgeTemplates serves the code of the chunks
+// upserted by the step
+func (e *Executor) expectTemplate(step Step) check {
+	// Any contract name serves to compare the joined chunks
+	const contractName = "BridgedAsset"
+	return func(ctx context.Context, state *State) (bool, error) {
+		template, err := results.String(step.argument("forTemplate").Value)
+		if err != nil {
+			return false, err
+		}
+		chunks, err := results.Strings(step.argument("newChunks").Value)
+		if err != nil {
+			return false, err
+		}
+		expected := strings.ToLower(strings.Join(chunks, hex.EncodeToString([]byte(contractName))))
+		value, err := e.executeScript(ctx, pathGetBridgedAssetContractCode,
+			[]cadence.Value{cadenceString(contractName), cadence.Bool(template == "bridgedNFT")})
+		if err != nil {
+			return false, err
+		}
+		code, err := results.OptionalString(value)
+		if err != nil || code == nil {
+			return false, err
+		}
+		return *code == expected, nil
+	}
+}
+
+// Returns a check that the account receiving the BridgeAccessor stores the
+// BridgeRouter of the bridge account
+func (e *Executor) expectRouter(bridgeAddress cadence.Address) check {
+	expected := "A." + bridgeAddress.Hex() + ".FlowEVMBridgeAccessor.BridgeRouter"
+	return func(ctx context.Context, state *State) (bool, error) {
+		recipient, err := cadenceAddress(e.Config.accessorRecipient())
+		if err != nil {
+			return false, err
+		}
+		value, err := e.executeScript(ctx, pathGetBridgeRouterType, []cadence.Value{recipient})
+		if err != nil {
+			return false, err
+		}
+		routerType, err := results.OptionalString(value)
+		if err != nil || routerType == nil {
+			return false, err
+		}
+		return *routerType == expected, nil
+	}
+}
+
+func (e *Executor) executeScript(ctx context.Context, scriptPath string, arguments []cadence.Value) (cadence.Value, error) {
+	code, err := bridge.GetCadenceScriptCode(scriptPath, e.Config.BridgeEnv, e.Config.CoreEnv)
+	if err != nil {
+		return nil, err
+	}
+	return e.Scripts.ExecuteScript(ctx, code, arguments)
+}
+
+// Returns the argument values with references to outputs of earlier
+// steps replaced by the recorded EVM address
+func resolveArguments(arguments []Argument, state *State) ([]cadence.Value, error) {
+	values := make([]cadence.Value, 0, len(arguments))
+	for _, argument := range arguments {
+		if argument.Ref == "" {
+			values = append(values, argument.Value)
+			continue
+		}
+		address, ok := state.Outputs[argument.Ref]
+		if !ok {
+			return nil, fmt.Errorf("Argument %s references %s which has not been deployed", argument.Name, argument.Ref)
+		}
+		values = append(values, cadenceString(address))
+	}
+	return values, nil
+}
+
+// Returns the address of the EVM contract deployed by a transaction
+// from the last EVM.TransactionExecuted event with a contract address
+func deployedEVMAddress(events []flow.Event) (string, error) {
+	for i := len(events) - 1; i >= 0; i-- {
+		if !strings.HasSuffix(events[i].Type, ".EVM.TransactionExecuted") {
+			continue
+		}
+		value := cadence.SearchFieldByName(events[i].Value, "contractAddress")
+		address, ok := value.(cadence.String)
+		if ok && address != "" {
+			return bridge.NormalizeEVMAddress(string(address)), nil
+		}
+	}
+	return "", errors.New("No EVM contract deployment found in transaction events")
+}
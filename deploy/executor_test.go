@@ -0,0 +1,302 @@
+package deploy_test
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/go-ethereum/common"
+	"github.com/onflow/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/deploy"
+)
+
+// fakeChain is an in-memory TransactionSender and ScriptExecutor which
+// tracks just enough bridge state to satisfy the executor's checks
+type fakeChain struct {
+	sent        []string
+	failAt      int
+	nilResult   bool
+	coa         string
+	nonce       uint64
+	contracts   []string
+	factory     string
+	registry    string
+	registrars  map[string]string
+	delegated   map[string]string
+	deployers   map[string]string
+	templates   map[string][]string
+	router      string
+	deployedEVM []string
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{
+		failAt:     -1,
+		registrars: map[string]string{},
+		delegated:  map[string]string{},
+		deployers:  map[string]string{},
+		templates:  map[string][]string{},
+	}
+}
+
+const fakeCOA = "000000000000000000000002000000000000000a"
+
+// Returns the address of the contract deployed by the COA at the nonce
+func deployedAt(nonce uint64) string {
+	return strings.ToLower(crypto.CreateAddress(common.HexToAddress(fakeCOA), nonce).Hex()[2:])
+}
+
+func (c *fakeChain) SendTransaction(_ context.Context, _ string, code []byte, arguments []cadence.Value) (*flow.TransactionResult, error) {
+	if c.nilResult {
+		return nil, nil
+	}
+	if c.failAt == len(c.sent) {
+		c.failAt = -1
+		return &flow.TransactionResult{Error: errors.New("execution error")}, nil
+	}
+	c.sent = append(c.sent, string(code))
+	result := &flow.TransactionResult{TransactionID: flow.HexToID(fmt.Sprintf("%x", len(c.sent)))}
+
+	source := string(code)
+	switch {
+	case strings.Contains(source, "EVM.createCadenceOwnedAccount()"):
+		// Like every EVM contract account, a COA starts at nonce 1
+		c.coa, c.nonce = fakeCOA, 1
+	case strings.Contains(source, "self.coa.deploy("):
+		address := "0x" + deployedAt(c.nonce)
+		c.nonce++
+		c.deployedEVM = append(c.deployedEVM, address)
+		result.Events = []flow.Event{transactionExecuted(address)}
+	case strings.Contains(source, "signer.contracts.add("):
+		c.contracts = append(c.contracts, string(arguments[0].(cadence.String)))
+		if strings.Contains(source, "bridgeFactoryAddressHex: factoryAddress") {
+			c.factory = string(arguments[2].(cadence.String))
+		}
+	case strings.Contains(source, "\"setRegistrar(address)\""):
+		c.nonce++
+		c.registrars[string(arguments[0].(cadence.String))] = c.factory
+	case strings.Contains(source, "\"setDeploymentRegistry(address)\""):
+		c.nonce++
+		c.registry = string(arguments[0].(cadence.String))
+	case strings.Contains(source, "\"setDelegatedDeployer(address)\""):
+		c.nonce++
+		c.delegated[string(arguments[0].(cadence.String))] = c.factory
+	case strings.Contains(source, "\"addDeployer(string,address)\""):
+		c.nonce++
+		c.deployers[string(arguments[0].(cadence.String))] = string(arguments[1].(cadence.String))
+	case strings.Contains(source, "upsertContractCodeChunks("):
+		chunks := []string{}
+		for _, chunk := range arguments[1].(cadence.Array).Values {
+			chunks = append(chunks, string(chunk.(cadence.String)))
+		}
+		c.templates[string(arguments[0].(cadence.String))] = chunks
+	case strings.Contains(source, "createBridgeRouter()"):
+		c.router = "A.f8d6e0586b0a20c7.FlowEVMBridgeAccessor.BridgeRouter"
+	}
+	return result, nil
+}
+
+func (c *fakeChain) ExecuteScript(_ context.Context, code []byte, arguments []cadence.Value) (cadence.Value, error) {
+	source := string(code)
+	optional := func(value string) cadence.Value {
+		if value == "" {
+			return cadence.NewOptional(nil)
+		}
+		return cadence.NewOptional(cadence.String(value))
+	}
+	var address string
+	switch {
+	case strings.Contains(source, "?.address()"):
+		return optional(c.coa), nil
+	case strings.Contains(source, ".nonce()"):
+		if string(arguments[0].(cadence.String)) != c.coa {
+			return nil, errors.New("script failed")
+		}
+		return cadence.UInt64(c.nonce), nil
+	case strings.Contains(source, "contracts.names"):
+		names := []cadence.Value{}
+		for _, name := range c.contracts {
+			names = append(names, cadence.String(name))
+		}
+		return cadence.NewArray(names), nil
+	case strings.Contains(source, "getBridgedAssetContractCode("):
+		template := "bridgedToken"
+		if arguments[1].(cadence.Bool) {
+			template = "bridgedNFT"
+		}
+		chunks, ok := c.templates[template]
+		if !ok {
+			return optional(""), nil
+		}
+		name := hex.EncodeToString([]byte(arguments[0].(cadence.String)))
+		return optional(strings.Join(chunks, name)), nil
+	case strings.Contains(source, "/storage/evmBridgeRouter"):
+		return optional(c.router), nil
+	case strings.Contains(source, "getBridgeFactoryEVMAddress().toString()"):
+		address = c.factory
+	case strings.Contains(source, "\"registrar()\""):
+		address = c.registrars[string(arguments[1].(cadence.String))]
+	case strings.Contains(source, "\"delegatedDeployer()\""):
+		address = c.delegated[string(arguments[1].(cadence.String))]
+	case strings.Contains(source, "\"getRegistry()\""):
+		address = c.registry
+	case strings.Contains(source, "\"getDeployer(string)\""):
+		address = c.deployers[string(arguments[1].(cadence.String))]
+	default:
+		return nil, errors.New("script failed")
+	}
+	if address == "" {
+		address = strings.Repeat("0", 40)
+	}
+	return cadence.String(address), nil
+}
+
+func transactionExecuted(contractAddress string) flow.Event {
+	eventType := cadence.NewEventType(nil, "EVM.TransactionExecuted", []cadence.Field{
+		{Identifier: "contractAddress", Type: cadence.StringType},
+	}, nil)
+	return flow.Event{
+		Type:  "A.f8d6e0586b0a20c7.EVM.TransactionExecuted",
+		Value: cadence.NewEvent([]cadence.Value{cadence.String(contractAddress)}).WithType(eventType),
+	}
+}
+
+func newExecutor(t *testing.T, chain *fakeChain, statePath string) *deploy.Executor {
+	config := emulatorConfig(t)
+	plan, err := deploy.NewPlan(config)
+	require.Nil(t, err)
+	return &deploy.Executor{
+		Config:    config,
+		Plan:      plan,
+		Sender:    chain,
+		Scripts:   chain,
+		StatePath: statePath,
+	}
+}
+
+func TestExecutorRun(t *testing.T) {
+	chain := newFakeChain()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	executor := newExecutor(t, chain, statePath)
+
+	state, err := executor.Run(context.Background())
+	require.Nil(t, err)
+
+	assert.Len(t, chain.sent, len(executor.Plan.Steps))
+	assert.Len(t, state.Completed, len(executor.Plan.Steps))
+	assert.Equal(t, fakeCOA, state.Outputs[deploy.OutputCOA])
+	assert.Equal(t, uint64(1), state.COANonce)
+	assert.Equal(t, deployedAt(4), state.Outputs[deploy.OutputFactory])
+	assert.Equal(t, deployedAt(1), chain.registry)
+	assert.Equal(t, deployedAt(4), chain.registrars[deployedAt(1)])
+
+	// Running a completed deployment again sends nothing
+	_, err = executor.Run(context.Background())
+	require.Nil(t, err)
+	assert.Len(t, chain.sent, len(executor.Plan.Steps))
+}
+
+func TestExecutorResume(t *testing.T) {
+	chain := newFakeChain()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	executor := newExecutor(t, chain, statePath)
+
+	// Fail the step after the factory has been deployed
+	failing := 0
+	for i, step := range executor.Plan.Steps {
+		if step.ID == "set-deployment-registry" {
+			failing = i
+		}
+	}
+	chain.failAt = failing
+
+	_, err := executor.Run(context.Background())
+	require.NotNil(t, err)
+	var stepErr *deploy.StepError
+	require.True(t, errors.As(err, &stepErr))
+	assert.Equal(t, "set-deployment-registry", stepErr.StepID)
+
+	state, err := deploy.LoadState(statePath)
+	require.Nil(t, err)
+	assert.Len(t, state.Completed, failing)
+	assert.False(t, state.IsCompleted("set-deployment-registry"))
+	assert.Contains(t, state.Outputs, deploy.OutputRegistry)
+
+	// Resuming continues with the failed step and deploys nothing twice
+	state, err = executor.Run(context.Background())
+	require.Nil(t, err)
+	assert.Len(t, state.Completed, len(executor.Plan.Steps))
+	assert.Len(t, chain.sent, len(executor.Plan.Steps))
+	assert.Len(t, chain.deployedEVM, 4)
+}
+
+func TestExecutorSkipsVerifiedSteps(t *testing.T) {
+	chain := newFakeChain()
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	executor := newExecutor(t, chain, statePath)
+
+	_, err := executor.Run(context.Background())
+	require.Nil(t, err)
+	deployed, err := deploy.LoadState(statePath)
+	require.Nil(t, err)
+
+	// Lose every record after the COA creation, including the addresses of
+	// the EVM contracts
+	state := &deploy.State{
+		Network:   deployed.Network,
+		Completed: deployed.Completed[:1],
+		Outputs:   map[string]string{deploy.OutputCOA: fakeCOA},
+		COANonce:  deployed.COANonce,
+	}
+	require.Nil(t, state.Save(statePath))
+
+	chain.sent = nil
+	resumed, err := executor.Run(context.Background())
+	require.Nil(t, err)
+
+	// Every step is verified on chain instead of being sent again, and the
+	// EVM contract addresses are recovered from the COA's nonce
+	assert.Empty(t, chain.sent)
+	assert.Len(t, chain.deployedEVM, 4)
+	assert.Len(t, resumed.Completed, len(executor.Plan.Steps))
+	assert.Equal(t, deployed.Outputs, resumed.Outputs)
+}
+
+func TestExecutorNilResult(t *testing.T) {
+	chain := newFakeChain()
+	chain.nilResult = true
+	_, err := newExecutor(t, chain, filepath.Join(t.TempDir(), "state.json")).Run(context.Background())
+	var stepErr *deploy.StepError
+	require.True(t, errors.As(err, &stepErr))
+	assert.Equal(t, "create-bridge-coa", stepErr.StepID)
+}
+
+func TestExecutorInvalidBridgeAddress(t *testing.T) {
+	chain := newFakeChain()
+	executor := newExecutor(t, chain, filepath.Join(t.TempDir(), "state.json"))
+	executor.Config.BridgeEnv.FlowEVMBridgeAddress = "not an address"
+
+	// Steps are not sent unverified
+	_, err := executor.Run(context.Background())
+	var stepErr *deploy.StepError
+	require.True(t, errors.As(err, &stepErr))
+	assert.Equal(t, "create-bridge-coa", stepErr.StepID)
+	assert.Empty(t, chain.sent)
+}
+
+func TestExecutorNetworkMismatch(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	require.Nil(t, (&deploy.State{Network: "mainnet"}).Save(statePath))
+
+	_, err := newExecutor(t, newFakeChain(), statePath).Run(context.Background())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,413 @@
+package deploy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+	coreContracts "github.com/onflow/flow-core-contracts/lib/go/templates"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+)
+
+const (
+	// Account holding the bridge contracts and the bridge COA
+	SignerBridge = "bridge"
+	// Account holding the EVM contract, which receives the bridge router
+	SignerEVM = "evm"
+)
+
+const (
+	// EVM address of the bridge COA, recorded once it is created
+	OutputCOA            = "coa"
+	OutputRegistry       = "registry"
+	OutputERC20Deployer  = "erc20Deployer"
+	OutputERC721Deployer = "erc721Deployer"
+	OutputFactory        = "factory"
+)
+
+const (
+	pathCreateCOA              = "cadence/transactions/evm/create_account.cdc"
+	pathEVMDeploy              = "cadence/transactions/evm/deploy.cdc"
+	pathDeployContract         = "cadence/transactions/bridge/admin/deploy_contract.cdc"
+	pathDeployBridgeUtils      = "cadence/transactions/bridge/admin/deploy_bridge_utils.cdc"
+	pathDeployBridgeAccessor   = "cadence/transactions/bridge/admin/deploy_bridge_accessor.cdc"
+	pathSetRegistrar           = "cadence/transactions/bridge/admin/evm/set_registrar.cdc"
+	pathSetDeploymentRegistry  = "cadence/transactions/bridge/admin/evm/set_deployment_registry.cdc"
+	pathSetDelegatedDeployer   = "cadence/transactions/bridge/admin/evm/set_delegated_deployer.cdc"
+	pathAddDeployer            = "cadence/transactions/bridge/admin/evm/add_deployer.cdc"
+	pathUpsertCodeChunks       = "cadence/transactions/bridge/admin/templates/upsert_contract_code_chunks.cdc"
+	pathClaimAccessorAndRouter = "cadence/transactions/bridge/admin/evm-integration/claim_accessor_capability_and_save_router.cdc"
+)
+
+// Config describes the bridge deployment to plan
+type Config struct {
+	// One of bridge.NetworkEmulator, bridge.NetworkTestnet or bridge.NetworkMainnet
+	Network   string
+	BridgeEnv bridge.Environment
+	CoreEnv   coreContracts.Environment
+	// Gas limit of every EVM contract deployment, defaults to 15_000_000
+	EVMGasLimit uint64
+	// $FLOW funding the bridge COA on creation, defaults to "1.0"
+	COAFunding string
+	// Account the BridgeAccessor Capability is published to,
+	// defaults to the EVM contract address of the core Environment
+	AccessorRecipient string
+}
+
+// Returns the account the BridgeAccessor Capability is published to
+func (c Config) accessorRecipient() string {
+	if c.AccessorRecipient == "" {
+		return c.CoreEnv.EVMAddress
+	}
+	return c.AccessorRecipient
+}
+
+// Argument is a named transaction argument. Arguments which depend on the
+// result of an earlier step reference that step's output instead of holding
+// a value, and are resolved to the hex EVM address String when executed
+type Argument struct {
+	Name  string
+	Value cadence.Value
+	Ref   string
+}
+
+// Step is a single rendered transaction of the deployment
+type Step struct {
+	ID          string
+	Description string
+	Path        string
+	Code        []byte
+	Arguments   []Argument
+	Signer      string
+	// Output names the EVM address deployed by the step, if any
+	Output string
+}
+
+// Plan is the ordered list of transactions standing up the bridge
+type Plan struct {
+	Network string `json:"network"`
+	Steps   []Step `json:"steps"`
+}
+
+// Builds the ordered list of transactions that deploys and configures
+// every bridge contract for the given network and Environment
+func NewPlan(config Config) (*Plan, error) {
+	switch config.Network {
+	case bridge.NetworkEmulator, bridge.NetworkTestnet, bridge.NetworkMainnet:
+	default:
+		return nil, errors.New("Unsupported network " + config.Network)
+	}
+	if config.EVMGasLimit == 0 {
+		config.EVMGasLimit = 15_000_000
+	}
+	if config.COAFunding == "" {
+		config.COAFunding = "1.0"
+	}
+	config.AccessorRecipient = config.accessorRecipient()
+
+	planner := &planner{config: config, plan: &Plan{Network: config.Network}}
+
+	funding, err := cadence.NewUFix64(config.COAFunding)
+	if err != nil {
+		return nil, err
+	}
+	planner.add(Step{
+		ID:          "create-bridge-coa",
+		Description: "Create and fund the bridge COA",
+		Path:        pathCreateCOA,
+		Arguments:   []Argument{{Name: "amount", Value: funding}},
+	})
+
+	evmContracts := []struct{ output, name, argsPath string }{
+		{OutputRegistry, "FlowBridgeDeploymentRegistry", "cadence/args/deploy-deployment-registry-args.json"},
+		{OutputERC20Deployer, "FlowEVMBridgedERC20Deployer", "cadence/args/deploy-erc20-deployer-args.json"},
+		{OutputERC721Deployer, "FlowEVMBridgedERC721Deployer", "cadence/args/deploy-erc721-deployer-args.json"},
+		{OutputFactory, "FlowBridgeFactory", "cadence/args/deploy-factory-args.json"},
+	}
+	for _, evmContract := range evmContracts {
+		planner.add(Step{
+			ID:          "deploy-evm-" + evmContract.output,
+			Description: "Deploy the " + evmContract.name + " EVM contract",
+			Path:        pathEVMDeploy,
+			Arguments: []Argument{
+				{Name: "bytecode", Value: cadenceString(bridge.GetBytecodeFromArgsJSON(evmContract.argsPath))},
+				{Name: "gasLimit", Value: cadence.NewUInt64(config.EVMGasLimit)},
+				{Name: "value", Value: cadence.UFix64(0)},
+			},
+			Output: evmContract.output,
+		})
+	}
+
+	graph, err := bridge.DependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+	order, err := graph.DeploymentOrder()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range order {
+		switch name {
+		case "FlowEVMBridgeAccessor":
+			// Deployed last, once the bridge is fully configured
+			continue
+		case "FlowEVMBridgeUtils":
+			planner.addContract(name, graph.Path(name), pathDeployBridgeUtils,
+				Argument{Name: "factoryAddress", Ref: OutputFactory})
+		default:
+			planner.addContract(name, graph.Path(name), pathDeployContract)
+		}
+	}
+
+	planner.add(Step{
+		ID:          "set-registrar",
+		Description: "Set the factory as registrar of the deployment registry",
+		Path:        pathSetRegistrar,
+		Arguments:   []Argument{{Name: "registryEVMAddressHex", Ref: OutputRegistry}},
+	})
+	planner.add(Step{
+		ID:          "set-deployment-registry",
+		Description: "Set the deployment registry of the factory",
+		Path:        pathSetDeploymentRegistry,
+		Arguments:   []Argument{{Name: "registryEVMAddressHex", Ref: OutputRegistry}},
+	})
+
+	deployers := []struct{ tag, output string }{
+		{"ERC20", OutputERC20Deployer},
+		{"ERC721", OutputERC721Deployer},
+	}
+	for _, deployer := range deployers {
+		planner.add(Step{
+			ID:          "set-delegated-deployer-" + deployer.tag,
+			Description: "Set the factory as delegated deployer of the " + deployer.tag + " deployer",
+			Path:        pathSetDelegatedDeployer,
+			Arguments:   []Argument{{Name: "deployerEVMAddressHex", Ref: deployer.output}},
+		})
+	}
+	for _, deployer := range deployers {
+		planner.add(Step{
+			ID:          "add-deployer-" + deployer.tag,
+			Description: "Add the " + deployer.tag + " deployer to the factory",
+			Path:        pathAddDeployer,
+			Arguments: []Argument{
+				{Name: "deployerTag", Value: cadenceString(deployer.tag)},
+				{Name: "deployerEVMAddressHex", Ref: deployer.output},
+			},
+		})
+	}
+
+	templates := []struct {
+		name string
+		nft  bool
+	}{
+		{"bridgedNFT", true},
+		{"bridgedToken", false},
+	}
+	for _, template := range templates {
+		chunks := []cadence.Value{}
+		for _, chunk := range bridge.GetCadenceTokenChunkedJSONArgumentsForNetwork(template.nft, config.Network) {
+			chunks = append(chunks, cadenceString(chunk))
+		}
+		planner.add(Step{
+			ID:          "upsert-code-chunks-" + template.name,
+			Description: "Store the " + template.name + " template code chunks",
+			Path:        pathUpsertCodeChunks,
+			Arguments: []Argument{
+				{Name: "forTemplate", Value: cadenceString(template.name)},
+				{Name: "newChunks", Value: cadence.NewArray(chunks)},
+			},
+		})
+	}
+
+	recipient, err := cadenceAddress(config.AccessorRecipient)
+	if err != nil {
+		return nil, err
+	}
+	planner.addContract("FlowEVMBridgeAccessor", graph.Path("FlowEVMBridgeAccessor"), pathDeployBridgeAccessor,
+		Argument{Name: "evmAddress", Value: recipient})
+
+	provider, err := cadenceAddress(config.BridgeEnv.FlowEVMBridgeAddress)
+	if err != nil {
+		return nil, err
+	}
+	planner.add(Step{
+		ID:          "claim-accessor-and-save-router",
+		Description: "Claim the BridgeAccessor Capability and save the bridge router in the EVM account",
+		Path:        pathClaimAccessorAndRouter,
+		Signer:      SignerEVM,
+		Arguments: []Argument{
+			{Name: "name", Value: cadenceString("FlowEVMBridgeAccessor")},
+			{Name: "provider", Value: provider},
+		},
+	})
+
+	if planner.err != nil {
+		return nil, planner.err
+	}
+	return planner.plan, nil
+}
+
+// Returns the step with the given ID
+func (p *Plan) Step(id string) (Step, bool) {
+	for _, step := range p.Steps {
+		if step.ID == id {
+			return step, true
+		}
+	}
+	return Step{}, false
+}
+
+// Returns the argument of the step with the given name
+func (s Step) argument(name string) Argument {
+	for _, argument := range s.Arguments {
+		if argument.Name == name {
+			return argument
+		}
+	}
+	return Argument{Name: name}
+}
+
+type planner struct {
+	config Config
+	plan   *Plan
+	err    error
+}
+
+// Renders the step transaction and appends it to the plan.
+// Steps are signed by the bridge account unless stated otherwise
+func (p *planner) add(step Step) {
+	if p.err != nil {
+		return
+	}
+	if step.Signer == "" {
+		step.Signer = SignerBridge
+	}
+	code, err := bridge.GetCadenceTransactionCode(step.Path, p.config.BridgeEnv, p.config.CoreEnv)
+	if err != nil {
+		p.err = err
+		return
+	}
+	step.Code = code
+	p.plan.Steps = append(p.plan.Steps, step)
+}
+
+// Appends a step deploying the rendered contract at contractPath with the given
+// deployment transaction, passing any init arguments after name and code
+func (p *planner) addContract(name, contractPath, transactionPath string, initArguments ...Argument) {
+	if p.err != nil {
+		return
+	}
+	code, err := bridge.GetCadenceContractCode(contractPath, p.config.BridgeEnv, p.config.CoreEnv)
+	if err != nil {
+		p.err = err
+		return
+	}
+	arguments := []Argument{
+		{Name: "name", Value: cadenceString(name)},
+		{Name: "code", Value: cadenceString(string(code))},
+	}
+	p.add(Step{
+		ID:          "deploy-contract-" + name,
+		Description: "Deploy the " + name + " contract",
+		Path:        transactionPath,
+		Arguments:   append(arguments, initArguments...),
+	})
+}
+
+func cadenceString(value string) cadence.String {
+	// NewString only fails on invalid UTF-8, which embedded sources never contain
+	str, _ := cadence.NewString(value)
+	return str
+}
+
+func cadenceAddress(hex string) (cadence.Address, error) {
+	if hex == "" {
+		return cadence.Address{}, errors.New("Missing Flow address")
+	}
+	address, err := common.HexToAddress(hex)
+	if err != nil {
+		return cadence.Address{}, err
+	}
+	return cadence.Address(address), nil
+}
+
+type argumentJSON struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value,omitempty"`
+	Ref   string          `json:"ref,omitempty"`
+}
+
+// Encodes the argument with its value as JSON-Cadence
+func (a Argument) MarshalJSON() ([]byte, error) {
+	out := argumentJSON{Name: a.Name, Ref: a.Ref}
+	if a.Value != nil {
+		value, err := jsoncdc.Encode(a.Value)
+		if err != nil {
+			return nil, err
+		}
+		out.Value = value
+	}
+	return json.Marshal(out)
+}
+
+func (a *Argument) UnmarshalJSON(data []byte) error {
+	var in argumentJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	a.Name = in.Name
+	a.Ref = in.Ref
+	a.Value = nil
+	if len(in.Value) > 0 {
+		value, err := jsoncdc.Decode(nil, in.Value)
+		if err != nil {
+			return fmt.Errorf("Invalid value of argument %s: %w", in.Name, err)
+		}
+		a.Value = value
+	}
+	return nil
+}
+
+type stepJSON struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	Path        string     `json:"path"`
+	Code        string     `json:"code"`
+	Arguments   []Argument `json:"arguments"`
+	Signer      string     `json:"signer"`
+	Output      string     `json:"output,omitempty"`
+}
+
+// Encodes the step with its code as text so plans can be reviewed
+func (s Step) MarshalJSON() ([]byte, error) {
+	return json.Marshal(stepJSON{
+		ID:          s.ID,
+		Description: s.Description,
+		Path:        s.Path,
+		Code:        string(s.Code),
+		Arguments:   s.Arguments,
+		Signer:      s.Signer,
+		Output:      s.Output,
+	})
+}
+
+func (s *Step) UnmarshalJSON(data []byte) error {
+	var in stepJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	*s = Step{
+		ID:          in.ID,
+		Description: in.Description,
+		Path:        in.Path,
+		Code:        []byte(in.Code),
+		Arguments:   in.Arguments,
+		Signer:      in.Signer,
+		Output:      in.Output,
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package deploy_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/deploy"
+)
+
+func emulatorConfig(t *testing.T) deploy.Config {
+	bridgeEnv, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkEmulator)
+	require.Nil(t, err)
+	return deploy.Config{
+		Network:   bridge.NetworkEmulator,
+		BridgeEnv: bridgeEnv,
+		CoreEnv:   coreEnv,
+	}
+}
+
+// Returns the position of every step in the plan
+func stepIndexes(plan *deploy.Plan) map[string]int {
+	indexes := make(map[string]int, len(plan.Steps))
+	for i, step := range plan.Steps {
+		indexes[step.ID] = i
+	}
+	return indexes
+}
+
+func TestNewPlan(t *testing.T) {
+	plan, err := deploy.NewPlan(emulatorConfig(t))
+	require.Nil(t, err)
+
+	// 1 COA, 4 EVM contracts, 26 Cadence contracts, 2 EVM integration,
+	// 2 delegated deployers, 2 deployers, 2 template code uploads and 1 claim
+	assert.Len(t, plan.Steps, 40)
+
+	index := stepIndexes(plan)
+	assert.Equal(t, 0, index["create-bridge-coa"])
+	assert.Less(t, index["deploy-evm-factory"], index["deploy-contract-FlowEVMBridgeUtils"])
+	assert.Less(t, index["deploy-contract-FlowEVMBridgeUtils"], index["set-deployment-registry"])
+	assert.Less(t, index["deploy-contract-FlowEVMBridgeTemplates"], index["upsert-code-chunks-bridgedNFT"])
+	assert.Less(t, index["add-deployer-ERC721"], index["deploy-contract-FlowEVMBridgeAccessor"])
+	assert.Equal(t, len(plan.Steps)-1, index["claim-accessor-and-save-router"])
+
+	for _, step := range plan.Steps {
+		assert.NotContains(t, string(step.Code), "import \"", step.ID)
+	}
+
+	utils, ok := plan.Step("deploy-contract-FlowEVMBridgeUtils")
+	require.True(t, ok)
+	assert.Equal(t, "cadence/transactions/bridge/admin/deploy_bridge_utils.cdc", utils.Path)
+	require.Len(t, utils.Arguments, 3)
+	assert.Equal(t, cadence.String("FlowEVMBridgeUtils"), utils.Arguments[0].Value)
+	assert.NotContains(t, utils.Arguments[1].Value.String(), "import \\\"")
+	assert.Equal(t, deploy.OutputFactory, utils.Arguments[2].Ref)
+
+	factory, _ := plan.Step("deploy-evm-factory")
+	assert.Equal(t, deploy.OutputFactory, factory.Output)
+
+	claim, _ := plan.Step("claim-accessor-and-save-router")
+	assert.Equal(t, deploy.SignerEVM, claim.Signer)
+	assert.Equal(t, "0xf8d6e0586b0a20c7", claim.Arguments[1].Value.String())
+}
+
+func TestPlanJSON(t *testing.T) {
+	plan, err := deploy.NewPlan(emulatorConfig(t))
+	require.Nil(t, err)
+
+	encoded, err := json.Marshal(plan)
+	require.Nil(t, err)
+
+	var decoded deploy.Plan
+	require.Nil(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, plan.Network, decoded.Network)
+	require.Len(t, decoded.Steps, len(plan.Steps))
+	for i, step := range plan.Steps {
+		assert.Equal(t, step, decoded.Steps[i])
+	}
+}
+
+func TestNewPlanUnsupportedNetwork(t *testing.T) {
+	config := emulatorConfig(t)
+	config.Network = "previewnet"
+	_, err := deploy.NewPlan(config)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,259 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+)
+
+// EventSource fetches events by type and reports the latest sealed height.
+// The access.Client of the Flow Go SDK satisfies it
+type EventSource interface {
+	GetLatestBlockHeader(ctx context.Context, isSealed bool) (*flow.BlockHeader, error)
+	GetEventsForHeightRange(ctx context.Context, eventType string, startHeight uint64, endHeight uint64) ([]flow.BlockEvents, error)
+}
+
+// EventKey identifies an event on chain
+type EventKey struct {
+	TransactionID flow.Identifier `json:"transactionId"`
+	EventIndex    int             `json:"eventIndex"`
+}
+
+// Checkpoint is the progress of a Consumer
+type Checkpoint struct {
+	// Every event up to and including this height was handled
+	Height uint64 `json:"height"`
+	// Events already handled in the blocks after Height, skipped on resume
+	Handled []EventKey `json:"handled,omitempty"`
+}
+
+// CheckpointStore persists the progress of a Consumer
+type CheckpointStore interface {
+	// Returns the saved checkpoint, and false if none was saved yet
+	Load(ctx context.Context) (Checkpoint, bool, error)
+	Save(ctx context.Context, checkpoint Checkpoint) error
+}
+
+// FileCheckpointStore stores a checkpoint as a JSON file
+type FileCheckpointStore struct {
+	Path string
+}
+
+func (s FileCheckpointStore) Load(_ context.Context) (Checkpoint, bool, error) {
+	var checkpoint Checkpoint
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, false, nil
+	}
+	if err != nil {
+		return checkpoint, false, err
+	}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return checkpoint, false, fmt.Errorf("Cannot read checkpoint %s: %w", s.Path, err)
+	}
+	return checkpoint, true, nil
+}
+
+// Writes the checkpoint, replacing the previous file atomically
+func (s FileCheckpointStore) Save(_ context.Context, checkpoint Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+// MemoryCheckpointStore keeps a checkpoint in memory
+type MemoryCheckpointStore struct {
+	Checkpoint *Checkpoint
+}
+
+func (s *MemoryCheckpointStore) Load(_ context.Context) (Checkpoint, bool, error) {
+	if s.Checkpoint == nil {
+		return Checkpoint{}, false, nil
+	}
+	return *s.Checkpoint, true, nil
+}
+
+func (s *MemoryCheckpointStore) Save(_ context.Context, checkpoint Checkpoint) error {
+	s.Checkpoint = &checkpoint
+	return nil
+}
+
+// DefaultPageSize is the number of blocks a Consumer requests at once,
+// the maximum height range accepted by access nodes
+const DefaultPageSize = 250
+
+// Consumer passes every bridge event of an EventSource to a handler exactly
+// once, in block order. It pages through block ranges up to the latest sealed
+// height, de-duplicates events on (transaction ID, event index) and saves a
+// checkpoint after every handled event, so it resumes where it stopped after
+// a failure. Only a crash between handling an event and saving the
+// checkpoint can deliver that event again
+type Consumer struct {
+	Source      EventSource
+	EventTypes  []string
+	Checkpoints CheckpointStore
+	// First height to consume when no checkpoint was saved
+	StartHeight uint64
+	PageSize    uint64
+	Handle      func(ctx context.Context, record Record) error
+}
+
+// Handler handles the records passed by a Consumer
+type Handler interface {
+	Handle(ctx context.Context, record Record) error
+}
+
+// Returns a consumer of every bridge event type of the Environment
+func NewConsumer(source EventSource, env bridge.Environment, checkpoints CheckpointStore, startHeight uint64, handle func(ctx context.Context, record Record) error) *Consumer {
+	return &Consumer{
+		Source:      source,
+		EventTypes:  TypeIDs(env),
+		Checkpoints: checkpoints,
+		StartHeight: startHeight,
+		PageSize:    DefaultPageSize,
+		Handle:      handle,
+	}
+}
+
+// Returns a consumer of the given fully qualified event types, which passes
+// every event to the handler
+func ConsumerFor(handler Handler, eventTypes []string, source EventSource, checkpoints CheckpointStore, startHeight uint64) *Consumer {
+	return &Consumer{
+		Source:      source,
+		EventTypes:  eventTypes,
+		Checkpoints: checkpoints,
+		StartHeight: startHeight,
+		PageSize:    DefaultPageSize,
+		Handle:      handler.Handle,
+	}
+}
+
+// Consumes events up to the latest sealed height and returns the checkpoint
+func (c *Consumer) Sync(ctx context.Context) (Checkpoint, error) {
+	checkpoint, ok, err := c.Checkpoints.Load(ctx)
+	if err != nil {
+		return checkpoint, err
+	}
+	// The root block at height 0 has no bridge events, so a start height of 0
+	// starts at height 1
+	if !ok && c.StartHeight > 0 {
+		checkpoint.Height = c.StartHeight - 1
+	}
+	header, err := c.Source.GetLatestBlockHeader(ctx, true)
+	if err != nil {
+		return checkpoint, fmt.Errorf("Cannot get latest sealed block: %w", err)
+	}
+
+	pageSize := c.PageSize
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	for checkpoint.Height < header.Height {
+		start := checkpoint.Height + 1
+		end := start + pageSize - 1
+		if end > header.Height {
+			end = header.Height
+		}
+		checkpoint, err = c.consumePage(ctx, checkpoint, start, end)
+		if err != nil {
+			return checkpoint, err
+		}
+	}
+	return checkpoint, nil
+}
+
+// Syncs immediately and then at every interval until the context is done
+// or an event cannot be handled
+func (c *Consumer) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.Sync(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Consumer) consumePage(ctx context.Context, checkpoint Checkpoint, start, end uint64) (Checkpoint, error) {
+	records := []Record{}
+	for _, eventType := range c.EventTypes {
+		blocks, err := c.Source.GetEventsForHeightRange(ctx, eventType, start, end)
+		if err != nil {
+			return checkpoint, fmt.Errorf("Cannot get %s events for heights %d-%d: %w", eventType, start, end, err)
+		}
+		for _, block := range blocks {
+			decoded, err := DecodeBlockEvents(block)
+			if err != nil {
+				return checkpoint, err
+			}
+			records = append(records, decoded...)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].BlockHeight != records[j].BlockHeight {
+			return records[i].BlockHeight < records[j].BlockHeight
+		}
+		if records[i].TransactionIndex != records[j].TransactionIndex {
+			return records[i].TransactionIndex < records[j].TransactionIndex
+		}
+		return records[i].EventIndex < records[j].EventIndex
+	})
+
+	handled := map[EventKey]bool{}
+	for _, key := range checkpoint.Handled {
+		handled[key] = true
+	}
+	for _, record := range records {
+		if record.BlockHeight > checkpoint.Height+1 {
+			// Every event of the blocks before this one was handled
+			checkpoint = Checkpoint{Height: record.BlockHeight - 1}
+			handled = map[EventKey]bool{}
+		}
+		// Handled events are those of the block after the checkpoint, so
+		// events without transaction ID in other blocks are not mistaken for them
+		key := EventKey{TransactionID: record.TransactionID, EventIndex: record.EventIndex}
+		if handled[key] {
+			continue
+		}
+		if err := c.Handle(ctx, record); err != nil {
+			return checkpoint, fmt.Errorf("Cannot handle event %d of transaction %s at height %d: %w",
+				record.EventIndex, record.TransactionID, record.BlockHeight, err)
+		}
+		handled[key] = true
+		checkpoint.Handled = append(checkpoint.Handled, key)
+		if err := c.Checkpoints.Save(ctx, checkpoint); err != nil {
+			return checkpoint, err
+		}
+	}
+
+	checkpoint = Checkpoint{Height: end}
+	return checkpoint, c.Checkpoints.Save(ctx, checkpoint)
+}
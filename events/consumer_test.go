@@ -0,0 +1,132 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+)
+
+func newSource(t *testing.T) *events.MemorySource {
+	event := bridgedNFTToEVM(t)
+	source := events.NewMemorySource()
+	for _, position := range []struct {
+		height     uint64
+		id         byte
+		eventIndex int
+	}{
+		{3, 1, 0},
+		{5, 2, 0},
+		{5, 2, 1},
+		{320, 3, 0},
+	} {
+		source.Add(position.height, flow.Event{
+			Type:          event.EventType.ID(),
+			TransactionID: flow.Identifier{position.id},
+			EventIndex:    position.eventIndex,
+			Value:         event,
+		})
+	}
+	// Returned twice by the source, handled once
+	source.Add(5, source.Blocks[5].Events[1])
+	source.LatestHeight = 400
+	return source
+}
+
+func newConsumer(t *testing.T, source events.EventSource, checkpoints events.CheckpointStore, handle func(ctx context.Context, record events.Record) error) *events.Consumer {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	consumer := events.NewConsumer(source, env, checkpoints, 1, handle)
+	consumer.PageSize = 100
+	return consumer
+}
+
+func TestConsumerResumes(t *testing.T) {
+	ctx := context.Background()
+	checkpoints := &events.MemoryCheckpointStore{}
+	handled := []events.EventKey{}
+	fail := true
+	consumer := newConsumer(t, newSource(t), checkpoints, func(_ context.Context, record events.Record) error {
+		key := events.EventKey{TransactionID: record.TransactionID, EventIndex: record.EventIndex}
+		if fail && key.EventIndex == 1 {
+			return errors.New("unavailable")
+		}
+		handled = append(handled, key)
+		return nil
+	})
+
+	_, err := consumer.Sync(ctx)
+	require.ErrorContains(t, err, "unavailable")
+	assert.Equal(t, &events.Checkpoint{
+		Height:  4,
+		Handled: []events.EventKey{{TransactionID: flow.Identifier{2}}},
+	}, checkpoints.Checkpoint)
+
+	fail = false
+	checkpoint, err := consumer.Sync(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, events.Checkpoint{Height: 400}, checkpoint)
+	assert.Equal(t, []events.EventKey{
+		{TransactionID: flow.Identifier{1}},
+		{TransactionID: flow.Identifier{2}},
+		{TransactionID: flow.Identifier{2}, EventIndex: 1},
+		{TransactionID: flow.Identifier{3}},
+	}, handled)
+
+	// Nothing new to consume
+	_, err = consumer.Sync(ctx)
+	require.Nil(t, err)
+	assert.Len(t, handled, 4)
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	store := events.FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+
+	_, ok, err := store.Load(ctx)
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	saved := events.Checkpoint{Height: 10, Handled: []events.EventKey{{TransactionID: flow.Identifier{1}, EventIndex: 2}}}
+	require.Nil(t, store.Save(ctx, saved))
+	loaded, ok, err := store.Load(ctx)
+	require.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, saved, loaded)
+}
+
+func TestReadJSONLSource(t *testing.T) {
+	payload, err := jsoncdc.Encode(bridgedNFTToEVM(t))
+	require.Nil(t, err)
+	line, err := json.Marshal(events.FixtureEvent{
+		BlockHeight:   7,
+		TransactionID: flow.Identifier{1}.String(),
+		Type:          "A.1e4aa0b87d10b141.IFlowEVMNFTBridge.BridgedNFTToEVM",
+		Payload:       payload,
+	})
+	require.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	require.Nil(t, os.WriteFile(path, append(line, '\n'), 0644))
+
+	source, err := events.ReadJSONLSource(path)
+	require.Nil(t, err)
+	records := []events.Record{}
+	_, err = newConsumer(t, source, &events.MemoryCheckpointStore{}, func(_ context.Context, record events.Record) error {
+		records = append(records, record)
+		return nil
+	}).Sync(context.Background())
+	require.Nil(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, uint64(7), records[0].BlockHeight)
+	assert.IsType(t, events.BridgedNFTToEVM{}, records[0].Event)
+}
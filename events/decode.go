@@ -0,0 +1,487 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/encoding/ccf"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// ErrUnknownEvent is returned when decoding an event that is not a bridge event
+var ErrUnknownEvent = errors.New("unknown bridge event")
+
+// Record is a decoded bridge event together with its position on chain
+type Record struct {
+	Event       Event
+	TypeID      string
+	BlockID     flow.Identifier
+	BlockHeight uint64
+	// Zero if the source does not provide block timestamps
+	BlockTimestamp   time.Time
+	TransactionID    flow.Identifier
+	TransactionIndex int
+	EventIndex       int
+}
+
+// Decodes a Cadence event into the Go struct of its bridge event type. The
+// type is matched by qualified identifier only, not by the address of the
+// contract declaring it, so any contract can declare an event decoded as a
+// bridge event. Callers must only pass events whose fully qualified TypeID is
+// one of TypeIDs of their Environment, as a Consumer does
+func Decode(event cadence.Event) (Event, error) {
+	if event.EventType == nil {
+		return nil, errors.New("Cannot decode event without type")
+	}
+	qualified := event.EventType.QualifiedIdentifier
+	decoder, ok := decoders[qualified]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", qualified, ErrUnknownEvent)
+	}
+	decoded, err := decoder(fields{event: event})
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decode %s: %w", qualified, err)
+	}
+	return decoded, nil
+}
+
+// Decodes a JSON-CDC encoded bridge event
+func DecodeJSONCDC(payload []byte) (Event, error) {
+	value, err := jsoncdc.Decode(nil, payload)
+	if err != nil {
+		return nil, err
+	}
+	return decodeValue(value)
+}
+
+// Decodes a CCF encoded bridge event
+func DecodeCCF(payload []byte) (Event, error) {
+	value, err := ccf.Decode(nil, payload)
+	if err != nil {
+		return nil, err
+	}
+	return decodeValue(value)
+}
+
+// Decodes an event returned by an access node. The decoded value of the event
+// is used if present, otherwise the payload is decoded as JSON-CDC or CCF. Like
+// Decode, it does not check the address declaring the type
+func DecodeFlowEvent(event flow.Event) (Event, error) {
+	if event.Value.EventType != nil {
+		return Decode(event.Value)
+	}
+	if len(event.Payload) == 0 {
+		return nil, errors.New("Cannot decode event without value or payload")
+	}
+	if event.Payload[0] == '{' {
+		return DecodeJSONCDC(event.Payload)
+	}
+	return DecodeCCF(event.Payload)
+}
+
+// Decodes every bridge event of a block. Events of other types are skipped
+func DecodeBlockEvents(block flow.BlockEvents) ([]Record, error) {
+	records := []Record{}
+	for _, event := range block.Events {
+		decoded, err := DecodeFlowEvent(event)
+		if errors.Is(err, ErrUnknownEvent) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Cannot decode event %d of transaction %s: %w", event.EventIndex, event.TransactionID, err)
+		}
+		records = append(records, Record{
+			Event:            decoded,
+			TypeID:           event.Type,
+			BlockID:          block.BlockID,
+			BlockHeight:      block.Height,
+			BlockTimestamp:   block.BlockTimestamp,
+			TransactionID:    event.TransactionID,
+			TransactionIndex: event.TransactionIndex,
+			EventIndex:       event.EventIndex,
+		})
+	}
+	return records, nil
+}
+
+func decodeValue(value cadence.Value) (Event, error) {
+	event, ok := value.(cadence.Event)
+	if !ok {
+		return nil, fmt.Errorf("Expected event, got %s", value.Type().ID())
+	}
+	return Decode(event)
+}
+
+var decoders = map[string]func(f fields) (Event, error){
+	TypeOnboarded: func(f fields) (Event, error) {
+		e := Onboarded{}
+		err := f.decode(
+			f.string("type", &e.Type),
+			f.address("cadenceContractAddress", &e.CadenceContractAddress),
+			f.string("evmContractAddress", &e.EVMContractAddress),
+		)
+		return e, err
+	},
+	TypeBridgeDefiningContractDeployed: func(f fields) (Event, error) {
+		e := BridgeDefiningContractDeployed{}
+		err := f.decode(
+			f.string("contractName", &e.ContractName),
+			f.string("assetName", &e.AssetName),
+			f.string("symbol", &e.Symbol),
+			f.bool("isERC721", &e.IsERC721),
+			f.string("evmContractAddress", &e.EVMContractAddress),
+		)
+		return e, err
+	},
+	TypeBridgedNFTBurned: func(f fields) (Event, error) {
+		e := BridgedNFTBurned{}
+		err := f.decode(
+			f.string("type", &e.Type),
+			f.uint64("id", &e.ID),
+			f.uint256("evmID", &e.EVMID),
+			f.uint64("uuid", &e.UUID),
+			f.string("erc721Address", &e.ERC721Address),
+		)
+		return e, err
+	},
+	TypeBridgedNFTToEVM: func(f fields) (Event, error) {
+		e := BridgedNFTToEVM{}
+		err := f.decode(
+			f.string("type", &e.Type),
+			f.uint64("id", &e.ID),
+			f.uint64("uuid", &e.UUID),
+			f.uint256("evmID", &e.EVMID),
+			f.string("to", &e.To),
+			f.string("evmContractAddress", &e.EVMContractAddress),
+			f.address("bridgeAddress", &e.BridgeAddress),
+		)
+		return e, err
+	},
+	TypeBridgedNFTFromEVM: func(f fields) (Event, error) {
+		e := BridgedNFTFromEVM{}
+		err := f.decode(
+			f.string("type", &e.Type),
+			f.uint64("id", &e.ID),
+			f.uint64("uuid", &e.UUID),
+			f.uint256("evmID", &e.EVMID),
+			f.string("caller", &e.Caller),
+			f.string("evmContractAddress", &e.EVMContractAddress),
+			f.address("bridgeAddress", &e.BridgeAddress),
+		)
+		return e, err
+	},
+	TypeBridgedTokensToEVM: func(f fields) (Event, error) {
+		e := BridgedTokensToEVM{}
+		err := f.decode(
+			f.string("type", &e.Type),
+			f.ufix64("amount", &e.Amount),
+			f.uint64("bridgedUUID", &e.BridgedUUID),
+			f.string("to", &e.To),
+			f.string("evmContractAddress", &e.EVMContractAddress),
+			f.address("bridgeAddress", &e.BridgeAddress),
+		)
+		return e, err
+	},
+	TypeBridgedTokensFromEVM: func(f fields) (Event, error) {
+		e := BridgedTokensFromEVM{}
+		err := f.decode(
+			f.string("type", &e.Type),
+			f.uint256("amount", &e.Amount),
+			f.uint64("bridgedUUID", &e.BridgedUUID),
+			f.string("caller", &e.Caller),
+			f.string("evmContractAddress", &e.EVMContractAddress),
+			f.address("bridgeAddress", &e.BridgeAddress),
+		)
+		return e, err
+	},
+	TypeBridgeFeeUpdated: func(f fields) (Event, error) {
+		e := BridgeFeeUpdated{}
+		err := f.decode(
+			f.ufix64("old", &e.Old),
+			f.ufix64("new", &e.New),
+			f.bool("isOnboarding", &e.IsOnboarding),
+		)
+		return e, err
+	},
+	TypeHandlerConfigured: func(f fields) (Event, error) {
+		e := HandlerConfigured{}
+		err := f.decode(
+			f.string("targetType", &e.TargetType),
+			f.optionalString("targetEVMAddress", &e.TargetEVMAddress),
+			f.bool("isEnabled", &e.IsEnabled),
+		)
+		return e, err
+	},
+	TypeBridgePauseStatusUpdated: func(f fields) (Event, error) {
+		e := BridgePauseStatusUpdated{}
+		err := f.decode(
+			f.bool("paused", &e.Paused),
+		)
+		return e, err
+	},
+	TypeAssetPauseStatusUpdated: func(f fields) (Event, error) {
+		e := AssetPauseStatusUpdated{}
+		err := f.decode(
+			f.bool("paused", &e.Paused),
+			f.string("type", &e.Type),
+			f.string("evmAddress", &e.EVMAddress),
+		)
+		return e, err
+	},
+	TypeAssociationUpdated: func(f fields) (Event, error) {
+		e := AssociationUpdated{}
+		err := f.decode(
+			f.string("type", &e.Type),
+			f.string("evmAddress", &e.EVMAddress),
+		)
+		return e, err
+	},
+	TypeCustomAssociationEstablished: func(f fields) (Event, error) {
+		e := CustomAssociationEstablished{}
+		err := f.decode(
+			f.string("type", &e.Type),
+			f.string("evmContractAddress", &e.EVMContractAddress),
+			f.uint8("nativeVMRawValue", &e.NativeVMRawValue),
+			f.bool("updatedFromBridged", &e.UpdatedFromBridged),
+			f.optionalString("fulfillmentMinterType", &e.FulfillmentMinterType),
+			f.optionalAddress("fulfillmentMinterOrigin", &e.FulfillmentMinterOrigin),
+			f.optionalUint64("fulfillmentMinterCapID", &e.FulfillmentMinterCapID),
+			f.optionalUint64("fulfillmentMinterUUID", &e.FulfillmentMinterUUID),
+			f.uint64("configUUID", &e.ConfigUUID),
+		)
+		return e, err
+	},
+	TypeFulfilledFromEVM: func(f fields) (Event, error) {
+		e := FulfilledFromEVM{}
+		err := f.decode(
+			f.string("type", &e.Type),
+			f.uint256("requestedID", &e.RequestedID),
+			f.uint64("resultID", &e.ResultID),
+			f.uint64("uuid", &e.UUID),
+		)
+		return e, err
+	},
+	TypeTemplateUpdated: func(f fields) (Event, error) {
+		e := TemplateUpdated{}
+		err := f.decode(
+			f.string("name", &e.Name),
+			f.optionalBool("isNew", &e.IsNew),
+		)
+		return e, err
+	},
+	TypeHandlerEnabled: func(f fields) (Event, error) {
+		e := HandlerEnabled{}
+		err := f.decode(
+			f.string("handlerType", &e.HandlerType),
+			f.uint64("handlerUUID", &e.HandlerUUID),
+			f.string("targetType", &e.TargetType),
+			f.string("targetEVMAddress", &e.TargetEVMAddress),
+		)
+		return e, err
+	},
+	TypeHandlerDisabled: func(f fields) (Event, error) {
+		e := HandlerDisabled{}
+		err := f.decode(
+			f.string("handlerType", &e.HandlerType),
+			f.uint64("handlerUUID", &e.HandlerUUID),
+			f.optionalString("targetType", &e.TargetType),
+			f.optionalString("targetEVMAddress", &e.TargetEVMAddress),
+		)
+		return e, err
+	},
+	TypeMinterSet: func(f fields) (Event, error) {
+		e := MinterSet{}
+		err := f.decode(
+			f.string("handlerType", &e.HandlerType),
+			f.uint64("handlerUUID", &e.HandlerUUID),
+			f.optionalString("targetType", &e.TargetType),
+			f.optionalString("targetEVMAddress", &e.TargetEVMAddress),
+			f.string("minterType", &e.MinterType),
+			f.uint64("minterUUID", &e.MinterUUID),
+		)
+		return e, err
+	},
+}
+
+// fields reads the fields of an event by name. Each reader assigns the field
+// to its target and returns an error if the field is missing or has an
+// unexpected type
+type fields struct {
+	event cadence.Event
+}
+
+// Combines the errors of the field readers
+func (f fields) decode(errs ...error) error {
+	return errors.Join(errs...)
+}
+
+func (f fields) value(name string) (cadence.Value, error) {
+	value := cadence.SearchFieldByName(f.event, name)
+	if value == nil {
+		return nil, fmt.Errorf("missing field %s", name)
+	}
+	return value, nil
+}
+
+// Returns the value of an optional field, nil if the field is nil
+func (f fields) optional(name string) (cadence.Value, error) {
+	value, err := f.value(name)
+	if err != nil {
+		return nil, err
+	}
+	if optional, ok := value.(cadence.Optional); ok {
+		return optional.Value, nil
+	}
+	return value, nil
+}
+
+func mismatch(name string, expected string, value cadence.Value) error {
+	return fmt.Errorf("field %s: expected %s, got %s", name, expected, value.Type().ID())
+}
+
+func (f fields) string(name string, target *string) error {
+	value, err := f.value(name)
+	if err != nil {
+		return err
+	}
+	return assignString(name, value, target)
+}
+
+func (f fields) optionalString(name string, target **string) error {
+	value, err := f.optional(name)
+	if err != nil || value == nil {
+		return err
+	}
+	*target = new(string)
+	return assignString(name, value, *target)
+}
+
+func assignString(name string, value cadence.Value, target *string) error {
+	s, ok := value.(cadence.String)
+	if !ok {
+		return mismatch(name, "String", value)
+	}
+	*target = string(s)
+	return nil
+}
+
+func (f fields) bool(name string, target *bool) error {
+	value, err := f.value(name)
+	if err != nil {
+		return err
+	}
+	return assignBool(name, value, target)
+}
+
+func (f fields) optionalBool(name string, target **bool) error {
+	value, err := f.optional(name)
+	if err != nil || value == nil {
+		return err
+	}
+	*target = new(bool)
+	return assignBool(name, value, *target)
+}
+
+func assignBool(name string, value cadence.Value, target *bool) error {
+	b, ok := value.(cadence.Bool)
+	if !ok {
+		return mismatch(name, "Bool", value)
+	}
+	*target = bool(b)
+	return nil
+}
+
+func (f fields) uint8(name string, target *uint8) error {
+	value, err := f.value(name)
+	if err != nil {
+		return err
+	}
+	n, ok := value.(cadence.UInt8)
+	if !ok {
+		return mismatch(name, "UInt8", value)
+	}
+	*target = uint8(n)
+	return nil
+}
+
+func (f fields) uint64(name string, target *uint64) error {
+	value, err := f.value(name)
+	if err != nil {
+		return err
+	}
+	return assignUint64(name, value, target)
+}
+
+func (f fields) optionalUint64(name string, target **uint64) error {
+	value, err := f.optional(name)
+	if err != nil || value == nil {
+		return err
+	}
+	*target = new(uint64)
+	return assignUint64(name, value, *target)
+}
+
+func assignUint64(name string, value cadence.Value, target *uint64) error {
+	n, ok := value.(cadence.UInt64)
+	if !ok {
+		return mismatch(name, "UInt64", value)
+	}
+	*target = uint64(n)
+	return nil
+}
+
+func (f fields) uint256(name string, target **big.Int) error {
+	value, err := f.value(name)
+	if err != nil {
+		return err
+	}
+	n, ok := value.(cadence.UInt256)
+	if !ok {
+		return mismatch(name, "UInt256", value)
+	}
+	*target = new(big.Int).Set(n.Value)
+	return nil
+}
+
+func (f fields) ufix64(name string, target *cadence.UFix64) error {
+	value, err := f.value(name)
+	if err != nil {
+		return err
+	}
+	n, ok := value.(cadence.UFix64)
+	if !ok {
+		return mismatch(name, "UFix64", value)
+	}
+	*target = n
+	return nil
+}
+
+func (f fields) address(name string, target *flow.Address) error {
+	value, err := f.value(name)
+	if err != nil {
+		return err
+	}
+	return assignAddress(name, value, target)
+}
+
+func (f fields) optionalAddress(name string, target **flow.Address) error {
+	value, err := f.optional(name)
+	if err != nil || value == nil {
+		return err
+	}
+	*target = new(flow.Address)
+	return assignAddress(name, value, *target)
+}
+
+func assignAddress(name string, value cadence.Value, target *flow.Address) error {
+	address, ok := value.(cadence.Address)
+	if !ok {
+		return mismatch(name, "Address", value)
+	}
+	*target = flow.Address(address)
+	return nil
+}
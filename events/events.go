@@ -0,0 +1,191 @@
+package events
+
+import (
+	"math/big"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Event is a decoded bridge event
+type Event interface {
+	// Returns the qualified identifier of the event type, e.g. TypeOnboarded
+	EventType() string
+}
+
+// Onboarded is emitted when a Cadence type or EVM contract is onboarded to the bridge
+type Onboarded struct {
+	Type                   string
+	CadenceContractAddress flow.Address
+	EVMContractAddress     string
+}
+
+// BridgeDefiningContractDeployed is emitted when the bridge deploys a contract
+// defining the bridged representation of an asset
+type BridgeDefiningContractDeployed struct {
+	ContractName       string
+	AssetName          string
+	Symbol             string
+	IsERC721           bool
+	EVMContractAddress string
+}
+
+// BridgedNFTBurned is emitted when a bridged NFT is burned, e.g. after its
+// association was updated to a custom cross-VM implementation
+type BridgedNFTBurned struct {
+	Type          string
+	ID            uint64
+	EVMID         *big.Int
+	UUID          uint64
+	ERC721Address string
+}
+
+// BridgedNFTToEVM is emitted when an NFT is bridged from Cadence to EVM
+type BridgedNFTToEVM struct {
+	Type               string
+	ID                 uint64
+	UUID               uint64
+	EVMID              *big.Int
+	To                 string
+	EVMContractAddress string
+	BridgeAddress      flow.Address
+}
+
+// BridgedNFTFromEVM is emitted when an NFT is bridged from EVM to Cadence
+type BridgedNFTFromEVM struct {
+	Type               string
+	ID                 uint64
+	UUID               uint64
+	EVMID              *big.Int
+	Caller             string
+	EVMContractAddress string
+	BridgeAddress      flow.Address
+}
+
+// BridgedTokensToEVM is emitted when fungible tokens are bridged from Cadence to EVM
+type BridgedTokensToEVM struct {
+	Type               string
+	Amount             cadence.UFix64
+	BridgedUUID        uint64
+	To                 string
+	EVMContractAddress string
+	BridgeAddress      flow.Address
+}
+
+// BridgedTokensFromEVM is emitted when fungible tokens are bridged from EVM to
+// Cadence. The amount is denominated in the ERC20's smallest unit
+type BridgedTokensFromEVM struct {
+	Type               string
+	Amount             *big.Int
+	BridgedUUID        uint64
+	Caller             string
+	EVMContractAddress string
+	BridgeAddress      flow.Address
+}
+
+// BridgeFeeUpdated is emitted when the onboarding or base fee changes
+type BridgeFeeUpdated struct {
+	Old          cadence.UFix64
+	New          cadence.UFix64
+	IsOnboarding bool
+}
+
+// HandlerConfigured is emitted when a token handler is added or reconfigured
+type HandlerConfigured struct {
+	TargetType       string
+	TargetEVMAddress *string
+	IsEnabled        bool
+}
+
+// BridgePauseStatusUpdated is emitted when the whole bridge is paused or unpaused
+type BridgePauseStatusUpdated struct {
+	Paused bool
+}
+
+// AssetPauseStatusUpdated is emitted when bridging of a single asset is paused or unpaused
+type AssetPauseStatusUpdated struct {
+	Paused     bool
+	Type       string
+	EVMAddress string
+}
+
+// AssociationUpdated is emitted when a Cadence type is associated with an EVM contract
+type AssociationUpdated struct {
+	Type       string
+	EVMAddress string
+}
+
+// CustomAssociationEstablished is emitted when a custom cross-VM association
+// is registered with the bridge
+type CustomAssociationEstablished struct {
+	Type                    string
+	EVMContractAddress      string
+	NativeVMRawValue        uint8
+	UpdatedFromBridged      bool
+	FulfillmentMinterType   *string
+	FulfillmentMinterOrigin *flow.Address
+	FulfillmentMinterCapID  *uint64
+	FulfillmentMinterUUID   *uint64
+	ConfigUUID              uint64
+}
+
+// FulfilledFromEVM is emitted when an EVM-native NFT with a custom association
+// is fulfilled into Cadence by the project's minter
+type FulfilledFromEVM struct {
+	Type        string
+	RequestedID *big.Int
+	ResultID    uint64
+	UUID        uint64
+}
+
+// TemplateUpdated is the Updated event emitted when template code chunks are
+// stored or removed. IsNew is nil when the template was removed
+type TemplateUpdated struct {
+	Name  string
+	IsNew *bool
+}
+
+// HandlerEnabled is emitted when a token handler is enabled
+type HandlerEnabled struct {
+	HandlerType      string
+	HandlerUUID      uint64
+	TargetType       string
+	TargetEVMAddress string
+}
+
+// HandlerDisabled is emitted when a token handler is disabled
+type HandlerDisabled struct {
+	HandlerType      string
+	HandlerUUID      uint64
+	TargetType       *string
+	TargetEVMAddress *string
+}
+
+// MinterSet is emitted when the minter of a token handler is set
+type MinterSet struct {
+	HandlerType      string
+	HandlerUUID      uint64
+	TargetType       *string
+	TargetEVMAddress *string
+	MinterType       string
+	MinterUUID       uint64
+}
+
+func (Onboarded) EventType() string                      { return TypeOnboarded }
+func (BridgeDefiningContractDeployed) EventType() string { return TypeBridgeDefiningContractDeployed }
+func (BridgedNFTBurned) EventType() string               { return TypeBridgedNFTBurned }
+func (BridgedNFTToEVM) EventType() string                { return TypeBridgedNFTToEVM }
+func (BridgedNFTFromEVM) EventType() string              { return TypeBridgedNFTFromEVM }
+func (BridgedTokensToEVM) EventType() string             { return TypeBridgedTokensToEVM }
+func (BridgedTokensFromEVM) EventType() string           { return TypeBridgedTokensFromEVM }
+func (BridgeFeeUpdated) EventType() string               { return TypeBridgeFeeUpdated }
+func (HandlerConfigured) EventType() string              { return TypeHandlerConfigured }
+func (BridgePauseStatusUpdated) EventType() string       { return TypeBridgePauseStatusUpdated }
+func (AssetPauseStatusUpdated) EventType() string        { return TypeAssetPauseStatusUpdated }
+func (AssociationUpdated) EventType() string             { return TypeAssociationUpdated }
+func (CustomAssociationEstablished) EventType() string   { return TypeCustomAssociationEstablished }
+func (FulfilledFromEVM) EventType() string               { return TypeFulfilledFromEVM }
+func (TemplateUpdated) EventType() string                { return TypeTemplateUpdated }
+func (HandlerEnabled) EventType() string                 { return TypeHandlerEnabled }
+func (HandlerDisabled) EventType() string                { return TypeHandlerDisabled }
+func (MinterSet) EventType() string                      { return TypeMinterSet }
@@ -0,0 +1,162 @@
+package events_test
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/encoding/ccf"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+)
+
+const bridgeAddress = "1e4aa0b87d10b141"
+
+func newEvent(t *testing.T, qualified string, fields []cadence.Field, values []cadence.Value) cadence.Event {
+	address, err := common.HexToAddress(bridgeAddress)
+	require.Nil(t, err)
+	contract, _, _ := strings.Cut(qualified, ".")
+	location := common.NewAddressLocation(nil, address, contract)
+	eventType := cadence.NewEventType(location, qualified, fields, nil)
+	return cadence.NewEvent(values).WithType(eventType)
+}
+
+func bridgedNFTToEVM(t *testing.T) cadence.Event {
+	evmID, err := cadence.NewUInt256FromBig(big.NewInt(42))
+	require.Nil(t, err)
+	return newEvent(t, events.TypeBridgedNFTToEVM, []cadence.Field{
+		{Identifier: "type", Type: cadence.StringType},
+		{Identifier: "id", Type: cadence.UInt64Type},
+		{Identifier: "uuid", Type: cadence.UInt64Type},
+		{Identifier: "evmID", Type: cadence.UInt256Type},
+		{Identifier: "to", Type: cadence.StringType},
+		{Identifier: "evmContractAddress", Type: cadence.StringType},
+		{Identifier: "bridgeAddress", Type: cadence.AddressType},
+	}, []cadence.Value{
+		cadence.String("A.0ae53cb6e3f42a79.ExampleNFT.NFT"),
+		cadence.UInt64(42),
+		cadence.UInt64(7),
+		evmID,
+		cadence.String("0000000000000000000000000000000000000001"),
+		cadence.String("0000000000000000000000000000000000000002"),
+		cadence.BytesToAddress(flow.HexToAddress(bridgeAddress).Bytes()),
+	})
+}
+
+func TestTypeIDs(t *testing.T) {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+
+	assert.Equal(t, "A.1e4aa0b87d10b141.FlowEVMBridge.Onboarded", events.TypeID(env, events.TypeOnboarded))
+	assert.Equal(t, "A.1e4aa0b87d10b141.IFlowEVMNFTBridge.BridgedNFTToEVM", events.TypeID(env, events.TypeBridgedNFTToEVM))
+	assert.Len(t, events.TypeIDs(env), len(events.Types))
+	assert.Empty(t, events.TypeID(bridge.Environment{}, events.TypeOnboarded))
+
+	assert.Equal(t, events.TypeOnboarded, events.QualifiedIdentifier("A.1e4aa0b87d10b141.FlowEVMBridge.Onboarded"))
+}
+
+func TestDecodeEncodings(t *testing.T) {
+	expected := events.BridgedNFTToEVM{
+		Type:               "A.0ae53cb6e3f42a79.ExampleNFT.NFT",
+		ID:                 42,
+		UUID:               7,
+		EVMID:              big.NewInt(42),
+		To:                 "0000000000000000000000000000000000000001",
+		EVMContractAddress: "0000000000000000000000000000000000000002",
+		BridgeAddress:      flow.HexToAddress(bridgeAddress),
+	}
+	event := bridgedNFTToEVM(t)
+
+	decoded, err := events.Decode(event)
+	require.Nil(t, err)
+	assert.Equal(t, expected, decoded)
+
+	payload, err := jsoncdc.Encode(event)
+	require.Nil(t, err)
+	decoded, err = events.DecodeJSONCDC(payload)
+	require.Nil(t, err)
+	assert.Equal(t, expected, decoded)
+
+	payload, err = ccf.Encode(event)
+	require.Nil(t, err)
+	decoded, err = events.DecodeCCF(payload)
+	require.Nil(t, err)
+	assert.Equal(t, expected, decoded)
+
+	decoded, err = events.DecodeFlowEvent(flow.Event{Payload: payload})
+	require.Nil(t, err)
+	assert.Equal(t, expected, decoded)
+}
+
+func TestDecodeOptionalFields(t *testing.T) {
+	optionalString := cadence.NewOptionalType(cadence.StringType)
+	fields := []cadence.Field{
+		{Identifier: "handlerType", Type: cadence.StringType},
+		{Identifier: "handlerUUID", Type: cadence.UInt64Type},
+		{Identifier: "targetType", Type: optionalString},
+		{Identifier: "targetEVMAddress", Type: optionalString},
+	}
+	event := newEvent(t, events.TypeHandlerDisabled, fields, []cadence.Value{
+		cadence.String("A.1e4aa0b87d10b141.FlowEVMBridgeHandlers.CadenceNativeTokenHandler"),
+		cadence.UInt64(3),
+		cadence.NewOptional(cadence.String("A.1654653399040a61.FlowToken.Vault")),
+		cadence.NewOptional(nil),
+	})
+
+	decoded, err := events.Decode(event)
+	require.Nil(t, err)
+	disabled := decoded.(events.HandlerDisabled)
+	require.NotNil(t, disabled.TargetType)
+	assert.Equal(t, "A.1654653399040a61.FlowToken.Vault", *disabled.TargetType)
+	assert.Nil(t, disabled.TargetEVMAddress)
+
+	payload, err := jsoncdc.Encode(event)
+	require.Nil(t, err)
+	decoded, err = events.DecodeJSONCDC(payload)
+	require.Nil(t, err)
+	assert.Equal(t, disabled, decoded)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	unknown := newEvent(t, "FlowEVMBridge.Unknown", nil, nil)
+	_, err := events.Decode(unknown)
+	assert.True(t, errors.Is(err, events.ErrUnknownEvent))
+
+	mistyped := newEvent(t, events.TypeBridgePauseStatusUpdated, []cadence.Field{
+		{Identifier: "paused", Type: cadence.StringType},
+	}, []cadence.Value{cadence.String("true")})
+	_, err = events.Decode(mistyped)
+	assert.ErrorContains(t, err, "field paused: expected Bool")
+}
+
+func TestDecodeBlockEvents(t *testing.T) {
+	other := newEvent(t, "FlowToken.TokensDeposited", nil, nil)
+	block := flow.BlockEvents{
+		BlockID: flow.HexToID("01"),
+		Height:  100,
+		Events: []flow.Event{
+			{Type: "A.1654653399040a61.FlowToken.TokensDeposited", Value: other},
+			{
+				Type:          "A.1e4aa0b87d10b141.IFlowEVMNFTBridge.BridgedNFTToEVM",
+				TransactionID: flow.HexToID("02"),
+				EventIndex:    1,
+				Value:         bridgedNFTToEVM(t),
+			},
+		},
+	}
+
+	records, err := events.DecodeBlockEvents(block)
+	require.Nil(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, uint64(100), records[0].BlockHeight)
+	assert.Equal(t, 1, records[0].EventIndex)
+	assert.Equal(t, events.TypeBridgedNFTToEVM, records[0].Event.EventType())
+}
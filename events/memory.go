@@ -0,0 +1,130 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+)
+
+// FixtureEvent is a line of a JSONL fixture file: a single event with its
+// position on chain and its JSON-CDC encoded value
+type FixtureEvent struct {
+	BlockHeight      uint64          `json:"blockHeight"`
+	BlockID          string          `json:"blockId,omitempty"`
+	BlockTimestamp   time.Time       `json:"blockTimestamp"`
+	TransactionID    string          `json:"transactionId,omitempty"`
+	TransactionIndex int             `json:"transactionIndex"`
+	EventIndex       int             `json:"eventIndex"`
+	Type             string          `json:"type"`
+	Payload          json.RawMessage `json:"payload"`
+}
+
+// MemorySource is an EventSource serving events from memory, for tests and
+// for replaying JSONL fixtures
+type MemorySource struct {
+	// Events by height
+	Blocks map[uint64]flow.BlockEvents
+	// Latest sealed height reported, at least the highest block with events
+	LatestHeight uint64
+}
+
+// Returns an empty source
+func NewMemorySource() *MemorySource {
+	return &MemorySource{Blocks: map[uint64]flow.BlockEvents{}}
+}
+
+// Returns a source serving the events of JSONL fixture files of FixtureEvent lines
+func ReadJSONLSource(paths ...string) (*MemorySource, error) {
+	source := NewMemorySource()
+	for _, path := range paths {
+		if err := source.readJSONL(path); err != nil {
+			return nil, err
+		}
+	}
+	return source, nil
+}
+
+// Adds an event to the block at the given height
+func (s *MemorySource) Add(height uint64, event flow.Event) {
+	block := s.Blocks[height]
+	block.Height = height
+	block.Events = append(block.Events, event)
+	s.Blocks[height] = block
+	if height > s.LatestHeight {
+		s.LatestHeight = height
+	}
+}
+
+func (s *MemorySource) GetLatestBlockHeader(_ context.Context, _ bool) (*flow.BlockHeader, error) {
+	return &flow.BlockHeader{Height: s.LatestHeight}, nil
+}
+
+// Returns the events of the given type in the height range. Like an access
+// node, blocks without events of the type are returned with no events
+func (s *MemorySource) GetEventsForHeightRange(_ context.Context, eventType string, startHeight uint64, endHeight uint64) ([]flow.BlockEvents, error) {
+	if endHeight > s.LatestHeight {
+		return nil, fmt.Errorf("height %d is not sealed yet", endHeight)
+	}
+	heights := []uint64{}
+	for height := range s.Blocks {
+		if height >= startHeight && height <= endHeight {
+			heights = append(heights, height)
+		}
+	}
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+	blocks := []flow.BlockEvents{}
+	for _, height := range heights {
+		block := s.Blocks[height]
+		filtered := block
+		filtered.Events = []flow.Event{}
+		for _, event := range block.Events {
+			if event.Type == eventType {
+				filtered.Events = append(filtered.Events, event)
+			}
+		}
+		blocks = append(blocks, filtered)
+	}
+	return blocks, nil
+}
+
+func (s *MemorySource) readJSONL(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var fixture FixtureEvent
+		if err := json.Unmarshal(scanner.Bytes(), &fixture); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		s.Add(fixture.BlockHeight, flow.Event{
+			Type:             fixture.Type,
+			TransactionID:    flow.HexToID(fixture.TransactionID),
+			TransactionIndex: fixture.TransactionIndex,
+			EventIndex:       fixture.EventIndex,
+			Payload:          fixture.Payload,
+		})
+		block := s.Blocks[fixture.BlockHeight]
+		block.BlockID = flow.HexToID(fixture.BlockID)
+		block.BlockTimestamp = fixture.BlockTimestamp
+		s.Blocks[fixture.BlockHeight] = block
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
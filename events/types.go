@@ -0,0 +1,93 @@
+// Package events decodes the Cadence events emitted by the bridge contracts
+// into Go structs.
+package events
+
+import (
+	"strings"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+)
+
+// Qualified identifiers of the bridge events, prefixed with the contract or
+// contract interface declaring them. Events declared in a contract interface
+// are emitted with the interface as their type, so for example
+// BridgedNFTToEVM is emitted as IFlowEVMNFTBridge.BridgedNFTToEVM
+const (
+	TypeOnboarded                      = "FlowEVMBridge.Onboarded"
+	TypeBridgeDefiningContractDeployed = "FlowEVMBridge.BridgeDefiningContractDeployed"
+	TypeBridgedNFTBurned               = "FlowEVMBridge.BridgedNFTBurned"
+	TypeBridgedNFTToEVM                = "IFlowEVMNFTBridge.BridgedNFTToEVM"
+	TypeBridgedNFTFromEVM              = "IFlowEVMNFTBridge.BridgedNFTFromEVM"
+	TypeBridgedTokensToEVM             = "IFlowEVMTokenBridge.BridgedTokensToEVM"
+	TypeBridgedTokensFromEVM           = "IFlowEVMTokenBridge.BridgedTokensFromEVM"
+	TypeBridgeFeeUpdated               = "FlowEVMBridgeConfig.BridgeFeeUpdated"
+	TypeHandlerConfigured              = "FlowEVMBridgeConfig.HandlerConfigured"
+	TypeBridgePauseStatusUpdated       = "FlowEVMBridgeConfig.BridgePauseStatusUpdated"
+	TypeAssetPauseStatusUpdated        = "FlowEVMBridgeConfig.AssetPauseStatusUpdated"
+	TypeAssociationUpdated             = "FlowEVMBridgeConfig.AssociationUpdated"
+	TypeCustomAssociationEstablished   = "FlowEVMBridgeCustomAssociations.CustomAssociationEstablished"
+	TypeFulfilledFromEVM               = "FlowEVMBridgeCustomAssociationTypes.FulfilledFromEVM"
+	TypeTemplateUpdated                = "FlowEVMBridgeTemplates.Updated"
+	TypeHandlerEnabled                 = "FlowEVMBridgeHandlerInterfaces.HandlerEnabled"
+	TypeHandlerDisabled                = "FlowEVMBridgeHandlerInterfaces.HandlerDisabled"
+	TypeMinterSet                      = "FlowEVMBridgeHandlerInterfaces.MinterSet"
+)
+
+// Types lists the qualified identifier of every bridge event
+var Types = []string{
+	TypeOnboarded,
+	TypeBridgeDefiningContractDeployed,
+	TypeBridgedNFTBurned,
+	TypeBridgedNFTToEVM,
+	TypeBridgedNFTFromEVM,
+	TypeBridgedTokensToEVM,
+	TypeBridgedTokensFromEVM,
+	TypeBridgeFeeUpdated,
+	TypeHandlerConfigured,
+	TypeBridgePauseStatusUpdated,
+	TypeAssetPauseStatusUpdated,
+	TypeAssociationUpdated,
+	TypeCustomAssociationEstablished,
+	TypeFulfilledFromEVM,
+	TypeTemplateUpdated,
+	TypeHandlerEnabled,
+	TypeHandlerDisabled,
+	TypeMinterSet,
+}
+
+// Returns the fully qualified type ID of an event in the given Environment,
+// e.g. A.1e4aa0b87d10b141.FlowEVMBridge.Onboarded for TypeOnboarded on
+// mainnet. Returns an empty string if the declaring contract has no address
+func TypeID(env bridge.Environment, eventType string) string {
+	contract, _, found := strings.Cut(eventType, ".")
+	if !found {
+		return ""
+	}
+	address := env.ContractAddress(contract)
+	if address == "" {
+		return ""
+	}
+	return "A." + strings.TrimPrefix(address, "0x") + "." + eventType
+}
+
+// Returns the fully qualified type ID of every bridge event whose
+// declaring contract has an address in the given Environment
+func TypeIDs(env bridge.Environment) []string {
+	ids := []string{}
+	for _, eventType := range Types {
+		if id := TypeID(env, eventType); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Returns the qualified identifier of a fully qualified type ID,
+// e.g. FlowEVMBridge.Onboarded for A.1e4aa0b87d10b141.FlowEVMBridge.Onboarded
+func QualifiedIdentifier(typeID string) string {
+	parts := strings.SplitN(typeID, ".", 3)
+	if len(parts) == 3 && parts[0] == "A" {
+		return parts[2]
+	}
+	return typeID
+}
@@ -0,0 +1,90 @@
+package evmlogs
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/go-ethereum/common"
+
+	"github.com/onflow/flow-evm-bridge/events"
+)
+
+// NFTToEVMTrace pairs a Cadence BridgedNFTToEVM event with the EVM logs of
+// the same Flow transaction that moved the NFT to its recipient
+type NFTToEVMTrace struct {
+	FlowTransactionID flow.Identifier
+	Bridged           events.BridgedNFTToEVM
+	// Hash of the EVM transaction containing Transfer, empty if none was found
+	EVMTransactionHash string
+	// Transfer of the ERC721 to the recipient, nil if none was found
+	Transfer *ERC721Transfer
+	// Set if the ERC721 implements ICrossVMBridgeERC721Fulfillment
+	Fulfilled *FulfilledToEVM
+}
+
+// Returns whether a Transfer of the NFT to its recipient was found
+func (t NFTToEVMTrace) Matched() bool {
+	return t.Transfer != nil
+}
+
+// Returns a trace for every BridgedNFTToEVM event in the given Flow events,
+// matched with the ERC721 Transfer and FulfilledToEVM logs emitted by the
+// ERC721 in the same Flow transaction. Events are typically those of one
+// block, or of one transaction result
+func CorrelateNFTToEVM(flowEvents []flow.Event) ([]NFTToEVMTrace, error) {
+	logs, err := TransactionLogsFromEvents(flowEvents)
+	if err != nil {
+		return nil, err
+	}
+	logsByTransaction := map[flow.Identifier][]TransactionLogs{}
+	for _, l := range logs {
+		logsByTransaction[l.FlowTransactionID] = append(logsByTransaction[l.FlowTransactionID], l)
+	}
+
+	traces := []NFTToEVMTrace{}
+	for _, event := range flowEvents {
+		if !strings.HasSuffix(event.Type, "."+events.TypeBridgedNFTToEVM) {
+			continue
+		}
+		decoded, err := events.DecodeFlowEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		bridged, ok := decoded.(events.BridgedNFTToEVM)
+		if !ok {
+			return nil, errors.New("Unexpected event decoded from " + event.Type)
+		}
+		trace := NFTToEVMTrace{FlowTransactionID: event.TransactionID, Bridged: bridged}
+		for _, transaction := range logsByTransaction[event.TransactionID] {
+			trace.match(transaction)
+		}
+		traces = append(traces, trace)
+	}
+	return traces, nil
+}
+
+func (t *NFTToEVMTrace) match(transaction TransactionLogs) {
+	contract := common.HexToAddress(t.Bridged.EVMContractAddress)
+	recipient := common.HexToAddress(t.Bridged.To)
+	for _, l := range transaction.Logs {
+		if l.Address != contract || len(l.Topics) == 0 {
+			continue
+		}
+		decoded, err := Decode(l)
+		if err != nil {
+			continue
+		}
+		switch e := decoded.(type) {
+		case ERC721Transfer:
+			if t.Transfer == nil && e.To == recipient && e.TokenID.Cmp(t.Bridged.EVMID) == 0 {
+				t.Transfer = &e
+				t.EVMTransactionHash = transaction.Hash
+			}
+		case FulfilledToEVM:
+			if t.Fulfilled == nil && e.Recipient == recipient && e.TokenID.Cmp(t.Bridged.EVMID) == 0 {
+				t.Fulfilled = &e
+			}
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Package evmlogs decodes the EVM logs emitted by the bridge Solidity
+// contracts and correlates them with the Cadence bridge events of the same
+// Flow transaction.
+//
+// EVM logs reach Flow clients inside the EVM.TransactionExecuted Cadence event
+// emitted for every EVM transaction, as an RLP encoded list of logs.
+package evmlogs
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/go-ethereum/common"
+	"github.com/onflow/go-ethereum/crypto"
+	"github.com/onflow/go-ethereum/rlp"
+)
+
+// Topic hashes of the decoded events, the Keccak-256 hash of their signature
+var (
+	TopicDeployerAdded             = crypto.Keccak256Hash([]byte("DeployerAdded(string,address)"))
+	TopicDeployerUpdated           = crypto.Keccak256Hash([]byte("DeployerUpdated(string,address,address)"))
+	TopicDeployerRemoved           = crypto.Keccak256Hash([]byte("DeployerRemoved(string,address)"))
+	TopicDeploymentRegistryUpdated = crypto.Keccak256Hash([]byte("DeploymentRegistryUpdated(address,address)"))
+	TopicFulfilledToEVM            = crypto.Keccak256Hash([]byte("FulfilledToEVM(address,uint256)"))
+	// Shared by ERC20 and ERC721, which indexes the token ID as a third topic
+	TopicTransfer = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+)
+
+// ErrUnknownLog is returned when decoding a log that is not a bridge event
+var ErrUnknownLog = errors.New("unknown EVM log")
+
+// Log is an EVM log in the RLP layout of go-ethereum's types.Log
+type Log struct {
+	Address common.Address
+	Topics  []common.Hash
+	Data    []byte
+}
+
+// Event is a decoded EVM log
+type Event interface {
+	// Returns the name of the Solidity event
+	EventName() string
+}
+
+// DeployerAdded is emitted by FlowBridgeFactory when a deployer is added.
+// The tag is indexed, so only its hash is available, see DeployerTag
+type DeployerAdded struct {
+	TagHash         common.Hash
+	DeployerAddress common.Address
+}
+
+// DeployerUpdated is emitted by FlowBridgeFactory when a deployer is replaced
+type DeployerUpdated struct {
+	TagHash    common.Hash
+	OldAddress common.Address
+	NewAddress common.Address
+}
+
+// DeployerRemoved is emitted by FlowBridgeFactory when a deployer is removed
+type DeployerRemoved struct {
+	TagHash    common.Hash
+	OldAddress common.Address
+}
+
+// DeploymentRegistryUpdated is emitted by FlowBridgeFactory when its registry changes
+type DeploymentRegistryUpdated struct {
+	OldAddress common.Address
+	NewAddress common.Address
+}
+
+// FulfilledToEVM is emitted by ICrossVMBridgeERC721Fulfillment contracts when
+// the bridge fulfills a Cadence-native NFT into EVM
+type FulfilledToEVM struct {
+	Recipient common.Address
+	TokenID   *big.Int
+}
+
+// ERC20Transfer is the Transfer event of an ERC20
+type ERC20Transfer struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+}
+
+// ERC721Transfer is the Transfer event of an ERC721
+type ERC721Transfer struct {
+	From    common.Address
+	To      common.Address
+	TokenID *big.Int
+}
+
+func (DeployerAdded) EventName() string             { return "DeployerAdded" }
+func (DeployerUpdated) EventName() string           { return "DeployerUpdated" }
+func (DeployerRemoved) EventName() string           { return "DeployerRemoved" }
+func (DeploymentRegistryUpdated) EventName() string { return "DeploymentRegistryUpdated" }
+func (FulfilledToEVM) EventName() string            { return "FulfilledToEVM" }
+func (ERC20Transfer) EventName() string             { return "Transfer" }
+func (ERC721Transfer) EventName() string            { return "Transfer" }
+
+// Returns the topic of an indexed FlowBridgeFactory deployer tag such as "ERC721"
+func DeployerTag(tag string) common.Hash {
+	return crypto.Keccak256Hash([]byte(tag))
+}
+
+var decoders = map[common.Hash]func(l Log) (Event, error){
+	TopicDeployerAdded: func(l Log) (Event, error) {
+		if err := l.expect(2, 1); err != nil {
+			return nil, err
+		}
+		return DeployerAdded{TagHash: l.Topics[1], DeployerAddress: l.address(0)}, nil
+	},
+	TopicDeployerUpdated: func(l Log) (Event, error) {
+		if err := l.expect(2, 2); err != nil {
+			return nil, err
+		}
+		return DeployerUpdated{TagHash: l.Topics[1], OldAddress: l.address(0), NewAddress: l.address(1)}, nil
+	},
+	TopicDeployerRemoved: func(l Log) (Event, error) {
+		if err := l.expect(2, 1); err != nil {
+			return nil, err
+		}
+		return DeployerRemoved{TagHash: l.Topics[1], OldAddress: l.address(0)}, nil
+	},
+	TopicDeploymentRegistryUpdated: func(l Log) (Event, error) {
+		if err := l.expect(3, 0); err != nil {
+			return nil, err
+		}
+		return DeploymentRegistryUpdated{
+			OldAddress: common.BytesToAddress(l.Topics[1].Bytes()),
+			NewAddress: common.BytesToAddress(l.Topics[2].Bytes()),
+		}, nil
+	},
+	TopicFulfilledToEVM: func(l Log) (Event, error) {
+		if err := l.expect(3, 0); err != nil {
+			return nil, err
+		}
+		return FulfilledToEVM{
+			Recipient: common.BytesToAddress(l.Topics[1].Bytes()),
+			TokenID:   l.Topics[2].Big(),
+		}, nil
+	},
+	TopicTransfer: func(l Log) (Event, error) {
+		if len(l.Topics) == 4 {
+			return ERC721Transfer{
+				From:    common.BytesToAddress(l.Topics[1].Bytes()),
+				To:      common.BytesToAddress(l.Topics[2].Bytes()),
+				TokenID: l.Topics[3].Big(),
+			}, nil
+		}
+		if err := l.expect(3, 1); err != nil {
+			return nil, err
+		}
+		return ERC20Transfer{
+			From:  common.BytesToAddress(l.Topics[1].Bytes()),
+			To:    common.BytesToAddress(l.Topics[2].Bytes()),
+			Value: new(big.Int).SetBytes(l.word(0)),
+		}, nil
+	},
+}
+
+// Decodes a log by the hash of its first topic
+func Decode(l Log) (Event, error) {
+	if len(l.Topics) == 0 {
+		return nil, fmt.Errorf("anonymous log: %w", ErrUnknownLog)
+	}
+	decoder, ok := decoders[l.Topics[0]]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", l.Topics[0].Hex(), ErrUnknownLog)
+	}
+	return decoder(l)
+}
+
+// Checks the number of topics and of 32 byte data words
+func (l Log) expect(topics, words int) error {
+	if len(l.Topics) != topics || len(l.Data) != words*32 {
+		return fmt.Errorf("Log %s has %d topics and %d bytes of data, expected %d topics and %d bytes",
+			l.Topics[0].Hex(), len(l.Topics), len(l.Data), topics, words*32)
+	}
+	return nil
+}
+
+func (l Log) word(i int) []byte {
+	return l.Data[i*32 : (i+1)*32]
+}
+
+func (l Log) address(i int) common.Address {
+	return common.BytesToAddress(l.word(i))
+}
+
+// TransactionLogs are the logs of an EVM transaction executed in a Flow transaction
+type TransactionLogs struct {
+	FlowTransactionID flow.Identifier
+	// Index of the EVM.TransactionExecuted event in the Flow transaction
+	EventIndex int
+	// Hash of the EVM transaction as hex with 0x prefix
+	Hash string
+	Logs []Log
+}
+
+// Returns the logs of every EVM transaction executed in the given Flow events,
+// read from their EVM.TransactionExecuted events. Other events are skipped
+func TransactionLogsFromEvents(flowEvents []flow.Event) ([]TransactionLogs, error) {
+	result := []TransactionLogs{}
+	for _, event := range flowEvents {
+		if !strings.HasSuffix(event.Type, ".EVM.TransactionExecuted") {
+			continue
+		}
+		logsBytes, err := bytesField(event.Value, "logs")
+		if err != nil {
+			return nil, err
+		}
+		logs := []Log{}
+		if len(logsBytes) > 0 {
+			if err := rlp.DecodeBytes(logsBytes, &logs); err != nil {
+				return nil, fmt.Errorf("Cannot decode logs of event %d of transaction %s: %w", event.EventIndex, event.TransactionID, err)
+			}
+		}
+		hash, err := transactionHash(event.Value)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, TransactionLogs{
+			FlowTransactionID: event.TransactionID,
+			EventIndex:        event.EventIndex,
+			Hash:              hash,
+			Logs:              logs,
+		})
+	}
+	return result, nil
+}
+
+// The hash is a [UInt8; 32] in current EVM contracts and a hex String in older ones
+func transactionHash(event cadence.Event) (string, error) {
+	value := cadence.SearchFieldByName(event, "hash")
+	if s, ok := value.(cadence.String); ok {
+		return "0x" + strings.TrimPrefix(string(s), "0x"), nil
+	}
+	hash, err := bytesField(event, "hash")
+	if err != nil {
+		return "", err
+	}
+	return common.BytesToHash(hash).Hex(), nil
+}
+
+func bytesField(event cadence.Event, name string) ([]byte, error) {
+	var values []cadence.Value
+	switch value := cadence.SearchFieldByName(event, name).(type) {
+	case cadence.Array:
+		values = value.Values
+	case nil:
+		return nil, fmt.Errorf("EVM.TransactionExecuted event has no field %s", name)
+	default:
+		return nil, fmt.Errorf("Unexpected %s of EVM.TransactionExecuted: %s", name, value.Type().ID())
+	}
+	bytes := make([]byte, len(values))
+	for i, value := range values {
+		b, ok := value.(cadence.UInt8)
+		if !ok {
+			return nil, fmt.Errorf("Unexpected byte in %s of EVM.TransactionExecuted: %s", name, value)
+		}
+		bytes[i] = byte(b)
+	}
+	return bytes, nil
+}
@@ -0,0 +1,215 @@
+package evmlogs_test
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/onflow/cadence"
+	cadenceCommon "github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/go-ethereum/common"
+	"github.com/onflow/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/evmlogs"
+)
+
+var (
+	erc721    = common.HexToAddress("0000000000000000000000000000000000000721")
+	coa       = common.HexToAddress("00000000000000000000000249250a5c27ecab3b")
+	recipient = common.HexToAddress("0000000000000000000000000000000000000001")
+)
+
+func word(b []byte) []byte {
+	return common.LeftPadBytes(b, 32)
+}
+
+func topic(address common.Address) common.Hash {
+	return common.BytesToHash(address.Bytes())
+}
+
+func transferNFT(from, to common.Address, id int64) evmlogs.Log {
+	return evmlogs.Log{
+		Address: erc721,
+		Topics:  []common.Hash{evmlogs.TopicTransfer, topic(from), topic(to), common.BigToHash(big.NewInt(id))},
+		Data:    []byte{},
+	}
+}
+
+func bytesArray(b []byte) cadence.Array {
+	values := make([]cadence.Value, len(b))
+	for i := range b {
+		values[i] = cadence.UInt8(b[i])
+	}
+	return cadence.NewArray(values).WithType(cadence.NewVariableSizedArrayType(cadence.UInt8Type))
+}
+
+func transactionExecuted(t *testing.T, id flow.Identifier, index int, hash common.Hash, logs []evmlogs.Log) flow.Event {
+	encoded, err := rlp.EncodeToBytes(logs)
+	require.Nil(t, err)
+	eventType := cadence.NewEventType(nil, "EVM.TransactionExecuted", []cadence.Field{
+		{Identifier: "hash", Type: cadence.NewConstantSizedArrayType(32, cadence.UInt8Type)},
+		{Identifier: "logs", Type: cadence.NewVariableSizedArrayType(cadence.UInt8Type)},
+	}, nil)
+	value := cadence.NewEvent([]cadence.Value{bytesArray(hash.Bytes()), bytesArray(encoded)}).WithType(eventType)
+	return flow.Event{
+		Type:          "A.e467b9dd11fa00df.EVM.TransactionExecuted",
+		TransactionID: id,
+		EventIndex:    index,
+		Value:         value,
+	}
+}
+
+func bridgedNFTToEVM(t *testing.T, id flow.Identifier, index int, evmID int64) flow.Event {
+	address, err := cadenceCommon.HexToAddress("1e4aa0b87d10b141")
+	require.Nil(t, err)
+	location := cadenceCommon.NewAddressLocation(nil, address, "IFlowEVMNFTBridge")
+	evmIDValue, err := cadence.NewUInt256FromBig(big.NewInt(evmID))
+	require.Nil(t, err)
+	eventType := cadence.NewEventType(location, events.TypeBridgedNFTToEVM, []cadence.Field{
+		{Identifier: "type", Type: cadence.StringType},
+		{Identifier: "id", Type: cadence.UInt64Type},
+		{Identifier: "uuid", Type: cadence.UInt64Type},
+		{Identifier: "evmID", Type: cadence.UInt256Type},
+		{Identifier: "to", Type: cadence.StringType},
+		{Identifier: "evmContractAddress", Type: cadence.StringType},
+		{Identifier: "bridgeAddress", Type: cadence.AddressType},
+	}, nil)
+	value := cadence.NewEvent([]cadence.Value{
+		cadence.String("A.0ae53cb6e3f42a79.ExampleNFT.NFT"),
+		cadence.UInt64(evmID),
+		cadence.UInt64(100 + evmID),
+		evmIDValue,
+		cadence.String("0000000000000000000000000000000000000001"),
+		cadence.String("0000000000000000000000000000000000000721"),
+		cadence.Address(address),
+	}).WithType(eventType)
+	return flow.Event{
+		Type:          "A.1e4aa0b87d10b141.IFlowEVMNFTBridge.BridgedNFTToEVM",
+		TransactionID: id,
+		EventIndex:    index,
+		Value:         value,
+	}
+}
+
+func TestDecode(t *testing.T) {
+	registry := common.HexToAddress("0000000000000000000000000000000000000123")
+	deployer := common.HexToAddress("0000000000000000000000000000000000000456")
+	for _, test := range []struct {
+		log      evmlogs.Log
+		expected evmlogs.Event
+	}{
+		{
+			log: evmlogs.Log{
+				Topics: []common.Hash{evmlogs.TopicDeployerAdded, evmlogs.DeployerTag("ERC721")},
+				Data:   word(deployer.Bytes()),
+			},
+			expected: evmlogs.DeployerAdded{TagHash: evmlogs.DeployerTag("ERC721"), DeployerAddress: deployer},
+		},
+		{
+			log: evmlogs.Log{
+				Topics: []common.Hash{evmlogs.TopicDeployerUpdated, evmlogs.DeployerTag("ERC20")},
+				Data:   append(word(deployer.Bytes()), word(registry.Bytes())...),
+			},
+			expected: evmlogs.DeployerUpdated{TagHash: evmlogs.DeployerTag("ERC20"), OldAddress: deployer, NewAddress: registry},
+		},
+		{
+			log: evmlogs.Log{
+				Topics: []common.Hash{evmlogs.TopicDeployerRemoved, evmlogs.DeployerTag("ERC20")},
+				Data:   word(deployer.Bytes()),
+			},
+			expected: evmlogs.DeployerRemoved{TagHash: evmlogs.DeployerTag("ERC20"), OldAddress: deployer},
+		},
+		{
+			log: evmlogs.Log{
+				Topics: []common.Hash{evmlogs.TopicDeploymentRegistryUpdated, topic(common.Address{}), topic(registry)},
+			},
+			expected: evmlogs.DeploymentRegistryUpdated{NewAddress: registry},
+		},
+		{
+			log: evmlogs.Log{
+				Topics: []common.Hash{evmlogs.TopicFulfilledToEVM, topic(recipient), common.BigToHash(big.NewInt(42))},
+			},
+			expected: evmlogs.FulfilledToEVM{Recipient: recipient, TokenID: big.NewInt(42)},
+		},
+		{
+			log:      transferNFT(coa, recipient, 42),
+			expected: evmlogs.ERC721Transfer{From: coa, To: recipient, TokenID: big.NewInt(42)},
+		},
+		{
+			log: evmlogs.Log{
+				Topics: []common.Hash{evmlogs.TopicTransfer, topic(coa), topic(recipient)},
+				Data:   word(big.NewInt(1e18).Bytes()),
+			},
+			expected: evmlogs.ERC20Transfer{From: coa, To: recipient, Value: big.NewInt(1e18)},
+		},
+	} {
+		decoded, err := evmlogs.Decode(test.log)
+		require.Nil(t, err)
+		assert.Equal(t, test.expected, decoded)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	_, err := evmlogs.Decode(evmlogs.Log{})
+	assert.True(t, errors.Is(err, evmlogs.ErrUnknownLog))
+
+	_, err = evmlogs.Decode(evmlogs.Log{Topics: []common.Hash{common.HexToHash("0x01")}})
+	assert.True(t, errors.Is(err, evmlogs.ErrUnknownLog))
+
+	_, err = evmlogs.Decode(evmlogs.Log{Topics: []common.Hash{evmlogs.TopicDeployerAdded}})
+	assert.ErrorContains(t, err, "expected 2 topics")
+}
+
+func TestTransactionLogsFromEvents(t *testing.T) {
+	id := flow.HexToID("01")
+	hash := common.HexToHash("0xabcd")
+	logs := []evmlogs.Log{transferNFT(coa, recipient, 42)}
+
+	transactions, err := evmlogs.TransactionLogsFromEvents([]flow.Event{
+		bridgedNFTToEVM(t, id, 0, 42),
+		transactionExecuted(t, id, 1, hash, logs),
+	})
+	require.Nil(t, err)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, id, transactions[0].FlowTransactionID)
+	assert.Equal(t, 1, transactions[0].EventIndex)
+	assert.Equal(t, hash.Hex(), transactions[0].Hash)
+	assert.Equal(t, logs, transactions[0].Logs)
+}
+
+func TestCorrelateNFTToEVM(t *testing.T) {
+	first, second := flow.HexToID("01"), flow.HexToID("02")
+	hash := common.HexToHash("0xabcd")
+	flowEvents := []flow.Event{
+		transactionExecuted(t, first, 0, hash, []evmlogs.Log{
+			// Transfer of another token and by another contract are not matched
+			transferNFT(coa, recipient, 41),
+			{Address: common.HexToAddress("0x0bad"), Topics: transferNFT(coa, recipient, 42).Topics},
+			transferNFT(coa, recipient, 42),
+			{
+				Address: erc721,
+				Topics:  []common.Hash{evmlogs.TopicFulfilledToEVM, topic(recipient), common.BigToHash(big.NewInt(42))},
+			},
+		}),
+		bridgedNFTToEVM(t, first, 1, 42),
+		// No EVM transaction in the second Flow transaction
+		bridgedNFTToEVM(t, second, 0, 42),
+	}
+
+	traces, err := evmlogs.CorrelateNFTToEVM(flowEvents)
+	require.Nil(t, err)
+	require.Len(t, traces, 2)
+
+	assert.True(t, traces[0].Matched())
+	assert.Equal(t, first, traces[0].FlowTransactionID)
+	assert.Equal(t, hash.Hex(), traces[0].EVMTransactionHash)
+	assert.Equal(t, &evmlogs.ERC721Transfer{From: coa, To: recipient, TokenID: big.NewInt(42)}, traces[0].Transfer)
+	assert.Equal(t, &evmlogs.FulfilledToEVM{Recipient: recipient, TokenID: big.NewInt(42)}, traces[0].Fulfilled)
+
+	assert.False(t, traces[1].Matched())
+	assert.Empty(t, traces[1].EVMTransactionHash)
+}
@@ -0,0 +1,160 @@
+package fees_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/fees"
+	"github.com/onflow/flow-evm-bridge/index"
+)
+
+const (
+	exampleNFT   = "A.0ae53cb6e3f42a79.ExampleNFT.NFT"
+	customNFT    = "A.0ae53cb6e3f42a79.CustomNFT.NFT"
+	legacyNFT    = "A.1e4aa0b87d10b141.EVMVMBridgedNFT_0000000000000000000000000000000000000c21.NFT"
+	exampleToken = "A.0ae53cb6e3f42a79.ExampleToken.Vault"
+	bridgedToken = "A.1e4aa0b87d10b141.EVMVMBridgedToken_0000000000000000000000000000000000000e20.Vault"
+	customERC721 = "0000000000000000000000000000000000000c21"
+)
+
+var september = time.Date(2024, 9, 30, 12, 0, 0, 0, time.UTC)
+var october = september.Add(24 * time.Hour)
+
+func ufix64(t *testing.T, value string) cadence.UFix64 {
+	amount, err := cadence.NewUFix64(value)
+	require.Nil(t, err)
+	return amount
+}
+
+func newReport(t *testing.T, period fees.Period) *fees.Report {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	report := fees.NewReport(env, period)
+
+	records := []events.Record{
+		{Event: events.BridgeFeeUpdated{Old: 0, New: ufix64(t, "1.0"), IsOnboarding: true}},
+		{Event: events.BridgeFeeUpdated{Old: 0, New: ufix64(t, "0.001"), IsOnboarding: false}},
+		{Event: events.Onboarded{Type: exampleNFT}, BlockTimestamp: september},
+		{Event: events.CustomAssociationEstablished{Type: customNFT, EVMContractAddress: "0x" + customERC721}, BlockTimestamp: september},
+		{Event: events.BridgeDefiningContractDeployed{ContractName: "EVMVMBridgedToken_0000000000000000000000000000000000000e20", EVMContractAddress: "0000000000000000000000000000000000000e20"}, BlockTimestamp: september},
+		// Default NFTs are charged both ways
+		{Event: events.BridgedNFTToEVM{Type: exampleNFT, EVMID: big.NewInt(1)}, BlockTimestamp: september},
+		{Event: events.BridgedNFTFromEVM{Type: exampleNFT, EVMID: big.NewInt(1)}, BlockTimestamp: september},
+		// Custom cross-VM NFTs are charged to EVM only
+		{Event: events.BridgedNFTToEVM{Type: customNFT, EVMID: big.NewInt(1), EVMContractAddress: customERC721}, BlockTimestamp: september},
+		{Event: events.BridgedNFTFromEVM{Type: customNFT, EVMID: big.NewInt(1), EVMContractAddress: customERC721}, BlockTimestamp: september},
+		// Bridge-defined NFTs updated to a custom association are burned without fee
+		{Event: events.BridgedNFTToEVM{Type: legacyNFT, EVMID: big.NewInt(2), EVMContractAddress: customERC721}, BlockTimestamp: september},
+		{Event: events.BridgeFeeUpdated{Old: ufix64(t, "0.001"), New: ufix64(t, "0.002"), IsOnboarding: false}},
+		{Event: events.BridgedTokensToEVM{Type: exampleToken, Amount: ufix64(t, "1.0")}, BlockTimestamp: october},
+		{Event: events.BridgedTokensToEVM{Type: bridgedToken, Amount: ufix64(t, "1.0")}, BlockTimestamp: october},
+		{Event: events.BridgedTokensFromEVM{Type: exampleToken, Amount: big.NewInt(1)}, BlockTimestamp: october},
+	}
+	for i, record := range records {
+		record.BlockHeight = uint64(i + 1)
+		require.Nil(t, report.Apply(record))
+	}
+	return report
+}
+
+func TestSchedule(t *testing.T) {
+	report := newReport(t, fees.PeriodDay)
+	require.Len(t, report.Schedule.Changes, 3)
+
+	assert.Equal(t, cadence.UFix64(0), report.Schedule.At(fees.FeeBase, index.Position{Height: 2}))
+	assert.Equal(t, ufix64(t, "0.001"), report.Schedule.At(fees.FeeBase, index.Position{Height: 3}))
+	assert.Equal(t, ufix64(t, "0.002"), report.Schedule.At(fees.FeeBase, index.Position{Height: 100}))
+	assert.Equal(t, ufix64(t, "1.0"), report.Schedule.At(fees.FeeOnboarding, index.Position{Height: 100}))
+}
+
+func TestReportConsumer(t *testing.T) {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	address, err := common.HexToAddress(env.FlowEVMBridgeConfigAddress)
+	require.Nil(t, err)
+	location := common.NewAddressLocation(nil, address, "FlowEVMBridgeConfig")
+	event := cadence.NewEvent([]cadence.Value{cadence.UFix64(0), ufix64(t, "0.001"), cadence.Bool(false)}).
+		WithType(cadence.NewEventType(location, events.TypeBridgeFeeUpdated, []cadence.Field{
+			{Identifier: "old", Type: cadence.UFix64Type},
+			{Identifier: "new", Type: cadence.UFix64Type},
+			{Identifier: "isOnboarding", Type: cadence.BoolType},
+		}, nil))
+	source := events.NewMemorySource()
+	source.Add(7, flow.Event{Type: event.EventType.ID(), TransactionID: flow.Identifier{1}, Value: event})
+
+	report := fees.NewReport(env, fees.PeriodDay)
+	checkpoints := &events.MemoryCheckpointStore{}
+	_, err = report.Consumer(source, env, checkpoints, 1).Sync(context.Background())
+	require.Nil(t, err)
+	// Resuming from the checkpoint applies no event twice
+	_, err = report.Consumer(source, env, checkpoints, 1).Sync(context.Background())
+	require.Nil(t, err)
+	require.Len(t, report.Schedule.Changes, 1)
+	assert.Equal(t, ufix64(t, "0.001"), report.Schedule.At(fees.FeeBase, index.Position{Height: 8}))
+}
+
+func TestReportRows(t *testing.T) {
+	type summary struct {
+		period, cadenceType     string
+		operation               fees.Operation
+		count, feeBearing, excl int
+		fees                    string
+	}
+	summaries := []summary{}
+	for _, row := range newReport(t, fees.PeriodMonth).Rows() {
+		summaries = append(summaries, summary{
+			row.Period, row.Type, row.Operation, row.Count, row.FeeBearing, row.StorageFeeExcluded, row.EstimatedFees,
+		})
+	}
+	assert.Equal(t, []summary{
+		{"2024-09", customNFT, fees.OperationNFTFromEVM, 1, 0, 0, "0.00000000"},
+		{"2024-09", customNFT, fees.OperationNFTToEVM, 1, 1, 1, "0.00100000"},
+		{"2024-09", customNFT, fees.OperationOnboard, 1, 1, 0, "1.00000000"},
+		{"2024-09", exampleNFT, fees.OperationNFTFromEVM, 1, 1, 0, "0.00100000"},
+		{"2024-09", exampleNFT, fees.OperationNFTToEVM, 1, 1, 1, "0.00100000"},
+		{"2024-09", exampleNFT, fees.OperationOnboard, 1, 1, 0, "1.00000000"},
+		{"2024-09", legacyNFT, fees.OperationNFTToEVM, 1, 0, 0, "0.00000000"},
+		{"2024-09", bridgedToken, fees.OperationOnboard, 1, 1, 0, "1.00000000"},
+		{"2024-10", exampleToken, fees.OperationTokensFromEVM, 1, 1, 0, "0.00200000"},
+		{"2024-10", exampleToken, fees.OperationTokensToEVM, 1, 1, 1, "0.00200000"},
+		{"2024-10", bridgedToken, fees.OperationTokensToEVM, 1, 1, 0, "0.00200000"},
+	}, summaries)
+}
+
+func TestReportOutput(t *testing.T) {
+	report := newReport(t, fees.PeriodDay)
+
+	var csvOutput bytes.Buffer
+	require.Nil(t, report.WriteCSV(&csvOutput))
+	lines := bytes.Split(bytes.TrimSpace(csvOutput.Bytes()), []byte("\n"))
+	require.Len(t, lines, 12)
+	assert.Equal(t, "period,type,operation,count,fee_bearing,storage_fee_excluded,estimated_fees", string(lines[0]))
+	assert.Equal(t, "2024-09-30,"+exampleNFT+",onboard,1,1,0,1.00000000", string(lines[6]))
+
+	var jsonOutput bytes.Buffer
+	require.Nil(t, report.WriteJSON(&jsonOutput))
+	var decoded struct {
+		Schedule []struct {
+			Height uint64 `json:"height"`
+			Fee    string `json:"fee"`
+			New    string `json:"new"`
+		} `json:"schedule"`
+		Rows []fees.Row `json:"rows"`
+	}
+	require.Nil(t, json.Unmarshal(jsonOutput.Bytes(), &decoded))
+	assert.Len(t, decoded.Schedule, 3)
+	assert.Equal(t, "0.00200000", decoded.Schedule[2].New)
+	assert.Len(t, decoded.Rows, 11)
+}
@@ -0,0 +1,303 @@
+package fees
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/onflow/cadence"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/index"
+)
+
+// Returns the fully qualified types of the events a report consumes
+func EventTypes(env bridge.Environment) []string {
+	return []string{
+		events.TypeID(env, events.TypeBridgeFeeUpdated),
+		events.TypeID(env, events.TypeCustomAssociationEstablished),
+		events.TypeID(env, events.TypeHandlerConfigured),
+		events.TypeID(env, events.TypeOnboarded),
+		events.TypeID(env, events.TypeBridgeDefiningContractDeployed),
+		events.TypeID(env, events.TypeBridgedNFTToEVM),
+		events.TypeID(env, events.TypeBridgedNFTFromEVM),
+		events.TypeID(env, events.TypeBridgedTokensToEVM),
+		events.TypeID(env, events.TypeBridgedTokensFromEVM),
+	}
+}
+
+// Operation is a kind of bridge operation
+type Operation string
+
+const (
+	OperationOnboard       Operation = "onboard"
+	OperationNFTToEVM      Operation = "nft-to-evm"
+	OperationNFTFromEVM    Operation = "nft-from-evm"
+	OperationTokensToEVM   Operation = "tokens-to-evm"
+	OperationTokensFromEVM Operation = "tokens-from-evm"
+)
+
+// Classification tells whether and how a bridge operation is charged
+type Classification struct {
+	Operation  Operation
+	FeeBearing bool
+	// The fee charged, set if FeeBearing
+	Fee Fee
+	// Whether a storage fee is charged on top of the fee, for operations
+	// escrowing assets in the bridge account
+	StorageFee bool
+}
+
+// Period is the granularity of a report
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodMonth Period = "month"
+)
+
+// Row is the activity of one asset and operation in one period
+type Row struct {
+	// Start of the period as YYYY-MM-DD or YYYY-MM, empty for events
+	// without block timestamp
+	Period    string    `json:"period"`
+	Type      string    `json:"type"`
+	Operation Operation `json:"operation"`
+	// Number of operations
+	Count int `json:"count"`
+	// Number of operations charged a fee
+	FeeBearing int `json:"feeBearing"`
+	// Number of operations charged a storage fee not included in EstimatedFees
+	StorageFeeExcluded int `json:"storageFeeExcluded"`
+	// Onboarding and base fees collected in FLOW, as a UFix64
+	EstimatedFees string `json:"estimatedFees"`
+
+	total cadence.UFix64
+}
+
+// Report estimates fees collected per asset, operation and period
+type Report struct {
+	// Used to tell EVM-native from Cadence-native tokens
+	BridgeEnv bridge.Environment
+	Period    Period
+	Schedule  Schedule
+
+	// Types with a custom cross-VM association and their EVM contracts
+	custom      map[string]bool
+	customByEVM map[string]bool
+	// Types bridged through a token handler
+	handlers map[string]bool
+	rows     map[rowKey]*Row
+}
+
+type rowKey struct {
+	period    string
+	cadence   string
+	operation Operation
+}
+
+// Returns an empty report grouping operations by the given period
+func NewReport(env bridge.Environment, period Period) *Report {
+	return &Report{
+		BridgeEnv:   env,
+		Period:      period,
+		custom:      map[string]bool{},
+		customByEVM: map[string]bool{},
+		handlers:    map[string]bool{},
+		rows:        map[rowKey]*Row{},
+	}
+}
+
+// Returns how a bridge event is charged given the associations seen so far,
+// and false if the event is not a bridge operation. Onboarding by type emits
+// Onboarded, onboarding by EVM address BridgeDefiningContractDeployed and
+// registering a custom cross-VM NFT CustomAssociationEstablished
+func (r *Report) Classify(record events.Record) (Classification, bool) {
+	switch event := record.Event.(type) {
+	case events.Onboarded, events.BridgeDefiningContractDeployed, events.CustomAssociationEstablished:
+		return Classification{Operation: OperationOnboard, FeeBearing: true, Fee: FeeOnboarding}, true
+	case events.BridgedNFTToEVM:
+		classification := Classification{Operation: OperationNFTToEVM}
+		if !r.custom[event.Type] && r.customByEVM[bridge.NormalizeEVMAddress(event.EVMContractAddress)] {
+			// A bridge-defined NFT whose ERC721 was later registered as a custom
+			// cross-VM NFT is burned rather than escrowed, and is not charged
+			return classification, true
+		}
+		// Default and custom cross-VM NFTs are escrowed
+		classification.FeeBearing, classification.Fee, classification.StorageFee = true, FeeBase, true
+		return classification, true
+	case events.BridgedNFTFromEVM:
+		classification := Classification{Operation: OperationNFTFromEVM}
+		// Custom cross-VM NFTs, including those updated from bridge-defined
+		// NFTs, are released from escrow or minted without a fee
+		if !r.custom[event.Type] {
+			classification.FeeBearing, classification.Fee = true, FeeBase
+		}
+		return classification, true
+	case events.BridgedTokensToEVM:
+		return Classification{
+			Operation:  OperationTokensToEVM,
+			FeeBearing: true,
+			Fee:        FeeBase,
+			// Cadence-native tokens are escrowed, other tokens are burned
+			StorageFee: !r.handlers[event.Type] && !r.BridgeEnv.IsBridgeDefinedType(event.Type),
+		}, true
+	case events.BridgedTokensFromEVM:
+		return Classification{Operation: OperationTokensFromEVM, FeeBearing: true, Fee: FeeBase}, true
+	}
+	return Classification{}, false
+}
+
+// Applies an event to the report. Events must be applied in block order
+func (r *Report) Apply(record events.Record) error {
+	switch event := record.Event.(type) {
+	case events.BridgeFeeUpdated:
+		r.Schedule.Apply(record, event)
+		return nil
+	case events.CustomAssociationEstablished:
+		r.custom[event.Type] = true
+		r.customByEVM[bridge.NormalizeEVMAddress(event.EVMContractAddress)] = true
+	case events.HandlerConfigured:
+		r.handlers[event.TargetType] = true
+		return nil
+	}
+
+	classification, ok := r.Classify(record)
+	if !ok {
+		return nil
+	}
+	key := rowKey{period: r.periodOf(record.BlockTimestamp), cadence: r.assetType(record.Event), operation: classification.Operation}
+	row, ok := r.rows[key]
+	if !ok {
+		row = &Row{Period: key.period, Type: key.cadence, Operation: key.operation}
+		r.rows[key] = row
+	}
+	row.Count++
+	if classification.FeeBearing {
+		row.FeeBearing++
+		fee := r.Schedule.At(classification.Fee, index.PositionOf(record))
+		if row.total > math.MaxUint64-fee {
+			return fmt.Errorf("Fees of %s overflow UFix64", row.Type)
+		}
+		row.total += fee
+	}
+	if classification.StorageFee {
+		row.StorageFeeExcluded++
+	}
+	return nil
+}
+
+// Returns a consumer of the events of the report in the Environment, which
+// applies every event to the report
+func (r *Report) Consumer(source events.EventSource, env bridge.Environment, checkpoints events.CheckpointStore, startHeight uint64) *events.Consumer {
+	return events.ConsumerFor(r, EventTypes(env), source, checkpoints, startHeight)
+}
+
+// Applies a record passed by an events.Consumer
+func (r *Report) Handle(_ context.Context, record events.Record) error {
+	return r.Apply(record)
+}
+
+// Returns the rows of the report ordered by period, type and operation
+func (r *Report) Rows() []Row {
+	rows := make([]Row, 0, len(r.rows))
+	for _, row := range r.rows {
+		result := *row
+		result.EstimatedFees = row.total.String()
+		rows = append(rows, result)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Period != rows[j].Period {
+			return rows[i].Period < rows[j].Period
+		}
+		if rows[i].Type != rows[j].Type {
+			return rows[i].Type < rows[j].Type
+		}
+		return rows[i].Operation < rows[j].Operation
+	})
+	return rows
+}
+
+// Writes the rows of the report as CSV with a header line
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"period", "type", "operation", "count", "fee_bearing", "storage_fee_excluded", "estimated_fees"}); err != nil {
+		return err
+	}
+	for _, row := range r.Rows() {
+		if err := writer.Write([]string{
+			row.Period,
+			row.Type,
+			string(row.Operation),
+			strconv.Itoa(row.Count),
+			strconv.Itoa(row.FeeBearing),
+			strconv.Itoa(row.StorageFeeExcluded),
+			row.EstimatedFees,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Writes the fee schedule and the rows of the report as JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	changes := r.Schedule.Changes
+	if changes == nil {
+		changes = []Change{}
+	}
+	type jsonChange struct {
+		Height uint64 `json:"height"`
+		Fee    Fee    `json:"fee"`
+		Old    string `json:"old"`
+		New    string `json:"new"`
+	}
+	schedule := make([]jsonChange, len(changes))
+	for i, change := range changes {
+		schedule[i] = jsonChange{Height: change.Position.Height, Fee: change.Fee, Old: change.Old.String(), New: change.New.String()}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Schedule []jsonChange `json:"schedule"`
+		Rows     []Row        `json:"rows"`
+	}{schedule, r.Rows()})
+}
+
+func (r *Report) periodOf(timestamp time.Time) string {
+	if timestamp.IsZero() {
+		return ""
+	}
+	if r.Period == PeriodMonth {
+		return timestamp.UTC().Format("2006-01")
+	}
+	return timestamp.UTC().Format("2006-01-02")
+}
+
+func (r *Report) assetType(event events.Event) string {
+	switch event := event.(type) {
+	case events.Onboarded:
+		return event.Type
+	case events.BridgeDefiningContractDeployed:
+		return r.BridgeEnv.BridgeDefinedType(event.ContractName, event.IsERC721)
+	case events.CustomAssociationEstablished:
+		return event.Type
+	case events.BridgedNFTToEVM:
+		return event.Type
+	case events.BridgedNFTFromEVM:
+		return event.Type
+	case events.BridgedTokensToEVM:
+		return event.Type
+	case events.BridgedTokensFromEVM:
+		return event.Type
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+// Package fees reconstructs the bridge fee schedule from BridgeFeeUpdated
+// events and estimates the fees collected by each bridge operation.
+//
+// Fees are charged only by operations that cause the bridge to store assets,
+// plus a base fee on fungible token and default NFT transfers, see
+// docs/adr/ADR-0001-nft-bridge-fee-model.md. The storage component of a fee
+// depends on the bytes stored, which events do not carry, so estimates cover
+// the onboarding and base fees only and are a lower bound of actual revenue.
+package fees
+
+import (
+	"github.com/onflow/cadence"
+
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/index"
+)
+
+// Fee distinguishes the two configurable fees of FlowEVMBridgeConfig
+type Fee string
+
+const (
+	// FlowEVMBridgeConfig.onboardFee, charged when onboarding an asset
+	FeeOnboarding Fee = "onboarding"
+	// FlowEVMBridgeConfig.baseFee, the floor of every bridge fee
+	FeeBase Fee = "base"
+)
+
+// Change is an update of a fee
+type Change struct {
+	Position index.Position `json:"position"`
+	Fee      Fee            `json:"fee"`
+	Old      cadence.UFix64 `json:"old"`
+	New      cadence.UFix64 `json:"new"`
+}
+
+// Schedule is the history of the bridge fees
+type Schedule struct {
+	// Fees in effect before the first change, zero when the schedule starts at
+	// the deployment of the bridge
+	InitialOnboardFee cadence.UFix64
+	InitialBaseFee    cadence.UFix64
+	Changes           []Change
+}
+
+// Records a BridgeFeeUpdated event. Changes must be applied in block order
+func (s *Schedule) Apply(record events.Record, event events.BridgeFeeUpdated) {
+	fee := FeeBase
+	if event.IsOnboarding {
+		fee = FeeOnboarding
+	}
+	s.Changes = append(s.Changes, Change{
+		Position: index.PositionOf(record),
+		Fee:      fee,
+		Old:      event.Old,
+		New:      event.New,
+	})
+}
+
+// Returns the fee in effect at a position, i.e. after every change before it
+func (s *Schedule) At(fee Fee, position index.Position) cadence.UFix64 {
+	amount := s.InitialBaseFee
+	if fee == FeeOnboarding {
+		amount = s.InitialOnboardFee
+	}
+	for _, change := range s.Changes {
+		if !change.Position.Before(position) {
+			break
+		}
+		if change.Fee == fee {
+			amount = change.New
+		}
+	}
+	return amount
+}
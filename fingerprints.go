@@ -0,0 +1,184 @@
+package bridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"golang.org/x/crypto/sha3"
+
+	coreContracts "github.com/onflow/flow-core-contracts/lib/go/templates"
+)
+
+// ContractFingerprint identifies the rendered code of a bridge contract
+type ContractFingerprint struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	SHA3_256 string `json:"sha3_256"`
+	SHA2_256 string `json:"sha2_256"`
+}
+
+type VerificationStatus string
+
+const (
+	// The on-chain code is identical to the rendered contract
+	VerificationMatch VerificationStatus = "match"
+	// The code only differs in the addresses or form of its imports
+	VerificationImportOnly VerificationStatus = "import-only"
+	// The code differs beyond its imports
+	VerificationMismatch VerificationStatus = "mismatch"
+)
+
+// Verification is the result of comparing on-chain code with a rendered contract
+type Verification struct {
+	Name     string              `json:"name"`
+	Status   VerificationStatus  `json:"status"`
+	Expected ContractFingerprint `json:"expected"`
+	Actual   ContractFingerprint `json:"actual"`
+	// Unified diff from the rendered to the on-chain code, set on mismatch
+	Diff string `json:"diff,omitempty"`
+}
+
+// Returns the canonical form of Cadence code that is hashed for fingerprints:
+// line endings are normalized, trailing whitespace is removed from every line
+// and the code ends with a single newline
+func CanonicalCode(code []byte) string {
+	text := strings.ReplaceAll(string(code), "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n"
+}
+
+// Computes the fingerprint of Cadence code
+func Fingerprint(name string, code []byte) ContractFingerprint {
+	canonical := []byte(CanonicalCode(code))
+	sha2 := sha256.Sum256(canonical)
+	sha3 := sha3.Sum256(canonical)
+	return ContractFingerprint{
+		Name:     name,
+		SHA3_256: hex.EncodeToString(sha3[:]),
+		SHA2_256: hex.EncodeToString(sha2[:]),
+	}
+}
+
+// Computes the fingerprint of every bridge contract rendered for the given
+// Environments, sorted by contract name
+func ContractFingerprints(bridgeEnv Environment, coreEnv coreContracts.Environment) ([]ContractFingerprint, error) {
+	graph, err := DependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprints := []ContractFingerprint{}
+	for _, name := range graph.Contracts() {
+		fingerprint, err := renderedFingerprint(graph, name, bridgeEnv, coreEnv)
+		if err != nil {
+			return nil, err
+		}
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	return fingerprints, nil
+}
+
+// Compares the on-chain code of a bridge contract, fetched by any means,
+// with the contract rendered for the given Environments
+func VerifyContractCode(name string, onChainCode []byte, bridgeEnv Environment, coreEnv coreContracts.Environment) (*Verification, error) {
+	graph, err := DependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+	if graph.Path(name) == "" {
+		return nil, errors.New("Unknown bridge contract " + name)
+	}
+
+	expected, err := renderedFingerprint(graph, name, bridgeEnv, coreEnv)
+	if err != nil {
+		return nil, err
+	}
+	actual := Fingerprint(name, onChainCode)
+	actual.Path = expected.Path
+
+	verification := &Verification{
+		Name:     name,
+		Expected: expected,
+		Actual:   actual,
+	}
+	if expected.SHA3_256 == actual.SHA3_256 {
+		verification.Status = VerificationMatch
+		return verification, nil
+	}
+
+	rendered, err := GetCadenceContractCode(expected.Path, bridgeEnv, coreEnv)
+	if err != nil {
+		return nil, err
+	}
+	renderedCode := CanonicalCode(rendered)
+	onChain := CanonicalCode(onChainCode)
+
+	if normalizeImports(renderedCode) == normalizeImports(onChain) {
+		verification.Status = VerificationImportOnly
+		return verification, nil
+	}
+
+	verification.Status = VerificationMismatch
+	verification.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(renderedCode),
+		B:        difflib.SplitLines(onChain),
+		FromFile: expected.Path,
+		ToFile:   name + " (on-chain)",
+		Context:  3,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+// Verifies the on-chain code of several contracts, keyed by contract name.
+// The results are sorted by contract name
+func VerifyContracts(onChainCode map[string][]byte, bridgeEnv Environment, coreEnv coreContracts.Environment) ([]Verification, error) {
+	names := make([]string, 0, len(onChainCode))
+	for name := range onChainCode {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	verifications := make([]Verification, 0, len(names))
+	for _, name := range names {
+		verification, err := VerifyContractCode(name, onChainCode[name], bridgeEnv, coreEnv)
+		if err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, *verification)
+	}
+	return verifications, nil
+}
+
+func renderedFingerprint(graph *ContractGraph, name string, bridgeEnv Environment, coreEnv coreContracts.Environment) (ContractFingerprint, error) {
+	code, err := GetCadenceContractCode(graph.Path(name), bridgeEnv, coreEnv)
+	if err != nil {
+		return ContractFingerprint{}, err
+	}
+	fingerprint := Fingerprint(name, code)
+	fingerprint.Path = graph.Path(name)
+	return fingerprint, nil
+}
+
+// Rewrites every import to the bare `import Name` form so
+// code importing from different addresses compares equal
+func normalizeImports(code string) string {
+	return importPattern.ReplaceAllStringFunc(code, func(match string) string {
+		groups := importPattern.FindStringSubmatch(match)
+		name := groups[1]
+		if name == "" {
+			name = groups[2]
+		}
+		indent := match[:len(match)-len(strings.TrimLeft(match, " \t\n"))]
+		return indent + "import " + name
+	})
+}
@@ -0,0 +1,77 @@
+package bridge_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+)
+
+func TestContractFingerprints(t *testing.T) {
+	bridgeEnv, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+
+	fingerprints, err := bridge.ContractFingerprints(bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	assert.Len(t, fingerprints, 26)
+	for _, fingerprint := range fingerprints {
+		assert.Len(t, fingerprint.SHA3_256, 64)
+		assert.Len(t, fingerprint.SHA2_256, 64)
+		assert.NotEqual(t, fingerprint.SHA3_256, fingerprint.SHA2_256)
+	}
+
+	// Fingerprints are stable across line ending and trailing whitespace differences
+	code := []byte("access(all) contract Foo {}\n")
+	assert.Equal(t, bridge.Fingerprint("Foo", code), bridge.Fingerprint("Foo", []byte("access(all) contract Foo {}  \r\n\n")))
+	assert.NotEqual(t, bridge.Fingerprint("Foo", code), bridge.Fingerprint("Foo", []byte("access(all) contract Bar {}\n")))
+}
+
+func TestVerifyContractCode(t *testing.T) {
+	bridgeEnv, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+
+	path := "cadence/contracts/bridge/FlowEVMBridgeConfig.cdc"
+	rendered, err := bridge.GetCadenceContractCode(path, bridgeEnv, coreEnv)
+	require.Nil(t, err)
+
+	verification, err := bridge.VerifyContractCode("FlowEVMBridgeConfig", rendered, bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	assert.Equal(t, bridge.VerificationMatch, verification.Status)
+	assert.Equal(t, path, verification.Expected.Path)
+	assert.Empty(t, verification.Diff)
+
+	// Imports from other addresses only differ in the imports
+	testnetEnv, testnetCoreEnv, err := bridge.NetworkEnvironment(bridge.NetworkTestnet)
+	require.Nil(t, err)
+	testnetCode, err := bridge.GetCadenceContractCode(path, testnetEnv, testnetCoreEnv)
+	require.Nil(t, err)
+	verification, err = bridge.VerifyContractCode("FlowEVMBridgeConfig", testnetCode, bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	assert.Equal(t, bridge.VerificationImportOnly, verification.Status)
+	assert.NotEqual(t, verification.Expected.SHA3_256, verification.Actual.SHA3_256)
+
+	// Real changes are reported with a diff
+	changed := strings.Replace(string(rendered), "    var gasLimit: UInt64", "    var gasLimit: UInt128", 1)
+	require.NotEqual(t, string(rendered), changed)
+	verification, err = bridge.VerifyContractCode("FlowEVMBridgeConfig", []byte(changed), bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	assert.Equal(t, bridge.VerificationMismatch, verification.Status)
+	assert.Contains(t, verification.Diff, "--- "+path)
+	assert.Contains(t, verification.Diff, "+    var gasLimit: UInt128")
+
+	_, err = bridge.VerifyContractCode("CryptoPunks", rendered, bridgeEnv, coreEnv)
+	assert.NotNil(t, err)
+
+	verifications, err := bridge.VerifyContracts(map[string][]byte{
+		"FlowEVMBridgeConfig": rendered,
+		"ArrayUtils":          []byte("access(all) contract ArrayUtils {}"),
+	}, bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	require.Len(t, verifications, 2)
+	assert.Equal(t, "ArrayUtils", verifications[0].Name)
+	assert.Equal(t, bridge.VerificationMismatch, verifications[0].Status)
+	assert.Equal(t, bridge.VerificationMatch, verifications[1].Status)
+}
@@ -0,0 +1,309 @@
+// Package index maintains an off-chain index of the associations between
+// Cadence types and EVM contracts, built from bridge events.
+//
+// The index mirrors FlowEVMBridgeConfig: associations established by
+// permissionless onboarding or token handlers are recorded from
+// AssociationUpdated and Onboarded, custom cross-VM associations from
+// CustomAssociationEstablished, and a custom association takes precedence
+// over the bridge-defined association it replaced. Types bridged by a token
+// handler are marked from HandlerConfigured.
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+)
+
+// Native VM of a custom cross-VM association
+const (
+	NativeVMCadence = bridge.NativeVMCadence
+	NativeVMEVM     = bridge.NativeVMEVM
+)
+
+// Returns the fully qualified types of the events the index consumes
+func EventTypes(env bridge.Environment) []string {
+	return []string{
+		events.TypeID(env, events.TypeOnboarded),
+		events.TypeID(env, events.TypeAssociationUpdated),
+		events.TypeID(env, events.TypeCustomAssociationEstablished),
+		events.TypeID(env, events.TypeHandlerConfigured),
+	}
+}
+
+// Association is the association of a Cadence type with an EVM contract
+type Association struct {
+	Type string `json:"type"`
+	// EVM contract address as lowercase hex without 0x prefix
+	EVMAddress string `json:"evmAddress"`
+	// Whether the association is a custom cross-VM association
+	Custom bool `json:"custom"`
+	// Native VM of a custom association, NativeVMCadence or NativeVMEVM
+	NativeVM string `json:"nativeVM,omitempty"`
+	// The bridge-defined EVM contract a Cadence-native custom association replaced
+	LegacyEVMAddress string `json:"legacyEVMAddress,omitempty"`
+	// The bridge-defined Cadence type an EVM-native custom association replaced
+	LegacyType string `json:"legacyType,omitempty"`
+	// Whether a token handler bridges the type instead of the bridge escrow,
+	// such as for USDC
+	Handled bool `json:"handled,omitempty"`
+	// Height of the block the association was established in
+	Height uint64 `json:"height"`
+}
+
+// Position orders events on chain
+type Position struct {
+	Height           uint64 `json:"height"`
+	TransactionIndex int    `json:"transactionIndex"`
+	EventIndex       int    `json:"eventIndex"`
+}
+
+// Returns the position of a record on chain
+func PositionOf(record events.Record) Position {
+	return Position{
+		Height:           record.BlockHeight,
+		TransactionIndex: record.TransactionIndex,
+		EventIndex:       record.EventIndex,
+	}
+}
+
+// Returns whether p is ordered before other
+func (p Position) Before(other Position) bool {
+	if p.Height != other.Height {
+		return p.Height < other.Height
+	}
+	if p.TransactionIndex != other.TransactionIndex {
+		return p.TransactionIndex < other.TransactionIndex
+	}
+	return p.EventIndex < other.EventIndex
+}
+
+type bridgedAssociation struct {
+	EVMAddress string `json:"evmAddress"`
+	Height     uint64 `json:"height"`
+}
+
+type customAssociation struct {
+	EVMAddress         string `json:"evmAddress"`
+	NativeVM           string `json:"nativeVM"`
+	UpdatedFromBridged bool   `json:"updatedFromBridged"`
+	Height             uint64 `json:"height"`
+}
+
+// Index is the type to EVM address association index. It is not safe for
+// concurrent use
+type Index struct {
+	// Position of the last applied event, nil if none was applied
+	last *Position
+	// Associations established by permissionless onboarding or token handlers
+	bridged      map[string]bridgedAssociation
+	bridgedByEVM map[string]string
+	// Custom cross-VM associations
+	custom      map[string]customAssociation
+	customByEVM map[string]string
+	// Types with a token handler
+	handled map[string]bool
+}
+
+// Returns an empty index
+func New() *Index {
+	return &Index{
+		bridged:      map[string]bridgedAssociation{},
+		bridgedByEVM: map[string]string{},
+		custom:       map[string]customAssociation{},
+		customByEVM:  map[string]string{},
+		handled:      map[string]bool{},
+	}
+}
+
+// Returns the position of the last applied event and whether any was applied
+func (ix *Index) Last() (Position, bool) {
+	if ix.last == nil {
+		return Position{}, false
+	}
+	return *ix.last, true
+}
+
+// Applies an event to the index. Events must be applied in block order;
+// events at or before the last applied position are ignored, so a restored
+// index can replay an overlapping range. Events the index does not consume
+// are ignored as well
+func (ix *Index) Apply(record events.Record) error {
+	position := PositionOf(record)
+	if ix.last != nil && !ix.last.Before(position) {
+		return nil
+	}
+
+	switch event := record.Event.(type) {
+	case events.Onboarded:
+		ix.associate(event.Type, event.EVMContractAddress, record.BlockHeight)
+	case events.AssociationUpdated:
+		ix.associate(event.Type, event.EVMAddress, record.BlockHeight)
+	case events.CustomAssociationEstablished:
+		nativeVM, err := bridge.NativeVM(event.NativeVMRawValue)
+		if err != nil {
+			return fmt.Errorf("Cannot apply custom association of %s at height %d: %w", event.Type, record.BlockHeight, err)
+		}
+		evmAddress := bridge.NormalizeEVMAddress(event.EVMContractAddress)
+		ix.custom[event.Type] = customAssociation{
+			EVMAddress:         evmAddress,
+			NativeVM:           nativeVM,
+			UpdatedFromBridged: event.UpdatedFromBridged,
+			Height:             record.BlockHeight,
+		}
+		ix.customByEVM[evmAddress] = event.Type
+	case events.HandlerConfigured:
+		ix.handled[event.TargetType] = true
+	}
+
+	ix.last = &position
+	return nil
+}
+
+// Returns a consumer of the events of the index in the Environment, which
+// applies every event to the index
+func (ix *Index) Consumer(source events.EventSource, env bridge.Environment, checkpoints events.CheckpointStore, startHeight uint64) *events.Consumer {
+	return events.ConsumerFor(ix, EventTypes(env), source, checkpoints, startHeight)
+}
+
+// Applies a record passed by an events.Consumer
+func (ix *Index) Handle(_ context.Context, record events.Record) error {
+	return ix.Apply(record)
+}
+
+func (ix *Index) associate(cadenceType, evmAddress string, height uint64) {
+	evmAddress = bridge.NormalizeEVMAddress(evmAddress)
+	if existing, ok := ix.bridged[cadenceType]; ok && existing.EVMAddress == evmAddress {
+		return
+	}
+	ix.bridged[cadenceType] = bridgedAssociation{EVMAddress: evmAddress, Height: height}
+	ix.bridgedByEVM[evmAddress] = cadenceType
+}
+
+// Returns the EVM address associated with a Cadence type, preferring a custom
+// association over the bridge-defined one like FlowEVMBridgeConfig does
+func (ix *Index) EVMAddress(cadenceType string) (string, bool) {
+	if custom, ok := ix.custom[cadenceType]; ok {
+		return custom.EVMAddress, true
+	}
+	bridged, ok := ix.bridged[cadenceType]
+	return bridged.EVMAddress, ok
+}
+
+// Returns the Cadence type associated with an EVM address, preferring a custom
+// association over the bridge-defined one like FlowEVMBridgeConfig does
+func (ix *Index) Type(evmAddress string) (string, bool) {
+	evmAddress = bridge.NormalizeEVMAddress(evmAddress)
+	if cadenceType, ok := ix.customByEVM[evmAddress]; ok {
+		return cadenceType, true
+	}
+	cadenceType, ok := ix.bridgedByEVM[evmAddress]
+	return cadenceType, ok
+}
+
+// Returns the current association of a Cadence type, including the legacy
+// bridge-defined side a custom association replaced
+func (ix *Index) Association(cadenceType string) (Association, bool) {
+	if custom, ok := ix.custom[cadenceType]; ok {
+		association := Association{
+			Type:       cadenceType,
+			EVMAddress: custom.EVMAddress,
+			Custom:     true,
+			NativeVM:   custom.NativeVM,
+			Handled:    ix.handled[cadenceType],
+			Height:     custom.Height,
+		}
+		if custom.UpdatedFromBridged {
+			if bridged, ok := ix.bridged[cadenceType]; ok && bridged.EVMAddress != custom.EVMAddress {
+				association.LegacyEVMAddress = bridged.EVMAddress
+			}
+			if legacyType, ok := ix.bridgedByEVM[custom.EVMAddress]; ok && legacyType != cadenceType {
+				association.LegacyType = legacyType
+			}
+		}
+		return association, true
+	}
+	if bridged, ok := ix.bridged[cadenceType]; ok {
+		return Association{Type: cadenceType, EVMAddress: bridged.EVMAddress, Handled: ix.handled[cadenceType], Height: bridged.Height}, true
+	}
+	return Association{}, false
+}
+
+// Returns the current association of every indexed Cadence type, sorted by type.
+// A bridge-defined type replaced by an EVM-native custom association is listed
+// with its original association and as the LegacyType of the custom one
+func (ix *Index) Associations() []Association {
+	types := make([]string, 0, len(ix.bridged)+len(ix.custom))
+	for cadenceType := range ix.bridged {
+		types = append(types, cadenceType)
+	}
+	for cadenceType := range ix.custom {
+		if _, ok := ix.bridged[cadenceType]; !ok {
+			types = append(types, cadenceType)
+		}
+	}
+	sort.Strings(types)
+
+	associations := make([]Association, 0, len(types))
+	for _, cadenceType := range types {
+		association, _ := ix.Association(cadenceType)
+		associations = append(associations, association)
+	}
+	return associations
+}
+
+type snapshot struct {
+	Last    *Position                     `json:"last,omitempty"`
+	Bridged map[string]bridgedAssociation `json:"bridged"`
+	Custom  map[string]customAssociation  `json:"custom"`
+	Handled map[string]bool               `json:"handled,omitempty"`
+}
+
+// Writes a snapshot of the index to path, replacing the previous file atomically
+func (ix *Index) Save(path string) error {
+	data, err := json.MarshalIndent(snapshot{Last: ix.last, Bridged: ix.bridged, Custom: ix.custom, Handled: ix.handled}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Restores an index from a snapshot written by Save, returning an empty
+// index if the file does not exist yet
+func Load(path string) (*Index, error) {
+	ix := New()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("Invalid index snapshot %s: %w", path, err)
+	}
+	ix.last = s.Last
+	for cadenceType, bridged := range s.Bridged {
+		ix.bridged[cadenceType] = bridged
+		ix.bridgedByEVM[bridged.EVMAddress] = cadenceType
+	}
+	for cadenceType, custom := range s.Custom {
+		ix.custom[cadenceType] = custom
+		ix.customByEVM[custom.EVMAddress] = cadenceType
+	}
+	for cadenceType := range s.Handled {
+		ix.handled[cadenceType] = true
+	}
+	return ix, nil
+}
@@ -0,0 +1,187 @@
+package index_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+	jsoncdc "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/index"
+)
+
+const (
+	bridgeAddress = "1e4aa0b87d10b141"
+	bridgedNFT    = "A.1e4aa0b87d10b141.EVMVMBridgedNFT_0000000000000000000000000000000000000aaa.NFT"
+	exampleNFT    = "A.0ae53cb6e3f42a79.ExampleNFT.NFT"
+	projectNFT    = "A.0ae53cb6e3f42a79.ProjectNFT.NFT"
+)
+
+func newEvent(t *testing.T, qualified string, fields []cadence.Field, values []cadence.Value) cadence.Event {
+	address, err := common.HexToAddress(bridgeAddress)
+	require.Nil(t, err)
+	contract, _, _ := strings.Cut(qualified, ".")
+	location := common.NewAddressLocation(nil, address, contract)
+	return cadence.NewEvent(values).WithType(cadence.NewEventType(location, qualified, fields, nil))
+}
+
+func associationUpdated(t *testing.T, cadenceType, evmAddress string) cadence.Event {
+	return newEvent(t, events.TypeAssociationUpdated, []cadence.Field{
+		{Identifier: "type", Type: cadence.StringType},
+		{Identifier: "evmAddress", Type: cadence.StringType},
+	}, []cadence.Value{cadence.String(cadenceType), cadence.String(evmAddress)})
+}
+
+func customAssociationEstablished(t *testing.T, cadenceType, evmAddress string, nativeVM uint8, updatedFromBridged bool) cadence.Event {
+	optionalString := cadence.NewOptionalType(cadence.StringType)
+	optionalUInt64 := cadence.NewOptionalType(cadence.UInt64Type)
+	return newEvent(t, events.TypeCustomAssociationEstablished, []cadence.Field{
+		{Identifier: "type", Type: cadence.StringType},
+		{Identifier: "evmContractAddress", Type: cadence.StringType},
+		{Identifier: "nativeVMRawValue", Type: cadence.UInt8Type},
+		{Identifier: "updatedFromBridged", Type: cadence.BoolType},
+		{Identifier: "fulfillmentMinterType", Type: optionalString},
+		{Identifier: "fulfillmentMinterOrigin", Type: cadence.NewOptionalType(cadence.AddressType)},
+		{Identifier: "fulfillmentMinterCapID", Type: optionalUInt64},
+		{Identifier: "fulfillmentMinterUUID", Type: optionalUInt64},
+		{Identifier: "configUUID", Type: cadence.UInt64Type},
+	}, []cadence.Value{
+		cadence.String(cadenceType),
+		cadence.String(evmAddress),
+		cadence.UInt8(nativeVM),
+		cadence.Bool(updatedFromBridged),
+		cadence.NewOptional(nil),
+		cadence.NewOptional(nil),
+		cadence.NewOptional(nil),
+		cadence.NewOptional(nil),
+		cadence.UInt64(1),
+	})
+}
+
+func writeFixture(t *testing.T, lines []events.FixtureEvent) string {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	var builder strings.Builder
+	for _, line := range lines {
+		data, err := json.Marshal(line)
+		require.Nil(t, err)
+		builder.Write(data)
+		builder.WriteByte('\n')
+	}
+	require.Nil(t, os.WriteFile(path, []byte(builder.String()), 0644))
+	return path
+}
+
+func fixtureEvent(t *testing.T, height uint64, event cadence.Event) events.FixtureEvent {
+	payload, err := jsoncdc.Encode(event)
+	require.Nil(t, err)
+	return events.FixtureEvent{BlockHeight: height, Type: event.EventType.ID(), Payload: payload}
+}
+
+func TestIndexFromJSONL(t *testing.T) {
+	// Out of order on purpose, the consumer sorts by block height
+	path := writeFixture(t, []events.FixtureEvent{
+		fixtureEvent(t, 30, customAssociationEstablished(t, exampleNFT, "0000000000000000000000000000000000000ccc", 0, true)),
+		fixtureEvent(t, 10, associationUpdated(t, exampleNFT, "0000000000000000000000000000000000000bbb")),
+		fixtureEvent(t, 20, associationUpdated(t, bridgedNFT, "0000000000000000000000000000000000000aaa")),
+		fixtureEvent(t, 40, customAssociationEstablished(t, projectNFT, "0000000000000000000000000000000000000AAA", 1, true)),
+	})
+
+	source, err := events.ReadJSONLSource(path)
+	require.Nil(t, err)
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	ix := index.New()
+	_, err = ix.Consumer(source, env, &events.MemoryCheckpointStore{}, 1).Sync(context.Background())
+	require.Nil(t, err)
+
+	// Custom associations take precedence
+	address, ok := ix.EVMAddress(exampleNFT)
+	require.True(t, ok)
+	assert.Equal(t, "0000000000000000000000000000000000000ccc", address)
+	cadenceType, ok := ix.Type("0x0000000000000000000000000000000000000aaa")
+	require.True(t, ok)
+	assert.Equal(t, projectNFT, cadenceType)
+
+	association, ok := ix.Association(exampleNFT)
+	require.True(t, ok)
+	assert.Equal(t, index.Association{
+		Type:             exampleNFT,
+		EVMAddress:       "0000000000000000000000000000000000000ccc",
+		Custom:           true,
+		NativeVM:         index.NativeVMCadence,
+		LegacyEVMAddress: "0000000000000000000000000000000000000bbb",
+		Height:           30,
+	}, association)
+
+	association, ok = ix.Association(projectNFT)
+	require.True(t, ok)
+	assert.Equal(t, index.NativeVMEVM, association.NativeVM)
+	assert.Equal(t, bridgedNFT, association.LegacyType)
+
+	assert.Len(t, ix.Associations(), 3)
+	last, ok := ix.Last()
+	require.True(t, ok)
+	assert.Equal(t, uint64(40), last.Height)
+}
+
+func TestIndexSnapshot(t *testing.T) {
+	ix := index.New()
+	record := events.Record{
+		Event:       events.AssociationUpdated{Type: exampleNFT, EVMAddress: "0000000000000000000000000000000000000bbb"},
+		BlockHeight: 10,
+	}
+	require.Nil(t, ix.Apply(record))
+	require.Nil(t, ix.Apply(events.Record{Event: events.HandlerConfigured{TargetType: exampleNFT}, BlockHeight: 10, EventIndex: 1}))
+
+	path := filepath.Join(t.TempDir(), "index.json")
+	require.Nil(t, ix.Save(path))
+	restored, err := index.Load(path)
+	require.Nil(t, err)
+	assert.Equal(t, ix.Associations(), restored.Associations())
+	association, _ := restored.Association(exampleNFT)
+	assert.True(t, association.Handled)
+
+	// Replaying an applied event after restoring is a no-op
+	record.Event = events.AssociationUpdated{Type: exampleNFT, EVMAddress: "0000000000000000000000000000000000000ddd"}
+	require.Nil(t, restored.Apply(record))
+	address, _ := restored.EVMAddress(exampleNFT)
+	assert.Equal(t, "0000000000000000000000000000000000000bbb", address)
+
+	empty, err := index.Load(filepath.Join(t.TempDir(), "missing.json"))
+	require.Nil(t, err)
+	assert.Empty(t, empty.Associations())
+}
+
+func TestIndexConsumer(t *testing.T) {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	event := associationUpdated(t, exampleNFT, "0000000000000000000000000000000000000bbb")
+	source := events.NewMemorySource()
+	source.Add(120, flow.Event{Type: event.EventType.ID(), TransactionID: flow.Identifier{1}, Value: event})
+
+	ix := index.New()
+	checkpoints := &events.MemoryCheckpointStore{}
+	checkpoint, err := ix.Consumer(source, env, checkpoints, 100).Sync(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, uint64(120), checkpoint.Height)
+	address, ok := ix.EVMAddress(exampleNFT)
+	require.True(t, ok)
+	assert.Equal(t, "0000000000000000000000000000000000000bbb", address)
+
+	// Resuming from the checkpoint applies no event twice
+	source.Add(130, flow.Event{Type: event.EventType.ID(), TransactionID: flow.Identifier{2}, Value: associationUpdated(t, exampleNFT, "0000000000000000000000000000000000000ccc")})
+	_, err = ix.Consumer(source, env, checkpoints, 100).Sync(context.Background())
+	require.Nil(t, err)
+	association, _ := ix.Association(exampleNFT)
+	assert.Equal(t, uint64(130), association.Height)
+}
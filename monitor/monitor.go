@@ -0,0 +1,298 @@
+// Package monitor continuously asserts the cross-VM supply invariants of
+// fungible tokens onboarded to the bridge.
+//
+// For a Cadence-native token, the balance locked in FlowEVMBridgeTokenEscrow
+// must back the supply of its bridged ERC20. For an EVM-native ERC20, the
+// balance held by the bridge COA must back the supply of the bridge-defined
+// EVMVMBridgedToken_* Vault minted in Cadence. Both are checked through
+// scripts, so any script executor, such as an access node client, can be used.
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	coreContracts "github.com/onflow/flow-core-contracts/lib/go/templates"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/index"
+)
+
+const (
+	pathGetLockedTokenBalance = "cadence/scripts/escrow/get_locked_token_balance.cdc"
+	pathGetTokenDecimals      = "cadence/scripts/utils/get_token_decimals.cdc"
+	pathERC20TotalSupply      = "cadence/scripts/utils/total_supply.cdc"
+	pathCadenceTotalSupply    = "cadence/scripts/tokens/total_supply.cdc"
+	pathBalanceOf             = "cadence/scripts/utils/balance_of.cdc"
+	pathGetBridgeCOAAddress   = "cadence/scripts/bridge/get_bridge_coa_address.cdc"
+)
+
+// ScriptExecutor executes a script and returns its result
+type ScriptExecutor interface {
+	ExecuteScript(ctx context.Context, code []byte, arguments []cadence.Value) (cadence.Value, error)
+}
+
+// Asset is a fungible token onboarded to the bridge
+type Asset struct {
+	// Cadence Vault type identifier
+	Type string `json:"type"`
+	// Associated ERC20 address as hex without 0x prefix
+	EVMContractAddress string `json:"evmContractAddress"`
+}
+
+// Returns the fungible tokens of an association index bridged through the
+// escrow, sorted by type. Tokens with a token handler, such as USDC, are
+// minted and burned by the handler and have no escrow to check
+func AssetsFromIndex(ix *index.Index) []Asset {
+	assets := []Asset{}
+	for _, association := range ix.Associations() {
+		if association.Custom || association.Handled || !strings.HasSuffix(association.Type, ".Vault") {
+			continue
+		}
+		assets = append(assets, Asset{Type: association.Type, EVMContractAddress: association.EVMAddress})
+	}
+	return assets
+}
+
+type Severity string
+
+const (
+	// An invariant is violated: bridged supply is not fully backed
+	SeverityCritical Severity = "critical"
+	// The backing exceeds the bridged supply by more than the tolerance
+	SeverityWarning Severity = "warning"
+	// The invariant could not be checked
+	SeverityError Severity = "error"
+)
+
+type Invariant string
+
+const (
+	// Locked escrow balance of a Cadence-native token backs its ERC20 supply
+	InvariantCadenceNative Invariant = "cadence-native-supply"
+	// ERC20 balance of the bridge COA backs the Cadence supply of an EVM-native token
+	InvariantEVMNative Invariant = "evm-native-supply"
+)
+
+// Finding is a violated or unverifiable invariant
+type Finding struct {
+	Time               time.Time `json:"time"`
+	Severity           Severity  `json:"severity"`
+	Invariant          Invariant `json:"invariant"`
+	Type               string    `json:"type"`
+	EVMContractAddress string    `json:"evmContractAddress"`
+	Message            string    `json:"message"`
+	// Backing and supply in units of the ERC20, unset if the check failed
+	Backing string `json:"backing,omitempty"`
+	Supply  string `json:"supply,omitempty"`
+}
+
+// Monitor checks supply invariants through a script executor
+type Monitor struct {
+	BridgeEnv bridge.Environment
+	CoreEnv   coreContracts.Environment
+	Scripts   ScriptExecutor
+	// Backing in excess of the supply tolerated before a warning is emitted.
+	// Excess arises from amounts truncated to UFix64 precision and from tokens
+	// sent to the bridge COA directly
+	Tolerance cadence.UFix64
+
+	coaAddress string
+}
+
+// Checks the invariant of every asset. Failed checks are reported as findings
+// with SeverityError, so one unavailable asset does not hide the others
+func (m *Monitor) Check(ctx context.Context, assets []Asset) []Finding {
+	findings := []Finding{}
+	for _, asset := range assets {
+		if finding := m.checkAsset(ctx, asset); finding != nil {
+			findings = append(findings, *finding)
+		}
+	}
+	return findings
+}
+
+// Checks the assets returned by assets immediately and then at every interval,
+// passing findings to emit, until the context is done
+func (m *Monitor) Run(ctx context.Context, interval time.Duration, assets func() []Asset, emit func(Finding)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, finding := range m.Check(ctx, assets()) {
+			emit(finding)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) checkAsset(ctx context.Context, asset Asset) *Finding {
+	invariant := InvariantCadenceNative
+	if m.BridgeEnv.IsBridgeDefinedType(asset.Type) {
+		invariant = InvariantEVMNative
+	}
+	finding := &Finding{
+		Time:               time.Now().UTC(),
+		Invariant:          invariant,
+		Type:               asset.Type,
+		EVMContractAddress: asset.EVMContractAddress,
+	}
+
+	var backing, supply *big.Int
+	var decimals uint8
+	var err error
+	if invariant == InvariantEVMNative {
+		backing, supply, decimals, err = m.evmNative(ctx, asset)
+	} else {
+		backing, supply, decimals, err = m.cadenceNative(ctx, asset)
+	}
+	if err != nil {
+		finding.Severity = SeverityError
+		finding.Message = err.Error()
+		return finding
+	}
+
+	finding.Backing = backing.String()
+	finding.Supply = supply.String()
+	excess := new(big.Int).Sub(backing, supply)
+	switch {
+	case excess.Sign() < 0:
+		finding.Severity = SeverityCritical
+		finding.Message = fmt.Sprintf("Supply exceeds backing by %s", new(big.Int).Neg(excess))
+	case excess.Cmp(bridge.ConvertCadenceAmountToERC20Amount(m.Tolerance, decimals)) > 0:
+		finding.Severity = SeverityWarning
+		finding.Message = fmt.Sprintf("Backing exceeds supply by %s", excess)
+	default:
+		return nil
+	}
+	return finding
+}
+
+// Returns the locked escrow balance and the supply of the bridged ERC20. The
+// escrow of a token is created when it is first bridged, so a missing escrow
+// locks nothing
+func (m *Monitor) cadenceNative(ctx context.Context, asset Asset) (*big.Int, *big.Int, uint8, error) {
+	decimals, err := m.decimals(ctx, asset.EVMContractAddress)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	value, err := m.execute(ctx, pathGetLockedTokenBalance, cadence.String(asset.Type))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	optional, ok := value.(cadence.Optional)
+	if !ok {
+		return nil, nil, 0, fmt.Errorf("Unexpected locked balance %s", value)
+	}
+	locked := cadence.UFix64(0)
+	if optional.Value != nil {
+		if locked, ok = optional.Value.(cadence.UFix64); !ok {
+			return nil, nil, 0, fmt.Errorf("Unexpected locked balance %s", value)
+		}
+	}
+	supply, err := m.uint256(ctx, pathERC20TotalSupply, cadence.String(asset.EVMContractAddress))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return bridge.ConvertCadenceAmountToERC20Amount(locked, decimals), supply, decimals, nil
+}
+
+// Returns the ERC20 balance of the bridge COA and the Cadence supply of the
+// bridge-defined Vault, converted to units of the ERC20
+func (m *Monitor) evmNative(ctx context.Context, asset Asset) (*big.Int, *big.Int, uint8, error) {
+	decimals, err := m.decimals(ctx, asset.EVMContractAddress)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	coa, err := m.bridgeCOAAddress(ctx)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	held, err := m.uint256(ctx, pathBalanceOf, cadence.String(coa), cadence.String(asset.EVMContractAddress))
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	parts := strings.Split(asset.Type, ".")
+	address, err := common.HexToAddress(parts[1])
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	value, err := m.execute(ctx, pathCadenceTotalSupply,
+		cadence.Address(address),
+		cadence.String(parts[2]),
+		cadence.String(asset.Type),
+	)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	optional, ok := value.(cadence.Optional)
+	if !ok || optional.Value == nil {
+		return nil, nil, 0, fmt.Errorf("No total supply for %s", asset.Type)
+	}
+	supply, ok := optional.Value.(cadence.UFix64)
+	if !ok {
+		return nil, nil, 0, fmt.Errorf("Unexpected total supply %s", value)
+	}
+	return held, bridge.ConvertCadenceAmountToERC20Amount(supply, decimals), decimals, nil
+}
+
+func (m *Monitor) bridgeCOAAddress(ctx context.Context) (string, error) {
+	if m.coaAddress != "" {
+		return m.coaAddress, nil
+	}
+	value, err := m.execute(ctx, pathGetBridgeCOAAddress)
+	if err != nil {
+		return "", err
+	}
+	address, ok := value.(cadence.String)
+	if !ok {
+		return "", fmt.Errorf("Unexpected bridge COA address %s", value)
+	}
+	m.coaAddress = string(address)
+	return m.coaAddress, nil
+}
+
+func (m *Monitor) decimals(ctx context.Context, evmAddress string) (uint8, error) {
+	value, err := m.execute(ctx, pathGetTokenDecimals, cadence.String(evmAddress))
+	if err != nil {
+		return 0, err
+	}
+	decimals, ok := value.(cadence.UInt8)
+	if !ok {
+		return 0, fmt.Errorf("Unexpected decimals %s", value)
+	}
+	return uint8(decimals), nil
+}
+
+func (m *Monitor) uint256(ctx context.Context, path string, arguments ...cadence.Value) (*big.Int, error) {
+	value, err := m.execute(ctx, path, arguments...)
+	if err != nil {
+		return nil, err
+	}
+	n, ok := value.(cadence.UInt256)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected result of %s: %s", path, value)
+	}
+	return n.Value, nil
+}
+
+func (m *Monitor) execute(ctx context.Context, path string, arguments ...cadence.Value) (cadence.Value, error) {
+	code, err := bridge.GetCadenceScriptCode(path, m.BridgeEnv, m.CoreEnv)
+	if err != nil {
+		return nil, err
+	}
+	value, err := m.Scripts.ExecuteScript(ctx, code, arguments)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", path, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,181 @@
+package monitor_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/index"
+	"github.com/onflow/flow-evm-bridge/monitor"
+)
+
+const (
+	exampleToken = "A.0ae53cb6e3f42a79.ExampleToken.Vault"
+	bridgedToken = "A.1e4aa0b87d10b141.EVMVMBridgedToken_0000000000000000000000000000000000000e20.Vault"
+	exampleERC20 = "0000000000000000000000000000000000000b20"
+	evmERC20     = "0000000000000000000000000000000000000e20"
+	coa          = "00000000000000000000000249250a5c27ecab3b"
+)
+
+// fakeChain answers the monitor scripts from in-memory state
+type fakeChain struct {
+	locked        map[string]cadence.UFix64
+	cadenceSupply map[string]cadence.UFix64
+	erc20Supply   map[string]*big.Int
+	coaBalance    map[string]*big.Int
+}
+
+func (c *fakeChain) ExecuteScript(_ context.Context, code []byte, arguments []cadence.Value) (cadence.Value, error) {
+	source := string(code)
+	argument := func(i int) string { return string(arguments[i].(cadence.String)) }
+	switch {
+	case strings.Contains(source, "getBridgeCOAEVMAddress"):
+		return cadence.String(coa), nil
+	case strings.Contains(source, "getTokenDecimals"):
+		if _, ok := c.erc20Supply[argument(0)]; !ok {
+			return nil, errors.New("call to decimals() reverted")
+		}
+		return cadence.UInt8(18), nil
+	case strings.Contains(source, "getLockedTokenBalance"):
+		locked, ok := c.locked[argument(0)]
+		if !ok {
+			return cadence.NewOptional(nil), nil
+		}
+		return cadence.NewOptional(locked), nil
+	case strings.Contains(source, "FlowEVMBridgeUtils.totalSupply"):
+		return cadence.NewUInt256FromBig(c.erc20Supply[argument(0)])
+	case strings.Contains(source, "FlowEVMBridgeUtils.balanceOf"):
+		if argument(0) != coa {
+			return nil, errors.New("unexpected owner")
+		}
+		return cadence.NewUInt256FromBig(c.coaBalance[argument(1)])
+	case strings.Contains(source, "FungibleTokenMetadataViews.TotalSupply"):
+		return cadence.NewOptional(c.cadenceSupply[argument(2)]), nil
+	}
+	return nil, errors.New("unexpected script")
+}
+
+func ufix64(t *testing.T, value string) cadence.UFix64 {
+	amount, err := cadence.NewUFix64(value)
+	require.Nil(t, err)
+	return amount
+}
+
+func wei(value string) *big.Int {
+	amount, _ := new(big.Int).SetString(value, 10)
+	return amount
+}
+
+func newMonitor(t *testing.T, chain *fakeChain) *monitor.Monitor {
+	env, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	return &monitor.Monitor{BridgeEnv: env, CoreEnv: coreEnv, Scripts: chain}
+}
+
+func newChain(t *testing.T) *fakeChain {
+	return &fakeChain{
+		locked:        map[string]cadence.UFix64{exampleToken: ufix64(t, "10.0")},
+		cadenceSupply: map[string]cadence.UFix64{bridgedToken: ufix64(t, "3.0")},
+		erc20Supply: map[string]*big.Int{
+			exampleERC20: wei("10000000000000000000"),
+			evmERC20:     wei("1000000000000000000000"),
+		},
+		coaBalance: map[string]*big.Int{evmERC20: wei("3000000000000000000")},
+	}
+}
+
+var assets = []monitor.Asset{
+	{Type: exampleToken, EVMContractAddress: exampleERC20},
+	{Type: bridgedToken, EVMContractAddress: evmERC20},
+}
+
+func TestCheckHolds(t *testing.T) {
+	findings := newMonitor(t, newChain(t)).Check(context.Background(), assets)
+	assert.Empty(t, findings)
+}
+
+func TestCheckWithoutEscrow(t *testing.T) {
+	chain := newChain(t)
+	// The escrow is created when a token is first bridged
+	delete(chain.locked, exampleToken)
+	chain.erc20Supply[exampleERC20] = big.NewInt(0)
+	m := newMonitor(t, chain)
+	assert.Empty(t, m.Check(context.Background(), assets))
+
+	chain.erc20Supply[exampleERC20] = big.NewInt(1)
+	findings := m.Check(context.Background(), assets)
+	require.Len(t, findings, 1)
+	assert.Equal(t, monitor.SeverityCritical, findings[0].Severity)
+	assert.Equal(t, "0", findings[0].Backing)
+}
+
+func TestCheckViolations(t *testing.T) {
+	chain := newChain(t)
+	// More ERC20 minted than locked in escrow
+	chain.erc20Supply[exampleERC20] = wei("10000000000000000001")
+	// More held by the COA than minted in Cadence, beyond the tolerance
+	chain.coaBalance[evmERC20] = wei("3000100000000000000")
+	m := newMonitor(t, chain)
+	m.Tolerance = ufix64(t, "0.00001")
+
+	findings := m.Check(context.Background(), append(assets, monitor.Asset{
+		Type:               "A.0ae53cb6e3f42a79.OtherToken.Vault",
+		EVMContractAddress: "0000000000000000000000000000000000000bad",
+	}))
+	require.Len(t, findings, 3)
+
+	assert.Equal(t, monitor.SeverityCritical, findings[0].Severity)
+	assert.Equal(t, monitor.InvariantCadenceNative, findings[0].Invariant)
+	assert.Equal(t, "10000000000000000000", findings[0].Backing)
+	assert.Equal(t, "10000000000000000001", findings[0].Supply)
+
+	assert.Equal(t, monitor.SeverityWarning, findings[1].Severity)
+	assert.Equal(t, monitor.InvariantEVMNative, findings[1].Invariant)
+
+	assert.Equal(t, monitor.SeverityError, findings[2].Severity)
+	assert.Contains(t, findings[2].Message, "reverted")
+}
+
+func TestAssetsFromIndex(t *testing.T) {
+	ix := index.New()
+	for i, event := range []events.Event{
+		events.AssociationUpdated{Type: exampleToken, EVMAddress: exampleERC20},
+		events.AssociationUpdated{Type: "A.0ae53cb6e3f42a79.ExampleNFT.NFT", EVMAddress: "0000000000000000000000000000000000000721"},
+		events.AssociationUpdated{Type: bridgedToken, EVMAddress: evmERC20},
+		// Tokens with a handler have no escrow
+		events.AssociationUpdated{Type: "A.0ae53cb6e3f42a79.USDC.Vault", EVMAddress: "0000000000000000000000000000000000000c20"},
+		events.HandlerConfigured{TargetType: "A.0ae53cb6e3f42a79.USDC.Vault", IsEnabled: true},
+	} {
+		require.Nil(t, ix.Apply(events.Record{Event: event, BlockHeight: uint64(i + 1)}))
+	}
+	assert.Equal(t, []monitor.Asset{
+		{Type: exampleToken, EVMContractAddress: exampleERC20},
+		{Type: bridgedToken, EVMContractAddress: evmERC20},
+	}, monitor.AssetsFromIndex(ix))
+}
+
+func TestRun(t *testing.T) {
+	chain := newChain(t)
+	chain.erc20Supply[exampleERC20] = wei("10000000000000000001")
+	m := newMonitor(t, chain)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	findings := []monitor.Finding{}
+	err := m.Run(ctx, time.Millisecond, func() []monitor.Asset { return assets }, func(finding monitor.Finding) {
+		findings = append(findings, finding)
+		if len(findings) == 2 {
+			cancel()
+		}
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, findings, 2)
+}
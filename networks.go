@@ -0,0 +1,180 @@
+package bridge
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	coreContracts "github.com/onflow/flow-core-contracts/lib/go/templates"
+)
+
+//go:embed flow.json
+var flowJSON []byte
+
+const (
+	NetworkEmulator = "emulator"
+	NetworkTestnet  = "testnet"
+	NetworkMainnet  = "mainnet"
+)
+
+type flowJSONContract struct {
+	Aliases map[string]string `json:"aliases"`
+}
+
+type flowJSONConfig struct {
+	Contracts    map[string]json.RawMessage  `json:"contracts"`
+	Dependencies map[string]flowJSONContract `json:"dependencies"`
+}
+
+// Maps every bridge contract name to its address field in the Environment
+func bridgeAddressFields(env *Environment) map[string]*string {
+	return map[string]*string{
+		"CrossVMNFT":                          &env.CrossVMNFTAddress,
+		"CrossVMToken":                        &env.CrossVMTokenAddress,
+		"FlowEVMBridgeHandlerInterfaces":      &env.FlowEVMBridgeHandlerInterfacesAddress,
+		"IBridgePermissions":                  &env.IBridgePermissionsAddress,
+		"ICrossVM":                            &env.ICrossVMAddress,
+		"ICrossVMAsset":                       &env.ICrossVMAssetAddress,
+		"IEVMBridgeNFTMinter":                 &env.IEVMBridgeNFTMinterAddress,
+		"IEVMBridgeTokenMinter":               &env.IEVMBridgeTokenMinterAddress,
+		"IFlowEVMNFTBridge":                   &env.IFlowEVMNFTBridgeAddress,
+		"IFlowEVMTokenBridge":                 &env.IFlowEVMTokenBridgeAddress,
+		"FlowEVMBridge":                       &env.FlowEVMBridgeAddress,
+		"FlowEVMBridgeAccessor":               &env.FlowEVMBridgeAccessorAddress,
+		"FlowEVMBridgeConfig":                 &env.FlowEVMBridgeConfigAddress,
+		"FlowEVMBridgeHandlers":               &env.FlowEVMBridgeHandlersAddress,
+		"FlowEVMBridgeNFTEscrow":              &env.FlowEVMBridgeNFTEscrowAddress,
+		"FlowEVMBridgeResolver":               &env.FlowEVMBridgeResolverAddress,
+		"FlowEVMBridgeTemplates":              &env.FlowEVMBridgeTemplatesAddress,
+		"FlowEVMBridgeTokenEscrow":            &env.FlowEVMBridgeTokenEscrowAddress,
+		"FlowEVMBridgeUtils":                  &env.FlowEVMBridgeUtilsAddress,
+		"FlowEVMBridgeCustomAssociationTypes": &env.FlowEVMBridgeCustomAssociationTypesAddress,
+		"FlowEVMBridgeCustomAssociations":     &env.FlowEVMBridgeCustomAssociationsAddress,
+		"ArrayUtils":                          &env.ArrayUtilsAddress,
+		"ScopedFTProviders":                   &env.ScopedFTProvidersAddress,
+		"Serialize":                           &env.SerializeAddress,
+		"SerializeMetadata":                   &env.SerializeMetadataAddress,
+		"StringUtils":                         &env.StringUtilsAddress,
+	}
+}
+
+// Maps every core contract imported by the bridge to its address field in the core Environment
+func coreAddressFields(env *coreContracts.Environment) map[string]*string {
+	return map[string]*string{
+		"Burner":                     &env.BurnerAddress,
+		"CrossVMMetadataViews":       &env.CrossVMMetadataViewsAddress,
+		"EVM":                        &env.EVMAddress,
+		"FlowStorageFees":            &env.StorageFeesAddress,
+		"FlowToken":                  &env.FlowTokenAddress,
+		"FungibleToken":              &env.FungibleTokenAddress,
+		"FungibleTokenMetadataViews": &env.FungibleTokenMetadataViewsAddress,
+		"MetadataViews":              &env.MetadataViewsAddress,
+		"NonFungibleToken":           &env.NonFungibleTokenAddress,
+		"ViewResolver":               &env.ViewResolverAddress,
+	}
+}
+
+// Returns the address of a bridge contract in the Environment,
+// or an empty string if the contract is unknown or unset
+func (env Environment) ContractAddress(name string) string {
+	field, ok := bridgeAddressFields(&env)[name]
+	if !ok {
+		return ""
+	}
+	return *field
+}
+
+// Prefixes of the names of the contracts the bridge deploys to its own
+// account to define the Cadence side of EVM-native assets
+const (
+	BridgedNFTContractPrefix   = "EVMVMBridgedNFT_"
+	BridgedTokenContractPrefix = "EVMVMBridgedToken_"
+)
+
+// Returns whether a Cadence type identifier, such as
+// A.1e4aa0b87d10b141.EVMVMBridgedToken_<address>.Vault, is defined by the
+// bridge in the Environment for an EVM-native asset
+func (env Environment) IsBridgeDefinedType(typeIdentifier string) bool {
+	parts := strings.Split(typeIdentifier, ".")
+	if len(parts) < 3 || parts[0] != "A" {
+		return false
+	}
+	return parts[1] == strings.TrimPrefix(env.FlowEVMBridgeAddress, "0x") &&
+		(strings.HasPrefix(parts[2], BridgedNFTContractPrefix) || strings.HasPrefix(parts[2], BridgedTokenContractPrefix))
+}
+
+// Returns the identifier of the Cadence type the bridge defines in the
+// Environment with the named contract, as deployed when an EVM-native
+// ERC721 or ERC20 is onboarded
+func (env Environment) BridgeDefinedType(contractName string, isERC721 bool) string {
+	resource := "Vault"
+	if isERC721 {
+		resource = "NFT"
+	}
+	return "A." + strings.TrimPrefix(env.FlowEVMBridgeAddress, "0x") + "." + contractName + "." + resource
+}
+
+// Native VM of a custom cross-VM association
+const (
+	NativeVMCadence = "cadence"
+	NativeVMEVM     = "evm"
+)
+
+// Returns the native VM, NativeVMCadence or NativeVMEVM, of the
+// nativeVMRawValue of a CustomAssociationEstablished event
+func NativeVM(rawValue uint8) (string, error) {
+	switch rawValue {
+	case 0:
+		return NativeVMCadence, nil
+	case 1:
+		return NativeVMEVM, nil
+	}
+	return "", fmt.Errorf("Unknown native VM raw value %d", rawValue)
+}
+
+// Returns the EVM address in lower case without 0x prefix, as bridge events
+// and scripts report it
+func NormalizeEVMAddress(address string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(address, "0x"), "0X"))
+}
+
+// Gets the bridge and core contract Environments of a network
+// using the contract aliases declared in flow.json
+func NetworkEnvironment(network string) (Environment, coreContracts.Environment, error) {
+	bridgeEnv := Environment{}
+	coreEnv := coreContracts.Environment{Network: network}
+
+	var config flowJSONConfig
+	if err := json.Unmarshal(flowJSON, &config); err != nil {
+		return bridgeEnv, coreEnv, err
+	}
+
+	found := false
+	for name, field := range bridgeAddressFields(&bridgeEnv) {
+		raw, ok := config.Contracts[name]
+		if !ok {
+			continue
+		}
+		var contract flowJSONContract
+		if err := json.Unmarshal(raw, &contract); err != nil {
+			return bridgeEnv, coreEnv, err
+		}
+		if address, ok := contract.Aliases[network]; ok {
+			*field = withHexPrefix(address)
+			found = true
+		}
+	}
+	for name, field := range coreAddressFields(&coreEnv) {
+		if address, ok := config.Dependencies[name].Aliases[network]; ok {
+			*field = withHexPrefix(address)
+		}
+	}
+
+	if !found {
+		return bridgeEnv, coreEnv, errors.New("No bridge contract addresses for network " + network)
+	}
+
+	return bridgeEnv, coreEnv, nil
+}
@@ -0,0 +1,60 @@
+package bridge_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+)
+
+func TestNetworkEnvironment(t *testing.T) {
+	bridgeEnv, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+
+	assert.Equal(t, "0x1e4aa0b87d10b141", bridgeEnv.FlowEVMBridgeAddress)
+	assert.Equal(t, "0x1e4aa0b87d10b141", bridgeEnv.StringUtilsAddress)
+	assert.Equal(t, "0x1e4aa0b87d10b141", bridgeEnv.ContractAddress("FlowEVMBridgeConfig"))
+	assert.Equal(t, "", bridgeEnv.ContractAddress("CryptoPunks"))
+	assert.Equal(t, "0xe467b9dd11fa00df", coreEnv.EVMAddress)
+	assert.Equal(t, "0xe467b9dd11fa00df", coreEnv.StorageFeesAddress)
+	assert.Equal(t, "0x1654653399040a61", coreEnv.FlowTokenAddress)
+	assert.Equal(t, bridge.NetworkMainnet, coreEnv.Network)
+
+	// Every import of every contract should be resolved
+	graph, err := bridge.DependencyGraph()
+	require.Nil(t, err)
+	for _, name := range graph.Contracts() {
+		GetCadenceContractShouldSucceed(t, graph.Path(name), bridgeEnv, coreEnv)
+	}
+
+	_, _, err = bridge.NetworkEnvironment("previewnet")
+	assert.NotNil(t, err)
+}
+
+func TestIsBridgeDefinedType(t *testing.T) {
+	bridgeEnv, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+
+	assert.True(t, bridgeEnv.IsBridgeDefinedType("A.1e4aa0b87d10b141.EVMVMBridgedToken_f1815bd50389c46847f0bda824ec8da914045d14.Vault"))
+	assert.True(t, bridgeEnv.IsBridgeDefinedType("A.1e4aa0b87d10b141.EVMVMBridgedNFT_2b7cfe0f24c18690a4e34a154e313859a7c6e718.NFT"))
+	assert.False(t, bridgeEnv.IsBridgeDefinedType("A.1654653399040a61.FlowToken.Vault"))
+	assert.False(t, bridgeEnv.IsBridgeDefinedType("A.0ae53cb6e3f42a79.EVMVMBridgedNFT_2b7cfe0f24c18690a4e34a154e313859a7c6e718.NFT"))
+
+	assert.Equal(t, "A.1e4aa0b87d10b141.EVMVMBridgedNFT_2b7cfe0f24c18690a4e34a154e313859a7c6e718.NFT",
+		bridgeEnv.BridgeDefinedType("EVMVMBridgedNFT_2b7cfe0f24c18690a4e34a154e313859a7c6e718", true))
+	assert.Equal(t, "A.1e4aa0b87d10b141.EVMVMBridgedToken_f1815bd50389c46847f0bda824ec8da914045d14.Vault",
+		bridgeEnv.BridgeDefinedType("EVMVMBridgedToken_f1815bd50389c46847f0bda824ec8da914045d14", false))
+}
+
+func TestNormalizeEVMAddress(t *testing.T) {
+	assert.Equal(t, "f1815bd50389c46847f0bda824ec8da914045d14", bridge.NormalizeEVMAddress("0xF1815bd50389c46847f0bda824ec8da914045D14"))
+	assert.Equal(t, "ab", bridge.NormalizeEVMAddress("0XAB"))
+
+	nativeVM, err := bridge.NativeVM(1)
+	require.Nil(t, err)
+	assert.Equal(t, bridge.NativeVMEVM, nativeVM)
+	_, err = bridge.NativeVM(2)
+	assert.ErrorContains(t, err, "Unknown native VM raw value 2")
+}
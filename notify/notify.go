@@ -0,0 +1,157 @@
+// Package notify delivers webhooks to on-call tooling when administrative
+// bridge events occur: pauses, token handler and template changes, and
+// deployer changes of the FlowBridgeFactory EVM contract.
+//
+// Cadence events are read through an events.Consumer, so any
+// events.EventSource works, such as an access node client or fixtures loaded
+// with events.ReadJSONLSource. Factory events are EVM logs, read from the
+// EVM.TransactionExecuted events passed to HandleFlowEvents.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/go-ethereum/common"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/evmlogs"
+)
+
+// Qualified identifiers of the Cadence events notified
+var EventTypes = []string{
+	events.TypeBridgePauseStatusUpdated,
+	events.TypeAssetPauseStatusUpdated,
+	events.TypeHandlerConfigured,
+	events.TypeHandlerEnabled,
+	events.TypeHandlerDisabled,
+	events.TypeMinterSet,
+	events.TypeTemplateUpdated,
+}
+
+// Returns the fully qualified types of the Cadence events notified in the
+// Environment
+func EventTypeIDs(env bridge.Environment) []string {
+	ids := make([]string, len(EventTypes))
+	for i, eventType := range EventTypes {
+		ids[i] = events.TypeID(env, eventType)
+	}
+	return ids
+}
+
+// Notification is the JSON body of a webhook
+type Notification struct {
+	// Unique per event, for receivers to de-duplicate retried deliveries
+	ID string `json:"id"`
+	// Qualified Cadence event identifier, or the Solidity event name for EVM logs
+	Event string `json:"event"`
+	// Fully qualified Cadence event type, or the address of the emitting EVM contract
+	Source         string          `json:"source"`
+	BlockHeight    uint64          `json:"blockHeight"`
+	BlockTimestamp *time.Time      `json:"blockTimestamp,omitempty"`
+	TransactionID  string          `json:"transactionId"`
+	EventIndex     int             `json:"eventIndex"`
+	Data           json.RawMessage `json:"data"`
+}
+
+// Notifier turns administrative events into webhook notifications
+type Notifier struct {
+	Webhook *Webhook
+	// Address of the FlowBridgeFactory contract. Deployer logs of other
+	// contracts are ignored, as are all logs if the address is zero
+	FactoryAddress common.Address
+}
+
+// Returns a consumer of the notified events of the Environment, which
+// delivers a notification for every event
+func (n *Notifier) Consumer(source events.EventSource, env bridge.Environment, checkpoints events.CheckpointStore, startHeight uint64) *events.Consumer {
+	return events.ConsumerFor(n, EventTypeIDs(env), source, checkpoints, startHeight)
+}
+
+// Delivers a notification if the record is a notified event
+func (n *Notifier) Handle(ctx context.Context, record events.Record) error {
+	if !notified(record.Event.EventType()) {
+		return nil
+	}
+	data, err := json.Marshal(record.Event)
+	if err != nil {
+		return err
+	}
+	return n.Webhook.Deliver(ctx, Notification{
+		ID:             fmt.Sprintf("%s-%d", record.TransactionID, record.EventIndex),
+		Event:          record.Event.EventType(),
+		Source:         record.TypeID,
+		BlockHeight:    record.BlockHeight,
+		BlockTimestamp: timestamp(record.BlockTimestamp),
+		TransactionID:  record.TransactionID.String(),
+		EventIndex:     record.EventIndex,
+		Data:           data,
+	})
+}
+
+// Delivers a notification for every deployer change of the factory in the
+// EVM.TransactionExecuted events of a block. Other events are skipped
+func (n *Notifier) HandleFlowEvents(ctx context.Context, block flow.BlockEvents) error {
+	if n.FactoryAddress == (common.Address{}) {
+		return nil
+	}
+	transactions, err := evmlogs.TransactionLogsFromEvents(block.Events)
+	if err != nil {
+		return err
+	}
+	for _, transaction := range transactions {
+		for i, l := range transaction.Logs {
+			if l.Address != n.FactoryAddress || len(l.Topics) == 0 {
+				continue
+			}
+			switch l.Topics[0] {
+			case evmlogs.TopicDeployerAdded, evmlogs.TopicDeployerUpdated, evmlogs.TopicDeployerRemoved, evmlogs.TopicDeploymentRegistryUpdated:
+			default:
+				continue
+			}
+			decoded, err := evmlogs.Decode(l)
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(decoded)
+			if err != nil {
+				return err
+			}
+			err = n.Webhook.Deliver(ctx, Notification{
+				ID:             fmt.Sprintf("%s-%d", transaction.Hash, i),
+				Event:          decoded.EventName(),
+				Source:         l.Address.Hex(),
+				BlockHeight:    block.Height,
+				BlockTimestamp: timestamp(block.BlockTimestamp),
+				TransactionID:  transaction.FlowTransactionID.String(),
+				EventIndex:     transaction.EventIndex,
+				Data:           data,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func notified(eventType string) bool {
+	for _, notifiedType := range EventTypes {
+		if eventType == notifiedType {
+			return true
+		}
+	}
+	return false
+}
+
+func timestamp(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
@@ -0,0 +1,183 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/onflow/cadence"
+	cadenceCommon "github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/go-ethereum/common"
+	"github.com/onflow/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/evmlogs"
+	"github.com/onflow/flow-evm-bridge/notify"
+)
+
+var secret = []byte("secret")
+
+// receiver is a local webhook endpoint answering with the given statuses in
+// turn, then 200
+type receiver struct {
+	mu            sync.Mutex
+	statuses      []int
+	notifications []notify.Notification
+	invalid       int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	body, _ := io.ReadAll(request.Body)
+	if !notify.Verify(secret, request.Header.Get(notify.HeaderTimestamp), body, request.Header.Get(notify.HeaderSignature)) {
+		r.invalid++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+	}
+	var notification notify.Notification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.notifications = append(r.notifications, notification)
+}
+
+func newWebhook(t *testing.T, r *receiver) *notify.Webhook {
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return &notify.Webhook{
+		URL:            server.URL,
+		Secret:         secret,
+		MaxAttempts:    3,
+		Backoff:        1,
+		DeadLetterPath: filepath.Join(t.TempDir(), "dead-letter.jsonl"),
+	}
+}
+
+func pauseEvent(t *testing.T) cadence.Event {
+	address, err := cadenceCommon.HexToAddress("1e4aa0b87d10b141")
+	require.Nil(t, err)
+	location := cadenceCommon.NewAddressLocation(nil, address, "FlowEVMBridgeConfig")
+	eventType := cadence.NewEventType(location, events.TypeBridgePauseStatusUpdated, []cadence.Field{
+		{Identifier: "paused", Type: cadence.BoolType},
+	}, nil)
+	return cadence.NewEvent([]cadence.Value{cadence.Bool(true)}).WithType(eventType)
+}
+
+func TestNotifierConsumer(t *testing.T) {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	r := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	notifier := &notify.Notifier{Webhook: newWebhook(t, r)}
+
+	source := events.NewMemorySource()
+	event := pauseEvent(t)
+	source.Add(10, flow.Event{Type: event.EventType.ID(), TransactionID: flow.Identifier{1}, Value: event})
+
+	_, err = notifier.Consumer(source, env, &events.MemoryCheckpointStore{}, 1).Sync(context.Background())
+	require.Nil(t, err)
+
+	// Delivered after one retry
+	require.Len(t, r.notifications, 1)
+	assert.Zero(t, r.invalid)
+	notification := r.notifications[0]
+	assert.Equal(t, events.TypeBridgePauseStatusUpdated, notification.Event)
+	assert.Equal(t, "A.1e4aa0b87d10b141.FlowEVMBridgeConfig.BridgePauseStatusUpdated", notification.Source)
+	assert.Equal(t, uint64(10), notification.BlockHeight)
+	assert.JSONEq(t, `{"Paused": true}`, string(notification.Data))
+}
+
+func TestNotifierIgnoresOtherEvents(t *testing.T) {
+	r := &receiver{}
+	notifier := &notify.Notifier{Webhook: newWebhook(t, r)}
+	require.Nil(t, notifier.Handle(context.Background(), events.Record{Event: events.Onboarded{Type: "A.0ae53cb6e3f42a79.ExampleNFT.NFT"}}))
+	assert.Empty(t, r.notifications)
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	r := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError}}
+	webhook := newWebhook(t, r)
+	notification := notify.Notification{ID: "1", Event: events.TypeMinterSet, Data: json.RawMessage(`{}`)}
+
+	require.Nil(t, webhook.Deliver(context.Background(), notification))
+	assert.Empty(t, r.notifications)
+
+	data, err := os.ReadFile(webhook.DeadLetterPath)
+	require.Nil(t, err)
+	var letter notify.DeadLetter
+	require.Nil(t, json.Unmarshal(data, &letter))
+	assert.Equal(t, "1", letter.Notification.ID)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Contains(t, letter.Error, "500")
+
+	// Client errors are not retried
+	r.statuses = []int{http.StatusBadRequest}
+	require.Nil(t, webhook.Deliver(context.Background(), notification))
+	data, err = os.ReadFile(webhook.DeadLetterPath)
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	require.Nil(t, json.Unmarshal([]byte(lines[1]), &letter))
+	assert.Equal(t, 1, letter.Attempts)
+}
+
+func bytesArray(b []byte) cadence.Array {
+	values := make([]cadence.Value, len(b))
+	for i := range b {
+		values[i] = cadence.UInt8(b[i])
+	}
+	return cadence.NewArray(values).WithType(cadence.NewVariableSizedArrayType(cadence.UInt8Type))
+}
+
+func TestNotifierFactoryLogs(t *testing.T) {
+	factory := common.HexToAddress("0000000000000000000000000000000000000fac")
+	deployer := common.HexToAddress("0000000000000000000000000000000000000de1")
+	deployerUpdated := evmlogs.Log{
+		Address: factory,
+		Topics:  []common.Hash{evmlogs.TopicDeployerUpdated, evmlogs.DeployerTag("ERC721")},
+		Data:    append(common.LeftPadBytes(deployer.Bytes(), 32), common.LeftPadBytes(deployer.Bytes(), 32)...),
+	}
+	// Same event emitted by another contract
+	spoofed := deployerUpdated
+	spoofed.Address = common.HexToAddress("0000000000000000000000000000000000000bad")
+	encoded, err := rlp.EncodeToBytes([]evmlogs.Log{deployerUpdated, spoofed})
+	require.Nil(t, err)
+
+	eventType := cadence.NewEventType(nil, "EVM.TransactionExecuted", []cadence.Field{
+		{Identifier: "hash", Type: cadence.NewConstantSizedArrayType(32, cadence.UInt8Type)},
+		{Identifier: "logs", Type: cadence.NewVariableSizedArrayType(cadence.UInt8Type)},
+	}, nil)
+	value := cadence.NewEvent([]cadence.Value{bytesArray(common.HexToHash("0xabcd").Bytes()), bytesArray(encoded)}).WithType(eventType)
+
+	r := &receiver{}
+	notifier := &notify.Notifier{Webhook: newWebhook(t, r), FactoryAddress: factory}
+	require.Nil(t, notifier.HandleFlowEvents(context.Background(), flow.BlockEvents{
+		Height: 20,
+		Events: []flow.Event{{Type: "A.e467b9dd11fa00df.EVM.TransactionExecuted", TransactionID: flow.Identifier{2}, Value: value}},
+	}))
+
+	require.Len(t, r.notifications, 1)
+	assert.Equal(t, "DeployerUpdated", r.notifications[0].Event)
+	assert.Equal(t, factory.Hex(), r.notifications[0].Source)
+	assert.Equal(t, uint64(20), r.notifications[0].BlockHeight)
+}
@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Headers of a webhook request
+const (
+	HeaderSignature = "X-Bridge-Signature"
+	HeaderTimestamp = "X-Bridge-Timestamp"
+)
+
+// Defaults of a Webhook
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = time.Second
+)
+
+// Webhook delivers notifications as signed JSON POST requests. A request is
+// signed with HMAC-SHA256 over "<timestamp>.<body>" using Secret; the hex
+// encoded signature is sent in HeaderSignature and the Unix timestamp in
+// HeaderTimestamp, see Verify
+type Webhook struct {
+	URL    string
+	Secret []byte
+	Client *http.Client
+	// Attempts before a notification is dead-lettered
+	MaxAttempts int
+	// Delay before the first retry, doubled after every attempt
+	Backoff time.Duration
+	// JSONL file notifications that could not be delivered are appended to
+	DeadLetterPath string
+}
+
+// DeadLetter is a line of the dead-letter file
+type DeadLetter struct {
+	Notification Notification `json:"notification"`
+	Error        string       `json:"error"`
+	Attempts     int          `json:"attempts"`
+	FailedAt     time.Time    `json:"failedAt"`
+}
+
+// Delivers a notification, retrying on network errors, 429 and 5xx
+// responses. A notification that cannot be delivered is appended to the
+// dead-letter file and nil is returned, so one failing notification does not
+// block the following ones; an error is returned only if the notification
+// was neither delivered nor dead-lettered
+func (w *Webhook) Deliver(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	attempts := w.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultMaxAttempts
+	}
+	backoff := w.Backoff
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= attempts {
+			return w.deadLetter(DeadLetter{
+				Notification: notification,
+				Error:        err.Error(),
+				Attempts:     attempt,
+				FailedAt:     time.Now().UTC(),
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Sends one request and returns whether a failure may be retried
+func (w *Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderTimestamp, timestamp)
+	request.Header.Set(HeaderSignature, Sign(w.Secret, timestamp, body))
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	retry := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	return retry, fmt.Errorf("Webhook responded with %s", response.Status)
+}
+
+func (w *Webhook) deadLetter(letter DeadLetter) error {
+	if w.DeadLetterPath == "" {
+		return fmt.Errorf("Cannot deliver notification %s: %s", letter.Notification.ID, letter.Error)
+	}
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.DeadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Returns the hex encoded signature of a request body sent at timestamp
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Returns whether signature is a valid signature of a request body sent at
+// timestamp, for use by receivers
+func Verify(secret []byte, timestamp string, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(Sign(secret, timestamp, body))
+	if err != nil {
+		return false
+	}
+	actual, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, actual)
+}
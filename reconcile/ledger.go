@@ -0,0 +1,202 @@
+// Package reconcile checks that the bridge is fully collateralised.
+//
+// Replaying bridge events yields the escrow state the bridge should be in:
+// every Cadence-native token bridged to EVM is locked in
+// FlowEVMBridgeTokenEscrow and backs the supply of its bridged ERC20, and every
+// Cadence-native NFT bridged to EVM is locked in FlowEVMBridgeNFTEscrow and
+// backs a token of its bridged ERC721. Reconcile compares that expected state
+// with the state reported on chain.
+package reconcile
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/onflow/cadence"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+)
+
+// Returns the fully qualified types of the events a Ledger consumes
+func EventTypes(env bridge.Environment) []string {
+	return []string{
+		events.TypeID(env, events.TypeBridgedTokensToEVM),
+		events.TypeID(env, events.TypeBridgedTokensFromEVM),
+		events.TypeID(env, events.TypeBridgedNFTToEVM),
+		events.TypeID(env, events.TypeBridgedNFTFromEVM),
+		events.TypeID(env, events.TypeHandlerConfigured),
+		events.TypeID(env, events.TypeCustomAssociationEstablished),
+	}
+}
+
+// TokenEscrow is the expected escrow of a Cadence-native fungible token
+type TokenEscrow struct {
+	Type               string
+	EVMContractAddress string
+	// Total amount bridged to EVM
+	ToEVM cadence.UFix64
+	// Total amount bridged back from EVM, in units of the bridged ERC20
+	FromEVM *big.Int
+
+	// Every amount bridged back from EVM. The bridge converts each to UFix64
+	// separately, truncating each amount beyond 8 decimals
+	fromEVMAmounts []*big.Int
+}
+
+// NFTEscrow is the expected escrow of a Cadence-native NFT collection
+type NFTEscrow struct {
+	Type               string
+	EVMContractAddress string
+	// Whether each NFT ever bridged to EVM is currently locked, by Cadence ID
+	Locked map[uint64]bool
+}
+
+// Returns the IDs of the NFTs expected to be locked in escrow, sorted
+func (e *NFTEscrow) LockedIDs() []uint64 {
+	ids := []uint64{}
+	for id, locked := range e.Locked {
+		if locked {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Ledger is the escrow state expected from replaying bridge events. Only
+// Cadence-native assets onboarded permissionlessly are tracked: assets moved by
+// a token handler or registered as custom cross-VM assets are not escrowed this
+// way and are reported as skipped
+type Ledger struct {
+	env        bridge.Environment
+	tokens     map[string]*TokenEscrow
+	nfts       map[string]*NFTEscrow
+	skipped    map[string]bool
+	lastHeight uint64
+}
+
+// Returns an empty ledger for the bridge deployed in the given Environment
+func NewLedger(env bridge.Environment) *Ledger {
+	return &Ledger{
+		env:     env,
+		tokens:  map[string]*TokenEscrow{},
+		nfts:    map[string]*NFTEscrow{},
+		skipped: map[string]bool{},
+	}
+}
+
+// Applies a bridge event to the ledger. Events must be applied in block order
+func (l *Ledger) Apply(record events.Record) {
+	if record.BlockHeight > l.lastHeight {
+		l.lastHeight = record.BlockHeight
+	}
+
+	switch event := record.Event.(type) {
+	case events.HandlerConfigured:
+		l.skip(event.TargetType)
+	case events.CustomAssociationEstablished:
+		l.skip(event.Type)
+	case events.BridgedTokensToEVM:
+		if escrow := l.token(event.Type, event.EVMContractAddress); escrow != nil {
+			escrow.ToEVM += event.Amount
+		}
+	case events.BridgedTokensFromEVM:
+		if escrow := l.token(event.Type, event.EVMContractAddress); escrow != nil {
+			escrow.FromEVM.Add(escrow.FromEVM, event.Amount)
+			escrow.fromEVMAmounts = append(escrow.fromEVMAmounts, event.Amount)
+		}
+	case events.BridgedNFTToEVM:
+		if escrow := l.nft(event.Type, event.EVMContractAddress); escrow != nil {
+			escrow.Locked[event.ID] = true
+		}
+	case events.BridgedNFTFromEVM:
+		if escrow := l.nft(event.Type, event.EVMContractAddress); escrow != nil {
+			escrow.Locked[event.ID] = false
+		}
+	}
+}
+
+// Returns a consumer of the events of the ledger in the Environment, which
+// applies every event to the ledger
+func (l *Ledger) Consumer(source events.EventSource, env bridge.Environment, checkpoints events.CheckpointStore, startHeight uint64) *events.Consumer {
+	return events.ConsumerFor(l, EventTypes(env), source, checkpoints, startHeight)
+}
+
+// Applies a record passed by an events.Consumer
+func (l *Ledger) Handle(_ context.Context, record events.Record) error {
+	l.Apply(record)
+	return nil
+}
+
+// Returns the height of the last applied event
+func (l *Ledger) Height() uint64 {
+	return l.lastHeight
+}
+
+// Returns the expected token escrows sorted by type
+func (l *Ledger) Tokens() []*TokenEscrow {
+	tokens := make([]*TokenEscrow, 0, len(l.tokens))
+	for _, escrow := range l.tokens {
+		if !l.skipped[escrow.Type] {
+			tokens = append(tokens, escrow)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Type < tokens[j].Type })
+	return tokens
+}
+
+// Returns the expected NFT escrows sorted by type
+func (l *Ledger) NFTs() []*NFTEscrow {
+	nfts := make([]*NFTEscrow, 0, len(l.nfts))
+	for _, escrow := range l.nfts {
+		if !l.skipped[escrow.Type] {
+			nfts = append(nfts, escrow)
+		}
+	}
+	sort.Slice(nfts, func(i, j int) bool { return nfts[i].Type < nfts[j].Type })
+	return nfts
+}
+
+// Returns the types that were bridged but are not escrowed by the bridge, sorted
+func (l *Ledger) Skipped() []string {
+	skipped := []string{}
+	for cadenceType := range l.skipped {
+		_, token := l.tokens[cadenceType]
+		_, nft := l.nfts[cadenceType]
+		if token || nft {
+			skipped = append(skipped, cadenceType)
+		}
+	}
+	sort.Strings(skipped)
+	return skipped
+}
+
+func (l *Ledger) skip(cadenceType string) {
+	l.skipped[cadenceType] = true
+}
+
+func (l *Ledger) token(cadenceType, evmAddress string) *TokenEscrow {
+	if l.env.IsBridgeDefinedType(cadenceType) {
+		return nil
+	}
+	escrow, ok := l.tokens[cadenceType]
+	if !ok {
+		escrow = &TokenEscrow{Type: cadenceType, EVMContractAddress: evmAddress, FromEVM: new(big.Int)}
+		l.tokens[cadenceType] = escrow
+	}
+	return escrow
+}
+
+func (l *Ledger) nft(cadenceType, evmAddress string) *NFTEscrow {
+	if l.env.IsBridgeDefinedType(cadenceType) {
+		return nil
+	}
+	escrow, ok := l.nfts[cadenceType]
+	if !ok {
+		escrow = &NFTEscrow{Type: cadenceType, EVMContractAddress: evmAddress, Locked: map[uint64]bool{}}
+		l.nfts[cadenceType] = escrow
+	}
+	return escrow
+}
@@ -0,0 +1,253 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/onflow/cadence"
+	coreContracts "github.com/onflow/flow-core-contracts/lib/go/templates"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+)
+
+const (
+	pathGetLockedTokenBalance = "cadence/scripts/escrow/get_locked_token_balance.cdc"
+	pathIsNFTLocked           = "cadence/scripts/escrow/is_nft_locked.cdc"
+	pathGetTokenDecimals      = "cadence/scripts/utils/get_token_decimals.cdc"
+	pathTotalSupply           = "cadence/scripts/utils/total_supply.cdc"
+)
+
+// ScriptExecutor executes a script and returns its result
+type ScriptExecutor interface {
+	ExecuteScript(ctx context.Context, code []byte, arguments []cadence.Value) (cadence.Value, error)
+}
+
+type DiscrepancyKind string
+
+const (
+	// The token balance locked in FlowEVMBridgeTokenEscrow differs from the
+	// amount bridged to EVM and not bridged back
+	DiscrepancyTokenEscrow DiscrepancyKind = "token-escrow"
+	// The supply of the bridged ERC20 differs from the amount bridged to EVM
+	// and not bridged back
+	DiscrepancyERC20Supply DiscrepancyKind = "erc20-supply"
+	// An NFT is locked in FlowEVMBridgeNFTEscrow while it should not be, or
+	// the other way around
+	DiscrepancyNFTEscrow DiscrepancyKind = "nft-escrow"
+	// The supply of the bridged ERC721 differs from the number of NFTs ever
+	// bridged to EVM
+	DiscrepancyERC721Supply DiscrepancyKind = "erc721-supply"
+)
+
+// Discrepancy is a difference between the expected and the on-chain state
+type Discrepancy struct {
+	Kind               DiscrepancyKind `json:"kind"`
+	Type               string          `json:"type"`
+	EVMContractAddress string          `json:"evmContractAddress"`
+	// Cadence ID of the NFT, set for DiscrepancyNFTEscrow
+	ID       *uint64 `json:"id,omitempty"`
+	Expected string  `json:"expected"`
+	Actual   string  `json:"actual"`
+}
+
+// Report is the result of a reconciliation
+type Report struct {
+	// Height of the last event replayed to compute the expected state
+	Height uint64 `json:"height"`
+	// Number of token and NFT types checked
+	Tokens int `json:"tokens"`
+	NFTs   int `json:"nfts"`
+	// Types not escrowed by the bridge, which were not checked
+	Skipped       []string      `json:"skipped"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
+
+// Returns whether the on-chain state matches the expected state
+func (r *Report) Balanced() bool {
+	return len(r.Discrepancies) == 0
+}
+
+// Reconciler compares the escrow state expected by a Ledger with the state
+// reported by scripts. The chain should be queried at the height of the last
+// event applied to the ledger, or bridging after it shows up as discrepancies
+type Reconciler struct {
+	BridgeEnv bridge.Environment
+	CoreEnv   coreContracts.Environment
+	Scripts   ScriptExecutor
+}
+
+// Reconciles every asset tracked by the ledger
+func (r *Reconciler) Reconcile(ctx context.Context, ledger *Ledger) (*Report, error) {
+	report := &Report{
+		Height:        ledger.Height(),
+		Skipped:       ledger.Skipped(),
+		Discrepancies: []Discrepancy{},
+	}
+	for _, escrow := range ledger.Tokens() {
+		discrepancies, err := r.reconcileToken(ctx, escrow)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot reconcile %s: %w", escrow.Type, err)
+		}
+		report.Tokens++
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+	}
+	for _, escrow := range ledger.NFTs() {
+		discrepancies, err := r.reconcileNFT(ctx, escrow)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot reconcile %s: %w", escrow.Type, err)
+		}
+		report.NFTs++
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+	}
+	return report, nil
+}
+
+func (r *Reconciler) reconcileToken(ctx context.Context, escrow *TokenEscrow) ([]Discrepancy, error) {
+	discrepancies := []Discrepancy{}
+	discrepancy := func(kind DiscrepancyKind, expected, actual string) {
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind:               kind,
+			Type:               escrow.Type,
+			EVMContractAddress: escrow.EVMContractAddress,
+			Expected:           expected,
+			Actual:             actual,
+		})
+	}
+
+	decimalsValue, err := r.execute(ctx, pathGetTokenDecimals, cadence.String(escrow.EVMContractAddress))
+	if err != nil {
+		return nil, err
+	}
+	decimals, ok := decimalsValue.(cadence.UInt8)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected decimals %s", decimalsValue)
+	}
+
+	// Expected balance in escrow, in UFix64 base units
+	expectedLocked := new(big.Int).SetUint64(uint64(escrow.ToEVM))
+	for _, amount := range escrow.fromEVMAmounts {
+		converted, err := bridge.ConvertERC20AmountToCadenceAmount(amount, uint8(decimals))
+		if err != nil {
+			return nil, err
+		}
+		expectedLocked.Sub(expectedLocked, new(big.Int).SetUint64(uint64(converted)))
+	}
+	lockedValue, err := r.execute(ctx, pathGetLockedTokenBalance, cadence.String(escrow.Type))
+	if err != nil {
+		return nil, err
+	}
+	locked := new(big.Int)
+	actualLocked := "none"
+	if optional, ok := lockedValue.(cadence.Optional); ok && optional.Value != nil {
+		balance, ok := optional.Value.(cadence.UFix64)
+		if !ok {
+			return nil, fmt.Errorf("Unexpected locked balance %s", lockedValue)
+		}
+		locked.SetUint64(uint64(balance))
+		actualLocked = balance.String()
+	}
+	if expectedLocked.Cmp(locked) != 0 {
+		discrepancy(DiscrepancyTokenEscrow, formatUFix64Units(expectedLocked), actualLocked)
+	}
+
+	// Expected supply of the bridged ERC20, in its own units
+	expectedSupply := bridge.ConvertCadenceAmountToERC20Amount(escrow.ToEVM, uint8(decimals))
+	expectedSupply.Sub(expectedSupply, escrow.FromEVM)
+	supply, err := r.totalSupply(ctx, escrow.EVMContractAddress)
+	if err != nil {
+		return nil, err
+	}
+	if expectedSupply.Cmp(supply) != 0 {
+		discrepancy(DiscrepancyERC20Supply, expectedSupply.String(), supply.String())
+	}
+	return discrepancies, nil
+}
+
+func (r *Reconciler) reconcileNFT(ctx context.Context, escrow *NFTEscrow) ([]Discrepancy, error) {
+	discrepancies := []Discrepancy{}
+
+	ids := make([]uint64, 0, len(escrow.Locked))
+	for id := range escrow.Locked {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		value, err := r.execute(ctx, pathIsNFTLocked, cadence.String(escrow.Type), cadence.UInt64(id))
+		if err != nil {
+			return nil, err
+		}
+		locked, ok := value.(cadence.Bool)
+		if !ok {
+			return nil, fmt.Errorf("Unexpected lock status %s", value)
+		}
+		if bool(locked) != escrow.Locked[id] {
+			discrepancies = append(discrepancies, Discrepancy{
+				Kind:               DiscrepancyNFTEscrow,
+				Type:               escrow.Type,
+				EVMContractAddress: escrow.EVMContractAddress,
+				ID:                 &id,
+				Expected:           lockStatus(escrow.Locked[id]),
+				Actual:             lockStatus(bool(locked)),
+			})
+		}
+	}
+
+	// The bridged ERC721 is minted the first time an NFT is bridged to EVM and
+	// escrowed by the bridge in EVM when it is bridged back, so its supply is
+	// the number of NFTs ever bridged
+	supply, err := r.totalSupply(ctx, escrow.EVMContractAddress)
+	if err != nil {
+		return nil, err
+	}
+	expected := big.NewInt(int64(len(escrow.Locked)))
+	if expected.Cmp(supply) != 0 {
+		discrepancies = append(discrepancies, Discrepancy{
+			Kind:               DiscrepancyERC721Supply,
+			Type:               escrow.Type,
+			EVMContractAddress: escrow.EVMContractAddress,
+			Expected:           expected.String(),
+			Actual:             supply.String(),
+		})
+	}
+	return discrepancies, nil
+}
+
+func (r *Reconciler) totalSupply(ctx context.Context, evmAddress string) (*big.Int, error) {
+	value, err := r.execute(ctx, pathTotalSupply, cadence.String(evmAddress))
+	if err != nil {
+		return nil, err
+	}
+	supply, ok := value.(cadence.UInt256)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected total supply %s", value)
+	}
+	return supply.Value, nil
+}
+
+func (r *Reconciler) execute(ctx context.Context, path string, arguments ...cadence.Value) (cadence.Value, error) {
+	code, err := bridge.GetCadenceScriptCode(path, r.BridgeEnv, r.CoreEnv)
+	if err != nil {
+		return nil, err
+	}
+	value, err := r.Scripts.ExecuteScript(ctx, code, arguments)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", path, err)
+	}
+	return value, nil
+}
+
+// Formats UFix64 base units like cadence.UFix64, including negative amounts
+// which cannot be represented as UFix64
+func formatUFix64Units(units *big.Int) string {
+	if units.IsUint64() {
+		return cadence.UFix64(units.Uint64()).String()
+	}
+	return "-" + cadence.UFix64(new(big.Int).Neg(units).Uint64()).String()
+}
+
+func lockStatus(locked bool) string {
+	return "locked=" + strconv.FormatBool(locked)
+}
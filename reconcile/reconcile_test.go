@@ -0,0 +1,177 @@
+package reconcile_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/reconcile"
+)
+
+const (
+	exampleToken = "A.0ae53cb6e3f42a79.ExampleToken.Vault"
+	exampleNFT   = "A.0ae53cb6e3f42a79.ExampleNFT.NFT"
+	customNFT    = "A.0ae53cb6e3f42a79.CustomNFT.NFT"
+	bridgedNFT   = "A.1e4aa0b87d10b141.EVMVMBridgedNFT_0000000000000000000000000000000000000aaa.NFT"
+	erc20        = "0000000000000000000000000000000000000b20"
+	erc721       = "0000000000000000000000000000000000000721"
+)
+
+// fakeChain answers the reconciliation scripts from in-memory state
+type fakeChain struct {
+	locked   map[string]*cadence.UFix64
+	nfts     map[uint64]bool
+	decimals uint8
+	supplies map[string]*big.Int
+}
+
+func (c *fakeChain) ExecuteScript(_ context.Context, code []byte, arguments []cadence.Value) (cadence.Value, error) {
+	source := string(code)
+	switch {
+	case strings.Contains(source, "getLockedTokenBalance"):
+		balance := c.locked[string(arguments[0].(cadence.String))]
+		if balance == nil {
+			return cadence.NewOptional(nil), nil
+		}
+		return cadence.NewOptional(*balance), nil
+	case strings.Contains(source, "FlowEVMBridgeNFTEscrow.isLocked"):
+		return cadence.Bool(c.nfts[uint64(arguments[1].(cadence.UInt64))]), nil
+	case strings.Contains(source, "getTokenDecimals"):
+		return cadence.UInt8(c.decimals), nil
+	case strings.Contains(source, "FlowEVMBridgeUtils.totalSupply"):
+		supply, err := cadence.NewUInt256FromBig(c.supplies[string(arguments[0].(cadence.String))])
+		return supply, err
+	}
+	return nil, errors.New("unexpected script")
+}
+
+func ufix64(t *testing.T, value string) cadence.UFix64 {
+	amount, err := cadence.NewUFix64(value)
+	require.Nil(t, err)
+	return amount
+}
+
+func wei(value string) *big.Int {
+	amount, _ := new(big.Int).SetString(value, 10)
+	return amount
+}
+
+func newReconciler(t *testing.T, chain *fakeChain) *reconcile.Reconciler {
+	env, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	return &reconcile.Reconciler{BridgeEnv: env, CoreEnv: coreEnv, Scripts: chain}
+}
+
+func newLedger(t *testing.T) *reconcile.Ledger {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	ledger := reconcile.NewLedger(env)
+
+	for height, event := range []events.Event{
+		events.BridgedTokensToEVM{Type: exampleToken, Amount: ufix64(t, "10.0"), EVMContractAddress: erc20},
+		// The bridge truncates the dust beyond 8 decimals when unlocking
+		events.BridgedTokensFromEVM{Type: exampleToken, Amount: wei("4500000000000000001"), EVMContractAddress: erc20},
+		events.BridgedNFTToEVM{Type: exampleNFT, ID: 1, EVMContractAddress: erc721},
+		events.BridgedNFTToEVM{Type: exampleNFT, ID: 2, EVMContractAddress: erc721},
+		events.BridgedNFTFromEVM{Type: exampleNFT, ID: 2, EVMContractAddress: erc721},
+		events.BridgedNFTToEVM{Type: bridgedNFT, ID: 3, EVMContractAddress: "0000000000000000000000000000000000000aaa"},
+		events.CustomAssociationEstablished{Type: customNFT},
+		events.BridgedNFTToEVM{Type: customNFT, ID: 4, EVMContractAddress: "0000000000000000000000000000000000000ccc"},
+	} {
+		ledger.Apply(events.Record{Event: event, BlockHeight: uint64(height + 1)})
+	}
+	return ledger
+}
+
+func balancedChain(t *testing.T) *fakeChain {
+	locked := ufix64(t, "5.5")
+	return &fakeChain{
+		locked:   map[string]*cadence.UFix64{exampleToken: &locked},
+		nfts:     map[uint64]bool{1: true},
+		decimals: 18,
+		supplies: map[string]*big.Int{
+			erc20:  wei("5499999999999999999"),
+			erc721: big.NewInt(2),
+		},
+	}
+}
+
+func TestLedger(t *testing.T) {
+	ledger := newLedger(t)
+
+	require.Len(t, ledger.Tokens(), 1)
+	assert.Equal(t, ufix64(t, "10.0"), ledger.Tokens()[0].ToEVM)
+	require.Len(t, ledger.NFTs(), 1)
+	assert.Equal(t, []uint64{1}, ledger.NFTs()[0].LockedIDs())
+	assert.Equal(t, []string{customNFT}, ledger.Skipped())
+	assert.Equal(t, uint64(8), ledger.Height())
+}
+
+func TestLedgerConsumer(t *testing.T) {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	address, err := common.HexToAddress(env.FlowEVMBridgeConfigAddress)
+	require.Nil(t, err)
+	location := common.NewAddressLocation(nil, address, "FlowEVMBridgeConfig")
+	event := cadence.NewEvent([]cadence.Value{cadence.String(exampleToken), cadence.NewOptional(nil), cadence.Bool(true)}).
+		WithType(cadence.NewEventType(location, events.TypeHandlerConfigured, []cadence.Field{
+			{Identifier: "targetType", Type: cadence.StringType},
+			{Identifier: "targetEVMAddress", Type: cadence.NewOptionalType(cadence.StringType)},
+			{Identifier: "isEnabled", Type: cadence.BoolType},
+		}, nil))
+	source := events.NewMemorySource()
+	source.Add(9, flow.Event{Type: event.EventType.ID(), TransactionID: flow.Identifier{1}, Value: event})
+
+	ledger := newLedger(t)
+	_, err = ledger.Consumer(source, env, &events.MemoryCheckpointStore{}, 9).Sync(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, []string{customNFT, exampleToken}, ledger.Skipped())
+	assert.Empty(t, ledger.Tokens())
+	assert.Equal(t, uint64(9), ledger.Height())
+}
+
+func TestReconcileBalanced(t *testing.T) {
+	ledger := newLedger(t)
+	reconciler := newReconciler(t, balancedChain(t))
+
+	report, err := reconciler.Reconcile(context.Background(), ledger)
+	require.Nil(t, err)
+	assert.True(t, report.Balanced(), report.Discrepancies)
+	assert.Equal(t, 1, report.Tokens)
+	assert.Equal(t, 1, report.NFTs)
+}
+
+func TestReconcileDiscrepancies(t *testing.T) {
+	ledger := newLedger(t)
+	chain := balancedChain(t)
+	short := ufix64(t, "5.0")
+	chain.locked[exampleToken] = &short
+	chain.nfts[2] = true
+	chain.supplies[erc721] = big.NewInt(3)
+	reconciler := newReconciler(t, chain)
+
+	report, err := reconciler.Reconcile(context.Background(), ledger)
+	require.Nil(t, err)
+	require.Len(t, report.Discrepancies, 3)
+
+	assert.Equal(t, reconcile.DiscrepancyTokenEscrow, report.Discrepancies[0].Kind)
+	assert.Equal(t, "5.50000000", report.Discrepancies[0].Expected)
+	assert.Equal(t, "5.00000000", report.Discrepancies[0].Actual)
+
+	assert.Equal(t, reconcile.DiscrepancyNFTEscrow, report.Discrepancies[1].Kind)
+	require.NotNil(t, report.Discrepancies[1].ID)
+	assert.Equal(t, uint64(2), *report.Discrepancies[1].ID)
+
+	assert.Equal(t, reconcile.DiscrepancyERC721Supply, report.Discrepancies[2].Kind)
+	assert.Equal(t, "2", report.Discrepancies[2].Expected)
+}
@@ -0,0 +1,250 @@
+// Package results decodes the values returned by the scripts in
+// cadence/scripts into native Go values.
+//
+// Optional results decode to pointers, or to nil maps and slices, which are
+// nil when the script returned nil. Types decode to their identifiers, EVM
+// addresses to hex without 0x prefix, UInt256 values to *big.Int and UFix64
+// values to cadence.UFix64, which formats exactly. Decoders lists the decoder
+// of every script by path.
+package results
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Returns the value of an optional and whether it is present. Values that
+// are not optionals are returned as is
+func Unwrap(value cadence.Value) (cadence.Value, bool) {
+	if optional, ok := value.(cadence.Optional); ok {
+		if optional.Value == nil {
+			return nil, false
+		}
+		return Unwrap(optional.Value)
+	}
+	return value, value != nil
+}
+
+func Bool(value cadence.Value) (bool, error) {
+	b, ok := value.(cadence.Bool)
+	if !ok {
+		return false, unexpected("Bool", value)
+	}
+	return bool(b), nil
+}
+
+func OptionalBool(value cadence.Value) (*bool, error) {
+	return optional(value, Bool)
+}
+
+func String(value cadence.Value) (string, error) {
+	s, ok := value.(cadence.String)
+	if !ok {
+		return "", unexpected("String", value)
+	}
+	return string(s), nil
+}
+
+func OptionalString(value cadence.Value) (*string, error) {
+	return optional(value, String)
+}
+
+func UFix64(value cadence.Value) (cadence.UFix64, error) {
+	n, ok := value.(cadence.UFix64)
+	if !ok {
+		return 0, unexpected("UFix64", value)
+	}
+	return n, nil
+}
+
+func OptionalUFix64(value cadence.Value) (*cadence.UFix64, error) {
+	return optional(value, UFix64)
+}
+
+func UInt8(value cadence.Value) (uint8, error) {
+	n, ok := value.(cadence.UInt8)
+	if !ok {
+		return 0, unexpected("UInt8", value)
+	}
+	return uint8(n), nil
+}
+
+func UInt64(value cadence.Value) (uint64, error) {
+	n, ok := value.(cadence.UInt64)
+	if !ok {
+		return 0, unexpected("UInt64", value)
+	}
+	return uint64(n), nil
+}
+
+// Decodes a UInt or UInt256
+func BigInt(value cadence.Value) (*big.Int, error) {
+	switch n := value.(type) {
+	case cadence.UInt256:
+		return n.Big(), nil
+	case cadence.UInt:
+		return n.Big(), nil
+	}
+	return nil, unexpected("UInt256", value)
+}
+
+func OptionalBigInt(value cadence.Value) (*big.Int, error) {
+	value, ok := Unwrap(value)
+	if !ok {
+		return nil, nil
+	}
+	return BigInt(value)
+}
+
+func Address(value cadence.Value) (flow.Address, error) {
+	address, ok := value.(cadence.Address)
+	if !ok {
+		return flow.Address{}, unexpected("Address", value)
+	}
+	return flow.Address(address), nil
+}
+
+func OptionalAddress(value cadence.Value) (*flow.Address, error) {
+	return optional(value, Address)
+}
+
+// Decodes a Type to its identifier
+func Type(value cadence.Value) (string, error) {
+	t, ok := value.(cadence.TypeValue)
+	if !ok || t.StaticType == nil {
+		return "", unexpected("Type", value)
+	}
+	return t.StaticType.ID(), nil
+}
+
+func OptionalType(value cadence.Value) (*string, error) {
+	return optional(value, Type)
+}
+
+// Decodes [Type]? to identifiers, nil if the script returned nil
+func OptionalTypes(value cadence.Value) ([]string, error) {
+	value, ok := Unwrap(value)
+	if !ok {
+		return nil, nil
+	}
+	return array(value, Type)
+}
+
+// Decodes [UInt64]? to IDs, nil if the script returned nil
+func OptionalUInt64s(value cadence.Value) ([]uint64, error) {
+	value, ok := Unwrap(value)
+	if !ok {
+		return nil, nil
+	}
+	return array(value, UInt64)
+}
+
+// Decodes [String]
+func Strings(value cadence.Value) ([]string, error) {
+	return array(value, String)
+}
+
+// Decodes an EVM.EVMAddress to hex without 0x prefix
+func EVMAddress(value cadence.Value) (string, error) {
+	composite, ok := value.(cadence.Composite)
+	if !ok {
+		return "", unexpected("EVM.EVMAddress", value)
+	}
+	bytes, ok := composite.SearchFieldByName("bytes").(cadence.Array)
+	if !ok || len(bytes.Values) != 20 {
+		return "", unexpected("EVM.EVMAddress", value)
+	}
+	address := make([]byte, len(bytes.Values))
+	for i, b := range bytes.Values {
+		n, ok := b.(cadence.UInt8)
+		if !ok {
+			return "", unexpected("EVM.EVMAddress", value)
+		}
+		address[i] = byte(n)
+	}
+	return hex.EncodeToString(address), nil
+}
+
+func OptionalEVMAddress(value cadence.Value) (*string, error) {
+	return optional(value, EVMAddress)
+}
+
+// Decodes an array of arbitrary values, such as the result of evm/call.cdc
+func Values(value cadence.Value) ([]cadence.Value, error) {
+	a, ok := value.(cadence.Array)
+	if !ok {
+		return nil, unexpected("array", value)
+	}
+	return a.Values, nil
+}
+
+// Decodes {String: Bool?}, or {Type: Bool?} keyed by type identifier
+func BoolMap(value cadence.Value) (map[string]*bool, error) {
+	return dictionary(value, OptionalBool)
+}
+
+// Decodes {String: String?}
+func StringMap(value cadence.Value) (map[string]*string, error) {
+	return dictionary(value, OptionalString)
+}
+
+// Decodes {String: Type?} to type identifiers
+func TypeMap(value cadence.Value) (map[string]*string, error) {
+	return dictionary(value, OptionalType)
+}
+
+func optional[T any](value cadence.Value, decode func(cadence.Value) (T, error)) (*T, error) {
+	value, ok := Unwrap(value)
+	if !ok {
+		return nil, nil
+	}
+	decoded, err := decode(value)
+	if err != nil {
+		return nil, err
+	}
+	return &decoded, nil
+}
+
+func array[T any](value cadence.Value, decode func(cadence.Value) (T, error)) ([]T, error) {
+	a, ok := value.(cadence.Array)
+	if !ok {
+		return nil, unexpected("array", value)
+	}
+	decoded := make([]T, len(a.Values))
+	for i, element := range a.Values {
+		var err error
+		if decoded[i], err = decode(element); err != nil {
+			return nil, err
+		}
+	}
+	return decoded, nil
+}
+
+// Decodes a dictionary keyed by String or by Type identifier
+func dictionary[T any](value cadence.Value, decode func(cadence.Value) (T, error)) (map[string]T, error) {
+	d, ok := value.(cadence.Dictionary)
+	if !ok {
+		return nil, unexpected("dictionary", value)
+	}
+	decoded := make(map[string]T, len(d.Pairs))
+	for _, pair := range d.Pairs {
+		key, err := String(pair.Key)
+		if err != nil {
+			if key, err = Type(pair.Key); err != nil {
+				return nil, unexpected("String or Type key", pair.Key)
+			}
+		}
+		if decoded[key], err = decode(pair.Value); err != nil {
+			return nil, err
+		}
+	}
+	return decoded, nil
+}
+
+func unexpected(expected string, value cadence.Value) error {
+	return fmt.Errorf("Unexpected value %v, expected %s", value, expected)
+}
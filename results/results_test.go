@@ -0,0 +1,171 @@
+package results_test
+
+import (
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/results"
+)
+
+var location = common.NewAddressLocation(nil, common.MustBytesToAddress([]byte{0x1}), "")
+
+// Returns a struct of the qualified type with alternating field names and values
+func newStruct(qualified string, fields ...any) cadence.Struct {
+	types := []cadence.Field{}
+	values := []cadence.Value{}
+	for i := 0; i < len(fields); i += 2 {
+		types = append(types, cadence.Field{Identifier: fields[i].(string), Type: cadence.AnyStructType})
+		values = append(values, fields[i+1].(cadence.Value))
+	}
+	return cadence.NewStruct(values).WithType(cadence.NewStructType(location, qualified, types, nil))
+}
+
+func typeValue(qualified string) cadence.TypeValue {
+	return cadence.NewTypeValue(cadence.NewResourceType(location, qualified, nil, nil))
+}
+
+func evmAddress(b byte) cadence.Struct {
+	bytes := make([]cadence.Value, 20)
+	for i := range bytes {
+		bytes[i] = cadence.UInt8(0)
+	}
+	bytes[19] = cadence.UInt8(b)
+	return newStruct("EVM.EVMAddress", "bytes", cadence.NewArray(bytes))
+}
+
+func TestEveryScriptHasDecoder(t *testing.T) {
+	err := filepath.WalkDir("../cadence/scripts", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".cdc" {
+			return err
+		}
+		relative, err := filepath.Rel("..", path)
+		require.Nil(t, err)
+		assert.Contains(t, results.Decoders, filepath.ToSlash(relative))
+		return nil
+	})
+	require.Nil(t, err)
+
+	for path := range results.Decoders {
+		_, err := os.Stat(filepath.Join("..", path))
+		assert.Nil(t, err, path)
+	}
+}
+
+func TestBatchResults(t *testing.T) {
+	requires, err := results.BoolMap(cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: typeValue("ExampleNFT.NFT"), Value: cadence.NewOptional(cadence.Bool(true))},
+		{Key: typeValue("Unknown.NFT"), Value: cadence.NewOptional(nil)},
+	}))
+	require.Nil(t, err)
+	require.Len(t, requires, 2)
+	assert.True(t, *requires["A.0000000000000001.ExampleNFT.NFT"])
+	assert.Nil(t, requires["A.0000000000000001.Unknown.NFT"])
+
+	associated, err := results.Decoders["cadence/scripts/bridge/batch_get_associated_evm_address.cdc"](cadence.NewDictionary([]cadence.KeyValuePair{
+		{Key: cadence.String("A.0000000000000001.ExampleNFT.NFT"), Value: cadence.NewOptional(cadence.String("ab"))},
+	}))
+	require.Nil(t, err)
+	assert.Equal(t, "ab", *associated.(map[string]*string)["A.0000000000000001.ExampleNFT.NFT"])
+
+	_, err = results.BoolMap(cadence.Bool(true))
+	assert.ErrorContains(t, err, "expected dictionary")
+}
+
+func TestVaultInfos(t *testing.T) {
+	storage, _ := cadence.NewPath(common.PathDomainStorage, "flowTokenVault")
+	receiver, _ := cadence.NewPath(common.PathDomainPublic, "flowTokenReceiver")
+	infos, err := results.VaultInfos(cadence.NewDictionary([]cadence.KeyValuePair{{
+		Key: typeValue("FlowToken.Vault"),
+		Value: newStruct("FTVaultInfo",
+			"name", cadence.String("FLOW"),
+			"symbol", cadence.String("FLOW"),
+			"balance", cadence.UFix64(150_000_000),
+			"tokenContractAddress", cadence.Address{0x1},
+			"tokenContractName", cadence.String("FlowToken"),
+			"storagePath", storage,
+			"receiverPath", receiver,
+		),
+	}}))
+	require.Nil(t, err)
+	info := infos["A.0000000000000001.FlowToken.Vault"]
+	assert.Equal(t, "1.50000000", info.Balance.String())
+	assert.Equal(t, flow.HexToAddress("0100000000000000"), info.TokenContractAddress)
+	assert.Equal(t, "/storage/flowTokenVault", info.StoragePath)
+	assert.Equal(t, "/public/flowTokenReceiver", info.ReceiverPath)
+}
+
+func TestCadenceEVMBalance(t *testing.T) {
+	balance, err := results.DecodeCadenceEVMBalance(cadence.NewArray([]cadence.Value{
+		cadence.UInt8(18), cadence.UFix64(100_000_000), cadence.NewUInt256(5), cadence.NewUInt256(1_000_000_000_000_000_005),
+	}))
+	require.Nil(t, err)
+	assert.Equal(t, uint8(18), balance.Decimals)
+	assert.Equal(t, big.NewInt(5), balance.EVMBalance)
+	assert.Equal(t, "1000000000000000005", balance.TotalBalance.String())
+}
+
+func TestViews(t *testing.T) {
+	httpFile := newStruct("MetadataViews.HTTPFile", "url", cadence.String("https://example.com/1.png"))
+	ipfsFile := newStruct("MetadataViews.IPFSFile", "cid", cadence.String("Qm"), "path", cadence.NewOptional(cadence.String("1.png")))
+
+	view, err := results.View(cadence.NewOptional(newStruct(results.ViewDisplay,
+		"name", cadence.String("Example"),
+		"description", cadence.String("An example"),
+		"thumbnail", ipfsFile,
+	)))
+	require.Nil(t, err)
+	display := view.(results.Display)
+	assert.Equal(t, "Example", display.Name)
+	assert.Equal(t, "ipfs://Qm/1.png", display.Thumbnail.URI())
+
+	externalURL := newStruct("MetadataViews.ExternalURL", "url", cadence.String("https://example.com"))
+	media := newStruct("MetadataViews.Media", "file", httpFile, "mediaType", cadence.String("image/png"))
+	view, err = results.View(newStruct(results.ViewNFTCollectionDisplay,
+		"name", cadence.String("Examples"),
+		"description", cadence.String("Example collection"),
+		"externalURL", externalURL,
+		"squareImage", media,
+		"bannerImage", media,
+		"socials", cadence.NewDictionary([]cadence.KeyValuePair{{Key: cadence.String("x"), Value: externalURL}}),
+	))
+	require.Nil(t, err)
+	collection := view.(results.NFTCollectionDisplay)
+	assert.Equal(t, "https://example.com", collection.ExternalURL)
+	assert.Equal(t, "https://example.com/1.png", collection.SquareImage.File.URI())
+	assert.Equal(t, map[string]string{"x": "https://example.com"}, collection.Socials)
+
+	vm := cadence.NewEnum([]cadence.Value{cadence.UInt8(1)}).WithType(cadence.NewEnumType(location, "CrossVMMetadataViews.VM", cadence.UInt8Type,
+		[]cadence.Field{{Identifier: "rawValue", Type: cadence.UInt8Type}}, nil))
+	pointer, err := results.Decoders["cadence/scripts/nft/get_evm_pointer_from_identifier.cdc"](cadence.NewOptional(newStruct(results.ViewEVMPointer,
+		"cadenceType", typeValue("ExampleNFT.NFT"),
+		"cadenceContractAddress", cadence.Address{0x1},
+		"evmContractAddress", evmAddress(0xab),
+		"nativeVM", vm,
+	)))
+	require.Nil(t, err)
+	assert.Equal(t, &results.EVMPointer{
+		CadenceType:            "A.0000000000000001.ExampleNFT.NFT",
+		CadenceContractAddress: flow.HexToAddress("0100000000000000"),
+		EVMContractAddress:     "00000000000000000000000000000000000000ab",
+		NativeVM:               results.NativeVMEVM,
+	}, pointer)
+
+	// Other views are returned as is
+	other := newStruct("MetadataViews.Royalties")
+	view, err = results.View(other)
+	require.Nil(t, err)
+	assert.Equal(t, other, view)
+
+	view, err = results.View(cadence.NewOptional(nil))
+	require.Nil(t, err)
+	assert.Nil(t, view)
+}
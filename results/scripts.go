@@ -0,0 +1,184 @@
+package results
+
+import (
+	"math/big"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// FTVaultInfo is a vault stored in an account, as returned by
+// get_all_vault_info_from_storage.cdc
+type FTVaultInfo struct {
+	Name    string         `json:"name"`
+	Symbol  string         `json:"symbol"`
+	Balance cadence.UFix64 `json:"balance"`
+	// Address and name of the contract defining the vault
+	TokenContractAddress flow.Address `json:"tokenContractAddress"`
+	TokenContractName    string       `json:"tokenContractName"`
+	StoragePath          string       `json:"storagePath"`
+	ReceiverPath         string       `json:"receiverPath"`
+}
+
+// Decodes {Type: FTVaultInfo} keyed by vault type identifier
+func VaultInfos(value cadence.Value) (map[string]FTVaultInfo, error) {
+	return dictionary(value, decodeVaultInfo)
+}
+
+func decodeVaultInfo(value cadence.Value) (FTVaultInfo, error) {
+	composite, ok := value.(cadence.Composite)
+	if !ok {
+		return FTVaultInfo{}, unexpected("FTVaultInfo", value)
+	}
+	fields := composite.FieldsMappedByName()
+	info := FTVaultInfo{}
+	var err error
+	if info.Name, err = String(fields["name"]); err != nil {
+		return info, err
+	}
+	if info.Symbol, err = String(fields["symbol"]); err != nil {
+		return info, err
+	}
+	if info.Balance, err = UFix64(fields["balance"]); err != nil {
+		return info, err
+	}
+	if info.TokenContractAddress, err = Address(fields["tokenContractAddress"]); err != nil {
+		return info, err
+	}
+	if info.TokenContractName, err = String(fields["tokenContractName"]); err != nil {
+		return info, err
+	}
+	if info.StoragePath, err = path(fields["storagePath"]); err != nil {
+		return info, err
+	}
+	info.ReceiverPath, err = path(fields["receiverPath"])
+	return info, err
+}
+
+// CadenceEVMBalance is the balance of a token across an account and its COA,
+// as returned by get_full_cadence_evm_balance.cdc
+type CadenceEVMBalance struct {
+	// Decimals of the ERC20, zero if the account has no COA or the token no ERC20
+	Decimals       uint8          `json:"decimals"`
+	CadenceBalance cadence.UFix64 `json:"cadenceBalance"`
+	// Balance of the COA in the ERC20's smallest unit
+	EVMBalance *big.Int `json:"evmBalance"`
+	// Sum of both balances in the ERC20's smallest unit
+	TotalBalance *big.Int `json:"totalBalance"`
+}
+
+func DecodeCadenceEVMBalance(value cadence.Value) (CadenceEVMBalance, error) {
+	values, err := Values(value)
+	if err != nil || len(values) != 4 {
+		return CadenceEVMBalance{}, unexpected("[decimals, cadenceBalance, evmBalance, totalBalance]", value)
+	}
+	balance := CadenceEVMBalance{}
+	if balance.Decimals, err = UInt8(values[0]); err != nil {
+		return balance, err
+	}
+	if balance.CadenceBalance, err = UFix64(values[1]); err != nil {
+		return balance, err
+	}
+	if balance.EVMBalance, err = BigInt(values[2]); err != nil {
+		return balance, err
+	}
+	balance.TotalBalance, err = BigInt(values[3])
+	return balance, err
+}
+
+func path(value cadence.Value) (string, error) {
+	p, ok := value.(cadence.Path)
+	if !ok {
+		return "", unexpected("Path", value)
+	}
+	return p.String(), nil
+}
+
+// Decoder decodes the result of a script
+type Decoder func(cadence.Value) (any, error)
+
+func decoder[T any](decode func(cadence.Value) (T, error)) Decoder {
+	return func(value cadence.Value) (any, error) {
+		return decode(value)
+	}
+}
+
+// Decoders of the scripts in cadence/scripts by path
+var Decoders = map[string]Decoder{
+	"cadence/scripts/bridge/batch_evm_address_requires_onboarding.cdc":                  decoder(BoolMap),
+	"cadence/scripts/bridge/batch_get_associated_evm_address.cdc":                       decoder(StringMap),
+	"cadence/scripts/bridge/batch_get_associated_type.cdc":                              decoder(TypeMap),
+	"cadence/scripts/bridge/batch_type_requires_onboarding.cdc":                         decoder(BoolMap),
+	"cadence/scripts/bridge/calculate_bridge_fee.cdc":                                   decoder(UFix64),
+	"cadence/scripts/bridge/evm_address_requires_onboarding.cdc":                        decoder(OptionalBool),
+	"cadence/scripts/bridge/get_associated_evm_address.cdc":                             decoder(OptionalString),
+	"cadence/scripts/bridge/get_associated_type.cdc":                                    decoder(OptionalType),
+	"cadence/scripts/bridge/get_bridge_coa_address.cdc":                                 decoder(String),
+	"cadence/scripts/bridge/get_bridge_router_type.cdc":                                 decoder(OptionalString),
+	"cadence/scripts/bridge/get_bridged_asset_contract_code.cdc":                        decoder(OptionalString),
+	"cadence/scripts/bridge/get_gas_limit.cdc":                                          decoder(UInt64),
+	"cadence/scripts/bridge/get_legacy_evm_address_for_custom_cross_vm_evm_address.cdc": decoder(OptionalEVMAddress),
+	"cadence/scripts/bridge/get_legacy_type_for_custom_cross_vm_type.cdc":               decoder(OptionalType),
+	"cadence/scripts/bridge/get_updated_custom_cross_vm_evm_address.cdc":                decoder(OptionalEVMAddress),
+	"cadence/scripts/bridge/get_updated_custom_cross_vm_type.cdc":                       decoder(OptionalType),
+	"cadence/scripts/bridge/is_cadence_type_blocked.cdc":                                decoder(Bool),
+	"cadence/scripts/bridge/is_evm_address_blocked.cdc":                                 decoder(Bool),
+	"cadence/scripts/bridge/is_paused.cdc":                                              decoder(Bool),
+	"cadence/scripts/bridge/is_type_paused.cdc":                                         decoder(OptionalBool),
+	"cadence/scripts/bridge/type_requires_onboarding.cdc":                               decoder(OptionalBool),
+	"cadence/scripts/bridge/type_requires_onboarding_by_identifier.cdc":                 decoder(OptionalBool),
+	"cadence/scripts/config/get_base_fee.cdc":                                           decoder(UFix64),
+	"cadence/scripts/config/get_onboard_fee.cdc":                                        decoder(UFix64),
+	"cadence/scripts/escrow/get_locked_token_balance.cdc":                               decoder(OptionalUFix64),
+	"cadence/scripts/escrow/get_nft_views.cdc":                                          decoder(OptionalTypes),
+	"cadence/scripts/escrow/get_vault_views.cdc":                                        decoder(OptionalTypes),
+	"cadence/scripts/escrow/is_nft_locked.cdc":                                          decoder(Bool),
+	"cadence/scripts/escrow/resolve_locked_nft_metadata.cdc":                            View,
+	"cadence/scripts/escrow/resolve_locked_vault_metadata.cdc":                          View,
+	"cadence/scripts/evm/call.cdc":                                                      decoder(Values),
+	"cadence/scripts/evm/get_attoflow_balance.cdc":                                      decoder(BigInt),
+	"cadence/scripts/evm/get_balance.cdc":                                               decoder(UFix64),
+	"cadence/scripts/evm/get_evm_address_string.cdc":                                    decoder(OptionalString),
+	"cadence/scripts/evm/get_evm_address_string_from_bytes.cdc":                         decoder(OptionalString),
+	"cadence/scripts/evm/get_nonce.cdc":                                                 decoder(UInt64),
+	"cadence/scripts/nft/get_evm_id_from_evm_nft.cdc":                                   decoder(OptionalBigInt),
+	"cadence/scripts/nft/get_evm_pointer_from_identifier.cdc":                           decoder(OptionalEVMPointer),
+	"cadence/scripts/nft/get_ids.cdc":                                                   decoder(OptionalUInt64s),
+	"cadence/scripts/nft/has_collection_configured.cdc":                                 decoder(Bool),
+	"cadence/scripts/serialize/serialize_nft.cdc":                                       decoder(OptionalString),
+	"cadence/scripts/tokens/get_all_vault_info_from_storage.cdc":                        decoder(VaultInfos),
+	"cadence/scripts/tokens/get_balance.cdc":                                            decoder(OptionalUFix64),
+	"cadence/scripts/tokens/get_full_cadence_evm_balance.cdc":                           decoder(DecodeCadenceEVMBalance),
+	"cadence/scripts/tokens/has_vault_configured.cdc":                                   decoder(Bool),
+	"cadence/scripts/tokens/total_supply.cdc":                                           decoder(OptionalUFix64),
+	"cadence/scripts/utils/balance_of.cdc":                                              decoder(BigInt),
+	"cadence/scripts/utils/derive_bridged_nft_contract_name.cdc":                        decoder(String),
+	"cadence/scripts/utils/derive_bridged_token_contract_name.cdc":                      decoder(String),
+	"cadence/scripts/utils/erc721_exists.cdc":                                           decoder(Bool),
+	"cadence/scripts/utils/get_account_contract_names.cdc":                              decoder(Strings),
+	"cadence/scripts/utils/get_declared_cadence_address.cdc":                            decoder(OptionalAddress),
+	"cadence/scripts/utils/get_declared_cadence_type.cdc":                               decoder(OptionalType),
+	"cadence/scripts/utils/get_delegated_deployer_address.cdc":                          decoder(String),
+	"cadence/scripts/utils/get_deployer_address.cdc":                                    decoder(String),
+	"cadence/scripts/utils/get_evm_address_from_hex.cdc":                                decoder(OptionalEVMAddress),
+	"cadence/scripts/utils/get_factory_address.cdc":                                     decoder(String),
+	"cadence/scripts/utils/get_registrar_address.cdc":                                   decoder(String),
+	"cadence/scripts/utils/get_registry_address.cdc":                                    decoder(String),
+	"cadence/scripts/utils/get_token_decimals.cdc":                                      decoder(UInt8),
+	"cadence/scripts/utils/get_vm_bridge_address_from_icross_vm.cdc":                    decoder(OptionalEVMAddress),
+	"cadence/scripts/utils/is_erc721.cdc":                                               decoder(Bool),
+	"cadence/scripts/utils/is_owner.cdc":                                                decoder(Bool),
+	"cadence/scripts/utils/is_owner_or_approved.cdc":                                    decoder(Bool),
+	"cadence/scripts/utils/owner_of.cdc":                                                decoder(OptionalString),
+	"cadence/scripts/utils/supports_cadence_native_nft_evm_interfaces.cdc":              decoder(Bool),
+	"cadence/scripts/utils/supports_icross_vm_bridge_callable.cdc":                      decoder(Bool),
+	"cadence/scripts/utils/supports_icross_vm_bridge_erc721_fulfillment.cdc":            decoder(Bool),
+	"cadence/scripts/utils/token_uri.cdc":                                               decoder(String),
+	"cadence/scripts/utils/total_supply.cdc":                                            decoder(BigInt),
+	"cadence/scripts/utils/ufix64_to_uint256.cdc":                                       decoder(BigInt),
+	"cadence/scripts/utils/uint256_to_ufix64.cdc":                                       decoder(UFix64),
+}
+
+func OptionalEVMPointer(value cadence.Value) (*EVMPointer, error) {
+	return optional(value, DecodeEVMPointer)
+}
@@ -0,0 +1,281 @@
+package results
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Qualified identifiers of the metadata views decoded by View
+const (
+	ViewDisplay              = "MetadataViews.Display"
+	ViewNFTCollectionDisplay = "MetadataViews.NFTCollectionDisplay"
+	ViewFTDisplay            = "FungibleTokenMetadataViews.FTDisplay"
+	ViewEVMPointer           = "CrossVMMetadataViews.EVMPointer"
+)
+
+// File is a MetadataViews.File: an HTTPFile with a URL or an IPFSFile with
+// a CID and optional path
+type File struct {
+	URL  string `json:"url,omitempty"`
+	CID  string `json:"cid,omitempty"`
+	Path string `json:"path,omitempty"`
+}
+
+// Returns the URL of an HTTP file or the ipfs:// URI of an IPFS file
+func (f File) URI() string {
+	if f.CID == "" {
+		return f.URL
+	}
+	if f.Path == "" {
+		return "ipfs://" + f.CID
+	}
+	return "ipfs://" + f.CID + "/" + strings.TrimPrefix(f.Path, "/")
+}
+
+// Media is a MetadataViews.Media
+type Media struct {
+	File      File   `json:"file"`
+	MediaType string `json:"mediaType"`
+}
+
+// Display is a MetadataViews.Display
+type Display struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Thumbnail   File   `json:"thumbnail"`
+}
+
+// NFTCollectionDisplay is a MetadataViews.NFTCollectionDisplay
+type NFTCollectionDisplay struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ExternalURL string `json:"externalURL"`
+	SquareImage Media  `json:"squareImage"`
+	BannerImage Media  `json:"bannerImage"`
+	// Social URLs by network, e.g. "twitter"
+	Socials map[string]string `json:"socials"`
+}
+
+// FTDisplay is a FungibleTokenMetadataViews.FTDisplay
+type FTDisplay struct {
+	Name        string            `json:"name"`
+	Symbol      string            `json:"symbol"`
+	Description string            `json:"description"`
+	ExternalURL string            `json:"externalURL"`
+	Logos       []Media           `json:"logos"`
+	Socials     map[string]string `json:"socials"`
+}
+
+// NativeVM is the VM an asset is native to
+type NativeVM string
+
+const (
+	NativeVMCadence NativeVM = "cadence"
+	NativeVMEVM     NativeVM = "evm"
+)
+
+// EVMPointer is a CrossVMMetadataViews.EVMPointer
+type EVMPointer struct {
+	CadenceType            string       `json:"cadenceType"`
+	CadenceContractAddress flow.Address `json:"cadenceContractAddress"`
+	// Hex without 0x prefix
+	EVMContractAddress string   `json:"evmContractAddress"`
+	NativeVM           NativeVM `json:"nativeVM"`
+}
+
+// Decodes a resolved view, such as the result of resolve_locked_nft_metadata.cdc,
+// into a Display, NFTCollectionDisplay, FTDisplay or EVMPointer. Other views
+// are returned as is, nil views as nil
+func View(value cadence.Value) (any, error) {
+	value, ok := Unwrap(value)
+	if !ok {
+		return nil, nil
+	}
+	switch qualifiedIdentifier(value) {
+	case ViewDisplay:
+		return DecodeDisplay(value)
+	case ViewNFTCollectionDisplay:
+		return DecodeNFTCollectionDisplay(value)
+	case ViewFTDisplay:
+		return DecodeFTDisplay(value)
+	case ViewEVMPointer:
+		return DecodeEVMPointer(value)
+	}
+	return value, nil
+}
+
+func DecodeDisplay(value cadence.Value) (Display, error) {
+	fields, err := fieldsOf(ViewDisplay, value)
+	if err != nil {
+		return Display{}, err
+	}
+	display := Display{}
+	if display.Name, err = String(fields["name"]); err != nil {
+		return display, err
+	}
+	if display.Description, err = String(fields["description"]); err != nil {
+		return display, err
+	}
+	display.Thumbnail, err = decodeFile(fields["thumbnail"])
+	return display, err
+}
+
+func DecodeNFTCollectionDisplay(value cadence.Value) (NFTCollectionDisplay, error) {
+	fields, err := fieldsOf(ViewNFTCollectionDisplay, value)
+	if err != nil {
+		return NFTCollectionDisplay{}, err
+	}
+	display := NFTCollectionDisplay{}
+	if display.Name, err = String(fields["name"]); err != nil {
+		return display, err
+	}
+	if display.Description, err = String(fields["description"]); err != nil {
+		return display, err
+	}
+	if display.ExternalURL, err = decodeExternalURL(fields["externalURL"]); err != nil {
+		return display, err
+	}
+	if display.SquareImage, err = decodeMedia(fields["squareImage"]); err != nil {
+		return display, err
+	}
+	if display.BannerImage, err = decodeMedia(fields["bannerImage"]); err != nil {
+		return display, err
+	}
+	display.Socials, err = dictionary(fields["socials"], decodeExternalURL)
+	return display, err
+}
+
+func DecodeFTDisplay(value cadence.Value) (FTDisplay, error) {
+	fields, err := fieldsOf(ViewFTDisplay, value)
+	if err != nil {
+		return FTDisplay{}, err
+	}
+	display := FTDisplay{}
+	if display.Name, err = String(fields["name"]); err != nil {
+		return display, err
+	}
+	if display.Symbol, err = String(fields["symbol"]); err != nil {
+		return display, err
+	}
+	if display.Description, err = String(fields["description"]); err != nil {
+		return display, err
+	}
+	if display.ExternalURL, err = decodeExternalURL(fields["externalURL"]); err != nil {
+		return display, err
+	}
+	logos, ok := fields["logos"].(cadence.Composite)
+	if !ok {
+		return display, unexpected("MetadataViews.Medias", fields["logos"])
+	}
+	if display.Logos, err = array(logos.SearchFieldByName("items"), decodeMedia); err != nil {
+		return display, err
+	}
+	display.Socials, err = dictionary(fields["socials"], decodeExternalURL)
+	return display, err
+}
+
+func DecodeEVMPointer(value cadence.Value) (EVMPointer, error) {
+	fields, err := fieldsOf(ViewEVMPointer, value)
+	if err != nil {
+		return EVMPointer{}, err
+	}
+	pointer := EVMPointer{}
+	if pointer.CadenceType, err = Type(fields["cadenceType"]); err != nil {
+		return pointer, err
+	}
+	if pointer.CadenceContractAddress, err = Address(fields["cadenceContractAddress"]); err != nil {
+		return pointer, err
+	}
+	if pointer.EVMContractAddress, err = EVMAddress(fields["evmContractAddress"]); err != nil {
+		return pointer, err
+	}
+	vm, ok := fields["nativeVM"].(cadence.Composite)
+	if !ok {
+		return pointer, unexpected("CrossVMMetadataViews.VM", fields["nativeVM"])
+	}
+	rawValue, err := UInt8(vm.SearchFieldByName("rawValue"))
+	if err != nil {
+		return pointer, err
+	}
+	switch rawValue {
+	case 0:
+		pointer.NativeVM = NativeVMCadence
+	case 1:
+		pointer.NativeVM = NativeVMEVM
+	default:
+		return pointer, fmt.Errorf("Unknown VM %d", rawValue)
+	}
+	return pointer, nil
+}
+
+// Decodes an HTTPFile or IPFSFile
+func decodeFile(value cadence.Value) (File, error) {
+	composite, ok := value.(cadence.Composite)
+	if !ok {
+		return File{}, unexpected("MetadataViews.File", value)
+	}
+	fields := composite.FieldsMappedByName()
+	switch qualifiedIdentifier(value) {
+	case "MetadataViews.HTTPFile":
+		url, err := String(fields["url"])
+		return File{URL: url}, err
+	case "MetadataViews.IPFSFile":
+		cid, err := String(fields["cid"])
+		if err != nil {
+			return File{}, err
+		}
+		path, err := OptionalString(fields["path"])
+		if err != nil || path == nil {
+			return File{CID: cid}, err
+		}
+		return File{CID: cid, Path: *path}, nil
+	}
+	// Other implementations of File expose no common field but uri(), which
+	// is not available in a script result
+	return File{}, nil
+}
+
+func decodeMedia(value cadence.Value) (Media, error) {
+	composite, ok := value.(cadence.Composite)
+	if !ok {
+		return Media{}, unexpected("MetadataViews.Media", value)
+	}
+	file, err := decodeFile(composite.SearchFieldByName("file"))
+	if err != nil {
+		return Media{}, err
+	}
+	mediaType, err := String(composite.SearchFieldByName("mediaType"))
+	return Media{File: file, MediaType: mediaType}, err
+}
+
+func decodeExternalURL(value cadence.Value) (string, error) {
+	composite, ok := value.(cadence.Composite)
+	if !ok {
+		return "", unexpected("MetadataViews.ExternalURL", value)
+	}
+	return String(composite.SearchFieldByName("url"))
+}
+
+// Returns the fields of a composite of the given qualified type
+func fieldsOf(qualified string, value cadence.Value) (map[string]cadence.Value, error) {
+	composite, ok := value.(cadence.Composite)
+	if !ok || qualifiedIdentifier(value) != qualified {
+		return nil, unexpected(qualified, value)
+	}
+	return composite.FieldsMappedByName(), nil
+}
+
+// Returns the qualified identifier of a composite's type, e.g.
+// MetadataViews.Display, empty for other values
+func qualifiedIdentifier(value cadence.Value) string {
+	if value == nil {
+		return ""
+	}
+	if t, ok := value.Type().(cadence.CompositeType); ok {
+		return t.CompositeTypeQualifiedIdentifier()
+	}
+	return ""
+}
@@ -0,0 +1,271 @@
+// Package sqlexport exports decoded bridge events into a SQLite database so
+// bridge activity can be queried with SQL.
+//
+// Besides the raw events, the export maintains tables of onboarded assets,
+// NFT and token transfers, the current VM of every bridged NFT, fee changes
+// and pause history; see the schema in schema.go. Exports are incremental:
+// the position of the last exported event is stored with the data, in the
+// same transaction, and events at or before it are skipped.
+package sqlexport
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/index"
+)
+
+// Returns the fully qualified types of the events the exporter consumes
+func EventTypes(env bridge.Environment) []string {
+	return []string{
+		events.TypeID(env, events.TypeOnboarded),
+		events.TypeID(env, events.TypeAssociationUpdated),
+		events.TypeID(env, events.TypeCustomAssociationEstablished),
+		events.TypeID(env, events.TypeBridgedNFTToEVM),
+		events.TypeID(env, events.TypeBridgedNFTFromEVM),
+		events.TypeID(env, events.TypeBridgedTokensToEVM),
+		events.TypeID(env, events.TypeBridgedTokensFromEVM),
+		events.TypeID(env, events.TypeBridgeFeeUpdated),
+		events.TypeID(env, events.TypeBridgePauseStatusUpdated),
+		events.TypeID(env, events.TypeAssetPauseStatusUpdated),
+	}
+}
+
+// Exporter writes bridge events to a SQLite database
+type Exporter struct {
+	// Used to tell EVM-native from Cadence-native onboarded assets
+	BridgeEnv bridge.Environment
+
+	db *sql.DB
+}
+
+// Opens or creates the SQLite database at path and migrates it to the
+// current schema
+func Open(ctx context.Context, path string, env bridge.Environment) (*Exporter, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// Writes are serialized by SQLite, a single connection avoids busy errors
+	db.SetMaxOpenConns(1)
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Exporter{BridgeEnv: env, db: db}, nil
+}
+
+// Returns the underlying database, e.g. for ad hoc queries
+func (e *Exporter) DB() *sql.DB {
+	return e.db
+}
+
+func (e *Exporter) Close() error {
+	return e.db.Close()
+}
+
+// Returns the position of the last exported event, and false if none was exported
+func (e *Exporter) Checkpoint(ctx context.Context) (index.Position, bool, error) {
+	var position index.Position
+	err := e.db.QueryRowContext(ctx,
+		"SELECT block_height, transaction_index, event_index FROM checkpoint WHERE id = 1",
+	).Scan(&position.Height, &position.TransactionIndex, &position.EventIndex)
+	if errors.Is(err, sql.ErrNoRows) {
+		return position, false, nil
+	}
+	if err != nil {
+		return position, false, err
+	}
+	return position, true, nil
+}
+
+// Returns a consumer of the exported events in the Environment, which
+// writes every event in its own transaction along with the checkpoint of the
+// exporter
+func (e *Exporter) Consumer(source events.EventSource, env bridge.Environment, checkpoints events.CheckpointStore, startHeight uint64) *events.Consumer {
+	return events.ConsumerFor(e, EventTypes(env), source, checkpoints, startHeight)
+}
+
+// Writes a record passed by an events.Consumer
+func (e *Exporter) Handle(ctx context.Context, record events.Record) error {
+	return e.Apply(ctx, []events.Record{record})
+}
+
+// Writes records in block order in a single transaction. Records at or
+// before the checkpoint are skipped
+func (e *Exporter) Apply(ctx context.Context, records []events.Record) error {
+	checkpoint, ok, err := e.Checkpoint(ctx)
+	if err != nil {
+		return err
+	}
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	applied := false
+	for _, record := range records {
+		position := index.PositionOf(record)
+		if ok && !checkpoint.Before(position) {
+			continue
+		}
+		if err := e.apply(ctx, tx, record); err != nil {
+			return fmt.Errorf("Cannot export event %d of transaction %s: %w", record.EventIndex, record.TransactionID, err)
+		}
+		checkpoint, ok, applied = position, true, true
+	}
+	if !applied {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO checkpoint (id, block_height, transaction_index, event_index) VALUES (1, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			block_height = excluded.block_height,
+			transaction_index = excluded.transaction_index,
+			event_index = excluded.event_index`,
+		checkpoint.Height, checkpoint.TransactionIndex, checkpoint.EventIndex,
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (e *Exporter) apply(ctx context.Context, tx *sql.Tx, record events.Record) error {
+	data, err := json.Marshal(record.Event)
+	if err != nil {
+		return err
+	}
+	timestamp := blockTimestamp(record)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO events (block_height, block_id, block_timestamp, transaction_id, transaction_index, event_index, type_id, event, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		record.BlockHeight, record.BlockID.String(), timestamp, record.TransactionID.String(),
+		record.TransactionIndex, record.EventIndex, record.TypeID, record.Event.EventType(), string(data),
+	)
+	if err != nil {
+		return err
+	}
+
+	switch event := record.Event.(type) {
+	case events.Onboarded:
+		nativeVM := index.NativeVMCadence
+		if e.BridgeEnv.IsBridgeDefinedType(event.Type) {
+			nativeVM = index.NativeVMEVM
+		}
+		return upsertAsset(ctx, tx, event.Type, event.EVMContractAddress, nativeVM, false, record.BlockHeight)
+	case events.AssociationUpdated:
+		nativeVM := index.NativeVMCadence
+		if e.BridgeEnv.IsBridgeDefinedType(event.Type) {
+			nativeVM = index.NativeVMEVM
+		}
+		return upsertAsset(ctx, tx, event.Type, event.EVMAddress, nativeVM, false, record.BlockHeight)
+	case events.CustomAssociationEstablished:
+		nativeVM, err := bridge.NativeVM(event.NativeVMRawValue)
+		if err != nil {
+			return err
+		}
+		return upsertAsset(ctx, tx, event.Type, event.EVMContractAddress, nativeVM, true, record.BlockHeight)
+	case events.BridgedNFTToEVM:
+		return insertNFTTransfer(ctx, tx, record, timestamp, true, event.Type, event.EVMContractAddress, event.To, event.ID, event.EVMID.String())
+	case events.BridgedNFTFromEVM:
+		return insertNFTTransfer(ctx, tx, record, timestamp, false, event.Type, event.EVMContractAddress, event.Caller, event.ID, event.EVMID.String())
+	case events.BridgedTokensToEVM:
+		return insertTokenTransfer(ctx, tx, record, timestamp, DirectionToEVM, event.Type, event.EVMContractAddress, event.To, event.Amount.String())
+	case events.BridgedTokensFromEVM:
+		return insertTokenTransfer(ctx, tx, record, timestamp, DirectionFromEVM, event.Type, event.EVMContractAddress, event.Caller, event.Amount.String())
+	case events.BridgeFeeUpdated:
+		fee := "base"
+		if event.IsOnboarding {
+			fee = "onboarding"
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO fee_updates (block_height, block_timestamp, transaction_id, event_index, fee, old, new)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			record.BlockHeight, timestamp, record.TransactionID.String(), record.EventIndex, fee, event.Old.String(), event.New.String(),
+		)
+		return err
+	case events.BridgePauseStatusUpdated:
+		return insertPause(ctx, tx, record, timestamp, nil, nil, event.Paused)
+	case events.AssetPauseStatusUpdated:
+		evmAddress := bridge.NormalizeEVMAddress(event.EVMAddress)
+		return insertPause(ctx, tx, record, timestamp, &event.Type, &evmAddress, event.Paused)
+	}
+	return nil
+}
+
+func upsertAsset(ctx context.Context, tx *sql.Tx, cadenceType, evmAddress, nativeVM string, custom bool, height uint64) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO assets (type, evm_address, native_vm, custom, onboarded_height, updated_height)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (type) DO UPDATE SET
+			evm_address = excluded.evm_address,
+			native_vm = excluded.native_vm,
+			custom = MAX(assets.custom, excluded.custom),
+			updated_height = excluded.updated_height`,
+		cadenceType, bridge.NormalizeEVMAddress(evmAddress), nativeVM, custom, height, height,
+	)
+	return err
+}
+
+func insertNFTTransfer(ctx context.Context, tx *sql.Tx, record events.Record, timestamp *string, toEVM bool, cadenceType, evmContractAddress, evmAccount string, id uint64, evmID string) error {
+	direction := DirectionFromEVM
+	if toEVM {
+		direction = DirectionToEVM
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO transfers (block_height, block_timestamp, transaction_id, event_index, direction, kind, type, evm_contract_address, evm_account, nft_id, evm_id)
+		VALUES (?, ?, ?, ?, ?, 'nft', ?, ?, ?, ?, ?)`,
+		record.BlockHeight, timestamp, record.TransactionID.String(), record.EventIndex, direction,
+		cadenceType, bridge.NormalizeEVMAddress(evmContractAddress), bridge.NormalizeEVMAddress(evmAccount), id, evmID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO nfts (type, id, evm_id, in_evm, updated_height) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (type, id) DO UPDATE SET
+			evm_id = excluded.evm_id,
+			in_evm = excluded.in_evm,
+			updated_height = excluded.updated_height`,
+		cadenceType, id, evmID, toEVM, record.BlockHeight,
+	)
+	return err
+}
+
+func insertTokenTransfer(ctx context.Context, tx *sql.Tx, record events.Record, timestamp *string, direction Direction, cadenceType, evmContractAddress, evmAccount, amount string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO transfers (block_height, block_timestamp, transaction_id, event_index, direction, kind, type, evm_contract_address, evm_account, amount)
+		VALUES (?, ?, ?, ?, ?, 'token', ?, ?, ?, ?)`,
+		record.BlockHeight, timestamp, record.TransactionID.String(), record.EventIndex, direction,
+		cadenceType, bridge.NormalizeEVMAddress(evmContractAddress), bridge.NormalizeEVMAddress(evmAccount), amount,
+	)
+	return err
+}
+
+func insertPause(ctx context.Context, tx *sql.Tx, record events.Record, timestamp *string, cadenceType, evmAddress *string, paused bool) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO pause_history (block_height, block_timestamp, transaction_id, event_index, type, evm_address, paused)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		record.BlockHeight, timestamp, record.TransactionID.String(), record.EventIndex, cadenceType, evmAddress, paused,
+	)
+	return err
+}
+
+// Returns the block timestamp in RFC 3339 UTC, or nil if it is unknown
+func blockTimestamp(record events.Record) *string {
+	if record.BlockTimestamp.IsZero() {
+		return nil
+	}
+	timestamp := record.BlockTimestamp.UTC().Format(time.RFC3339)
+	return &timestamp
+}
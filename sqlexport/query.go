@@ -0,0 +1,153 @@
+package sqlexport
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/big"
+
+	"github.com/onflow/cadence"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+)
+
+// Direction of a transfer through the bridge
+type Direction string
+
+const (
+	DirectionToEVM   Direction = "to_evm"
+	DirectionFromEVM Direction = "from_evm"
+)
+
+// Volume is the activity of one asset in one direction on one day
+type Volume struct {
+	// Day as YYYY-MM-DD in UTC, empty for events without block timestamp
+	Day       string
+	Type      string
+	Direction Direction
+	// Number of transfers, i.e. of NFTs for NFT types
+	Transfers int
+	// Total amount of tokens, empty for NFTs. A UFix64 for DirectionToEVM, in
+	// the smallest unit of the ERC20 for DirectionFromEVM
+	Amount string
+}
+
+// Returns the volume bridged per asset per day and direction, ordered by day
+// and type. If cadenceType is not empty, only the volume of that type is returned
+func (e *Exporter) VolumePerDay(ctx context.Context, cadenceType string) ([]Volume, error) {
+	// Amounts are summed exactly in Go rather than as SQLite floats
+	rows, err := e.db.QueryContext(ctx, `
+		SELECT COALESCE(day, ''), type, direction, kind, amount FROM transfers
+		WHERE ? = '' OR type = ?
+		ORDER BY day, type, direction`,
+		cadenceType, cadenceType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	volumes := []Volume{}
+	var total *big.Int
+	var current *Volume
+	flush := func() {
+		if current == nil {
+			return
+		}
+		if total != nil {
+			if current.Direction == DirectionToEVM {
+				current.Amount = formatUFix64(total)
+			} else {
+				current.Amount = total.String()
+			}
+		}
+		volumes = append(volumes, *current)
+	}
+	for rows.Next() {
+		var day, transferType, kind string
+		var direction Direction
+		var amount sql.NullString
+		if err := rows.Scan(&day, &transferType, &direction, &kind, &amount); err != nil {
+			return nil, err
+		}
+		if current == nil || current.Day != day || current.Type != transferType || current.Direction != direction {
+			flush()
+			current = &Volume{Day: day, Type: transferType, Direction: direction}
+			total = nil
+		}
+		current.Transfers++
+		if kind != "token" || !amount.Valid {
+			continue
+		}
+		units, err := parseAmount(direction, amount.String)
+		if err != nil {
+			return nil, err
+		}
+		if total == nil {
+			total = new(big.Int)
+		}
+		total.Add(total, units)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return volumes, nil
+}
+
+// NFT is a bridged NFT and its current VM
+type NFT struct {
+	Type  string
+	ID    uint64
+	EVMID string
+	// Height of the block the NFT was last bridged in
+	UpdatedHeight uint64
+}
+
+// Returns the NFTs of a type that are currently in EVM, ordered by ID
+func (e *Exporter) NFTsInEVM(ctx context.Context, cadenceType string) ([]NFT, error) {
+	rows, err := e.db.QueryContext(ctx, `
+		SELECT type, id, evm_id, updated_height FROM nfts
+		WHERE type = ? AND in_evm = 1
+		ORDER BY id`,
+		cadenceType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nfts := []NFT{}
+	for rows.Next() {
+		var nft NFT
+		if err := rows.Scan(&nft.Type, &nft.ID, &nft.EVMID, &nft.UpdatedHeight); err != nil {
+			return nil, err
+		}
+		nfts = append(nfts, nft)
+	}
+	return nfts, rows.Err()
+}
+
+// Returns an amount in its smallest unit: 1e-8 for UFix64 amounts bridged
+// to EVM, the ERC20 unit for amounts bridged from EVM
+func parseAmount(direction Direction, amount string) (*big.Int, error) {
+	if direction == DirectionToEVM {
+		ufix64, err := cadence.NewUFix64(amount)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid amount %s: %w", amount, err)
+		}
+		return new(big.Int).SetUint64(uint64(ufix64)), nil
+	}
+	units, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("Invalid amount %s", amount)
+	}
+	return units, nil
+}
+
+// Formats units of 1e-8 like UFix64, without the UFix64 range limit
+func formatUFix64(units *big.Int) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(bridge.UFix64Decimals), nil)
+	integer, fraction := new(big.Int).QuoRem(units, scale, new(big.Int))
+	return fmt.Sprintf("%s.%0*d", integer, bridge.UFix64Decimals, fraction)
+}
@@ -0,0 +1,153 @@
+package sqlexport
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Migrations of the export schema, applied in order. The number of applied
+// migrations is stored in PRAGMA user_version, so a migration must never be
+// changed once released; schema changes are appended as new migrations
+var migrations = []string{
+	// 1: initial schema
+	`
+-- Every decoded bridge event. data is the JSON encoding of the Go struct of
+-- the event in package events; UFix64 values are encoded as integers of 1e-8
+CREATE TABLE events (
+	block_height      INTEGER NOT NULL,
+	block_id          TEXT    NOT NULL,
+	-- RFC 3339 UTC, NULL if the source did not provide block timestamps
+	block_timestamp   TEXT,
+	transaction_id    TEXT    NOT NULL,
+	transaction_index INTEGER NOT NULL,
+	event_index       INTEGER NOT NULL,
+	-- Fully qualified event type, e.g. A.1e4aa0b87d10b141.FlowEVMBridge.Onboarded
+	type_id           TEXT    NOT NULL,
+	-- Qualified event name, e.g. FlowEVMBridge.Onboarded
+	event             TEXT    NOT NULL,
+	data              TEXT    NOT NULL,
+	PRIMARY KEY (transaction_id, event_index)
+);
+CREATE INDEX events_by_height ON events (block_height, transaction_index, event_index);
+CREATE INDEX events_by_event ON events (event);
+
+-- Assets onboarded to the bridge or associated through custom cross-VM
+-- associations, with their current association
+CREATE TABLE assets (
+	-- Cadence type identifier
+	type              TEXT    PRIMARY KEY,
+	-- EVM contract address as lowercase hex without 0x prefix
+	evm_address       TEXT    NOT NULL,
+	-- 'cadence' or 'evm', the VM the asset was originally defined in
+	native_vm         TEXT    NOT NULL,
+	-- 1 if associated through a custom cross-VM association
+	custom            INTEGER NOT NULL DEFAULT 0,
+	onboarded_height  INTEGER NOT NULL,
+	updated_height    INTEGER NOT NULL
+);
+CREATE INDEX assets_by_evm_address ON assets (evm_address);
+
+-- Every NFT and fungible token transfer through the bridge
+CREATE TABLE transfers (
+	block_height      INTEGER NOT NULL,
+	block_timestamp   TEXT,
+	-- Day of the block as YYYY-MM-DD in UTC
+	day               TEXT GENERATED ALWAYS AS (substr(block_timestamp, 1, 10)) VIRTUAL,
+	transaction_id    TEXT    NOT NULL,
+	event_index       INTEGER NOT NULL,
+	-- 'to_evm' or 'from_evm'
+	direction         TEXT    NOT NULL,
+	-- 'nft' or 'token'
+	kind              TEXT    NOT NULL,
+	type              TEXT    NOT NULL,
+	evm_contract_address TEXT NOT NULL,
+	-- Recipient for to_evm transfers, caller for from_evm transfers
+	evm_account       TEXT    NOT NULL,
+	-- Cadence and EVM IDs of NFTs, the EVM ID as a decimal string
+	nft_id            INTEGER,
+	evm_id            TEXT,
+	-- Amount of tokens as a decimal string: a UFix64 such as 1.50000000 for
+	-- to_evm transfers, in the smallest unit of the ERC20 for from_evm transfers
+	amount            TEXT,
+	PRIMARY KEY (transaction_id, event_index)
+);
+CREATE INDEX transfers_by_type ON transfers (type, block_height);
+
+-- The current VM of every NFT that was bridged at least once
+CREATE TABLE nfts (
+	type              TEXT    NOT NULL,
+	id                INTEGER NOT NULL,
+	evm_id            TEXT    NOT NULL,
+	-- 1 if the NFT was last bridged to EVM
+	in_evm            INTEGER NOT NULL,
+	updated_height    INTEGER NOT NULL,
+	PRIMARY KEY (type, id)
+);
+
+-- Changes of the onboarding and base bridge fees
+CREATE TABLE fee_updates (
+	block_height      INTEGER NOT NULL,
+	block_timestamp   TEXT,
+	transaction_id    TEXT    NOT NULL,
+	event_index       INTEGER NOT NULL,
+	-- 'onboarding' or 'base'
+	fee               TEXT    NOT NULL,
+	-- UFix64 amounts of FLOW
+	old               TEXT    NOT NULL,
+	new               TEXT    NOT NULL,
+	PRIMARY KEY (transaction_id, event_index)
+);
+
+-- Pause and unpause of the whole bridge or of single assets
+CREATE TABLE pause_history (
+	block_height      INTEGER NOT NULL,
+	block_timestamp   TEXT,
+	transaction_id    TEXT    NOT NULL,
+	event_index       INTEGER NOT NULL,
+	-- NULL when the whole bridge was paused or unpaused
+	type              TEXT,
+	evm_address       TEXT,
+	paused            INTEGER NOT NULL,
+	PRIMARY KEY (transaction_id, event_index)
+);
+
+-- Position of the last exported event, a single row
+CREATE TABLE checkpoint (
+	id                INTEGER PRIMARY KEY CHECK (id = 1),
+	block_height      INTEGER NOT NULL,
+	transaction_index INTEGER NOT NULL,
+	event_index       INTEGER NOT NULL
+);
+`,
+}
+
+// Applies the migrations that were not applied to the database yet
+func migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("Database schema version %d is newer than the supported version %d", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Cannot apply migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not accept bound parameters
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package sqlexport_test
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/runtime/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/events"
+	"github.com/onflow/flow-evm-bridge/index"
+	"github.com/onflow/flow-evm-bridge/sqlexport"
+)
+
+const (
+	exampleNFT   = "A.0ae53cb6e3f42a79.ExampleNFT.NFT"
+	exampleToken = "A.0ae53cb6e3f42a79.ExampleToken.Vault"
+	bridgedToken = "A.1e4aa0b87d10b141.EVMVMBridgedToken_0000000000000000000000000000000000000e20.Vault"
+	recipient    = "0000000000000000000000000000000000000001"
+)
+
+// export applies each batch in turn
+func export(t *testing.T, exporter *sqlexport.Exporter, batches [][]events.Record) {
+	for _, batch := range batches {
+		require.Nil(t, exporter.Apply(context.Background(), batch))
+	}
+}
+
+var day1 = time.Date(2024, 9, 4, 12, 0, 0, 0, time.UTC)
+var day2 = day1.Add(24 * time.Hour)
+
+func record(height uint64, timestamp time.Time, event events.Event) events.Record {
+	return events.Record{
+		Event:          event,
+		TypeID:         "A.1e4aa0b87d10b141." + event.EventType(),
+		BlockHeight:    height,
+		BlockTimestamp: timestamp,
+		TransactionID:  flow.Identifier{byte(height)},
+	}
+}
+
+func ufix64(t *testing.T, value string) cadence.UFix64 {
+	amount, err := cadence.NewUFix64(value)
+	require.Nil(t, err)
+	return amount
+}
+
+func open(t *testing.T, path string) *sqlexport.Exporter {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	exporter, err := sqlexport.Open(context.Background(), path, env)
+	require.Nil(t, err)
+	return exporter
+}
+
+func fixture(t *testing.T) [][]events.Record {
+	return [][]events.Record{
+		{
+			record(1, day1, events.Onboarded{Type: exampleNFT, EVMContractAddress: "0x0000000000000000000000000000000000000721"}),
+			record(2, day1, events.Onboarded{Type: exampleToken, EVMContractAddress: "0000000000000000000000000000000000000B20"}),
+			record(3, day1, events.Onboarded{Type: bridgedToken, EVMContractAddress: "0000000000000000000000000000000000000e20"}),
+			record(4, day1, events.BridgedNFTToEVM{Type: exampleNFT, ID: 1, EVMID: big.NewInt(1), To: recipient}),
+			record(5, day1, events.BridgedNFTToEVM{Type: exampleNFT, ID: 2, EVMID: big.NewInt(2), To: recipient}),
+		},
+		{
+			record(6, day1, events.BridgedTokensToEVM{Type: exampleToken, Amount: ufix64(t, "1.5"), To: recipient}),
+			record(7, day1, events.BridgedTokensToEVM{Type: exampleToken, Amount: ufix64(t, "2.25"), To: recipient}),
+			record(8, day2, events.BridgedTokensFromEVM{Type: bridgedToken, Amount: big.NewInt(1e18), Caller: recipient}),
+			record(9, day2, events.BridgedNFTFromEVM{Type: exampleNFT, ID: 1, EVMID: big.NewInt(1), Caller: recipient}),
+			record(10, day2, events.BridgeFeeUpdated{Old: 0, New: ufix64(t, "0.001")}),
+			record(11, day2, events.AssetPauseStatusUpdated{Paused: true, Type: exampleNFT, EVMAddress: "0x721"}),
+			record(12, day2, events.BridgePauseStatusUpdated{Paused: true}),
+		},
+	}
+}
+
+func count(t *testing.T, exporter *sqlexport.Exporter, table string) int {
+	var n int
+	require.Nil(t, exporter.DB().QueryRow("SELECT COUNT(*) FROM "+table).Scan(&n))
+	return n
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	exporter := open(t, filepath.Join(t.TempDir(), "bridge.db"))
+	defer exporter.Close()
+
+	_, ok, err := exporter.Checkpoint(ctx)
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	export(t, exporter, fixture(t))
+
+	checkpoint, ok, err := exporter.Checkpoint(ctx)
+	require.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, index.Position{Height: 12}, checkpoint)
+
+	assert.Equal(t, 12, count(t, exporter, "events"))
+	assert.Equal(t, 3, count(t, exporter, "assets"))
+	assert.Equal(t, 6, count(t, exporter, "transfers"))
+	assert.Equal(t, 1, count(t, exporter, "fee_updates"))
+	assert.Equal(t, 2, count(t, exporter, "pause_history"))
+
+	var evmAddress, nativeVM string
+	require.Nil(t, exporter.DB().QueryRow("SELECT evm_address, native_vm FROM assets WHERE type = ?", bridgedToken).Scan(&evmAddress, &nativeVM))
+	assert.Equal(t, "0000000000000000000000000000000000000e20", evmAddress)
+	assert.Equal(t, index.NativeVMEVM, nativeVM)
+
+	var fee, newFee string
+	require.Nil(t, exporter.DB().QueryRow("SELECT fee, new FROM fee_updates").Scan(&fee, &newFee))
+	assert.Equal(t, "base", fee)
+	assert.Equal(t, "0.00100000", newFee)
+}
+
+func TestExportIncremental(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.db")
+	batches := fixture(t)
+
+	exporter := open(t, path)
+	export(t, exporter, batches[:1])
+	require.Nil(t, exporter.Close())
+
+	// Reopening keeps the schema and data, and replaying an overlapping
+	// range does not duplicate events
+	exporter = open(t, path)
+	defer exporter.Close()
+	export(t, exporter, [][]events.Record{
+		append(batches[0][3:], batches[1]...),
+	})
+	assert.Equal(t, 12, count(t, exporter, "events"))
+}
+
+func TestVolumePerDay(t *testing.T) {
+	ctx := context.Background()
+	exporter := open(t, filepath.Join(t.TempDir(), "bridge.db"))
+	defer exporter.Close()
+	export(t, exporter, fixture(t))
+
+	volumes, err := exporter.VolumePerDay(ctx, "")
+	require.Nil(t, err)
+	assert.Equal(t, []sqlexport.Volume{
+		{Day: "2024-09-04", Type: exampleNFT, Direction: sqlexport.DirectionToEVM, Transfers: 2},
+		{Day: "2024-09-04", Type: exampleToken, Direction: sqlexport.DirectionToEVM, Transfers: 2, Amount: "3.75000000"},
+		{Day: "2024-09-05", Type: exampleNFT, Direction: sqlexport.DirectionFromEVM, Transfers: 1},
+		{Day: "2024-09-05", Type: bridgedToken, Direction: sqlexport.DirectionFromEVM, Transfers: 1, Amount: "1000000000000000000"},
+	}, volumes)
+
+	volumes, err = exporter.VolumePerDay(ctx, exampleToken)
+	require.Nil(t, err)
+	assert.Len(t, volumes, 1)
+}
+
+func TestNFTsInEVM(t *testing.T) {
+	ctx := context.Background()
+	exporter := open(t, filepath.Join(t.TempDir(), "bridge.db"))
+	defer exporter.Close()
+	export(t, exporter, fixture(t))
+
+	nfts, err := exporter.NFTsInEVM(ctx, exampleNFT)
+	require.Nil(t, err)
+	assert.Equal(t, []sqlexport.NFT{{Type: exampleNFT, ID: 2, EVMID: "2", UpdatedHeight: 5}}, nfts)
+}
+
+func TestExporterConsumer(t *testing.T) {
+	env, _, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	address, err := common.HexToAddress(env.FlowEVMBridgeConfigAddress)
+	require.Nil(t, err)
+	location := common.NewAddressLocation(nil, address, "FlowEVMBridgeConfig")
+	event := cadence.NewEvent([]cadence.Value{cadence.UFix64(0), ufix64(t, "0.001"), cadence.Bool(false)}).
+		WithType(cadence.NewEventType(location, events.TypeBridgeFeeUpdated, []cadence.Field{
+			{Identifier: "old", Type: cadence.UFix64Type},
+			{Identifier: "new", Type: cadence.UFix64Type},
+			{Identifier: "isOnboarding", Type: cadence.BoolType},
+		}, nil))
+	source := events.NewMemorySource()
+	source.Add(7, flow.Event{Type: event.EventType.ID(), TransactionID: flow.Identifier{1}, Value: event})
+
+	ctx := context.Background()
+	exporter := open(t, filepath.Join(t.TempDir(), "bridge.db"))
+	defer exporter.Close()
+	checkpoints := &events.MemoryCheckpointStore{}
+	_, err = exporter.Consumer(source, env, checkpoints, 1).Sync(ctx)
+	require.Nil(t, err)
+	// Resuming from the checkpoint exports no event twice
+	_, err = exporter.Consumer(source, env, checkpoints, 1).Sync(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, 1, count(t, exporter, "events"))
+	assert.Equal(t, 1, count(t, exporter, "fee_updates"))
+}
@@ -48,6 +48,8 @@ import (
 //go:embed cadence/scripts/bridge/get_associated_evm_address.cdc
 //go:embed cadence/scripts/bridge/get_associated_type.cdc
 //go:embed cadence/scripts/bridge/get_bridge_coa_address.cdc
+//go:embed cadence/scripts/bridge/get_bridge_router_type.cdc
+//go:embed cadence/scripts/bridge/get_bridged_asset_contract_code.cdc
 //go:embed cadence/scripts/bridge/get_gas_limit.cdc
 //go:embed cadence/scripts/bridge/is_cadence_type_blocked.cdc
 //go:embed cadence/scripts/bridge/is_evm_address_blocked.cdc
@@ -67,11 +69,14 @@ import (
 //go:embed cadence/scripts/escrow/resolve_locked_vault_metadata.cdc
 
 //go:embed cadence/scripts/evm/call.cdc
+//go:embed cadence/scripts/evm/get_attoflow_balance.cdc
 //go:embed cadence/scripts/evm/get_balance.cdc
 //go:embed cadence/scripts/evm/get_evm_address_string.cdc
 //go:embed cadence/scripts/evm/get_evm_address_string_from_bytes.cdc
+//go:embed cadence/scripts/evm/get_nonce.cdc
 
 //go:embed cadence/scripts/nft/get_evm_id_from_evm_nft.cdc
+//go:embed cadence/scripts/nft/get_evm_pointer_from_identifier.cdc
 //go:embed cadence/scripts/nft/get_ids.cdc
 //go:embed cadence/scripts/nft/has_collection_configured.cdc
 
@@ -79,24 +84,35 @@ import (
 
 //go:embed cadence/scripts/tokens/get_all_vault_info_from_storage.cdc
 //go:embed cadence/scripts/tokens/get_balance.cdc
+//go:embed cadence/scripts/tokens/get_full_cadence_evm_balance.cdc
 //go:embed cadence/scripts/tokens/has_vault_configured.cdc
 //go:embed cadence/scripts/tokens/total_supply.cdc
 
 //go:embed cadence/scripts/utils/balance_of.cdc
 //go:embed cadence/scripts/utils/derive_bridged_nft_contract_name.cdc
 //go:embed cadence/scripts/utils/derive_bridged_token_contract_name.cdc
+//go:embed cadence/scripts/utils/get_account_contract_names.cdc
+//go:embed cadence/scripts/utils/get_declared_cadence_address.cdc
+//go:embed cadence/scripts/utils/get_declared_cadence_type.cdc
+//go:embed cadence/scripts/utils/get_delegated_deployer_address.cdc
 //go:embed cadence/scripts/utils/get_deployer_address.cdc
 //go:embed cadence/scripts/utils/get_evm_address_from_hex.cdc
 //go:embed cadence/scripts/utils/get_factory_address.cdc
+//go:embed cadence/scripts/utils/get_registrar_address.cdc
 //go:embed cadence/scripts/utils/get_registry_address.cdc
 //go:embed cadence/scripts/utils/get_token_decimals.cdc
+//go:embed cadence/scripts/utils/is_erc721.cdc
 //go:embed cadence/scripts/utils/is_owner.cdc
 //go:embed cadence/scripts/utils/is_owner_or_approved.cdc
+//go:embed cadence/scripts/utils/supports_cadence_native_nft_evm_interfaces.cdc
+//go:embed cadence/scripts/utils/supports_icross_vm_bridge_callable.cdc
+//go:embed cadence/scripts/utils/supports_icross_vm_bridge_erc721_fulfillment.cdc
 //go:embed cadence/scripts/utils/token_uri.cdc
 //go:embed cadence/scripts/utils/total_supply.cdc
 
 //go:embed cadence/transactions/bridge/admin/deploy_bridge_utils.cdc
 //go:embed cadence/transactions/bridge/admin/deploy_bridge_accessor.cdc
+//go:embed cadence/transactions/bridge/admin/deploy_contract.cdc
 
 //go:embed cadence/transactions/bridge/admin/blocklist/block_cadence_type.cdc
 //go:embed cadence/transactions/bridge/admin/blocklist/block_evm_address.cdc
@@ -155,10 +171,19 @@ import (
 //go:embed cadence/transactions/evm/transfer_flow_to_evm_address.cdc
 //go:embed cadence/transactions/evm/withdraw.cdc
 
+//go:embed cadence/transactions/example-assets/setup/setup_generic_nft_collection.cdc
+//go:embed cadence/transactions/example-assets/setup/setup_generic_vault.cdc
+
+//go:embed cadence/transactions/flow-token/transfer_flow_to_cadence_or_evm.cdc
+
 //go:embed cadence/tests/test_helpers.cdc
 
 //go:embed cadence/args/bridged-nft-code-chunks-args-emulator.json
+//go:embed cadence/args/bridged-nft-code-chunks-args-mainnet.json
+//go:embed cadence/args/bridged-nft-code-chunks-args-testnet.json
 //go:embed cadence/args/bridged-token-code-chunks-args-emulator.json
+//go:embed cadence/args/bridged-token-code-chunks-args-mainnet.json
+//go:embed cadence/args/bridged-token-code-chunks-args-testnet.json
 //go:embed cadence/args/deploy-factory-args.json
 //go:embed cadence/args/deploy-deployment-registry-args.json
 //go:embed cadence/args/deploy-erc20-deployer-args.json
@@ -458,12 +483,18 @@ type Element struct {
 // Gets JSON Arguments with the chunked versions of
 // the Cadence NFT or Fungible Token template contract
 func GetCadenceTokenChunkedJSONArguments(nft bool) []string {
+	return GetCadenceTokenChunkedJSONArgumentsForNetwork(nft, NetworkEmulator)
+}
+
+// Gets JSON Arguments with the chunked versions of the Cadence NFT
+// or Fungible Token template contract for the given network
+func GetCadenceTokenChunkedJSONArgumentsForNetwork(nft bool, network string) []string {
 	filePath := ""
 
 	if nft {
-		filePath = "cadence/args/bridged-nft-code-chunks-args-emulator.json"
+		filePath = "cadence/args/bridged-nft-code-chunks-args-" + network + ".json"
 	} else {
-		filePath = "cadence/args/bridged-token-code-chunks-args-emulator.json"
+		filePath = "cadence/args/bridged-token-code-chunks-args-" + network + ".json"
 	}
 
 	file, err := content.Open(filePath)
@@ -185,6 +185,13 @@ func TestScripts(t *testing.T) {
 	SetAllAddresses(&bridgeEnv, &coreEnv)
 
 	GetScriptShouldSucceed(t, pathPrefix+"bridge/batch_get_associated_evm_address.cdc", bridgeEnv, coreEnv)
+	GetScriptShouldSucceed(t, pathPrefix+"nft/get_evm_pointer_from_identifier.cdc", bridgeEnv, coreEnv)
+	GetScriptShouldSucceed(t, pathPrefix+"utils/is_erc721.cdc", bridgeEnv, coreEnv)
+	GetScriptShouldSucceed(t, pathPrefix+"utils/supports_icross_vm_bridge_callable.cdc", bridgeEnv, coreEnv)
+	GetScriptShouldSucceed(t, pathPrefix+"tokens/get_full_cadence_evm_balance.cdc", bridgeEnv, coreEnv)
+	GetScriptShouldSucceed(t, pathPrefix+"evm/get_nonce.cdc", bridgeEnv, coreEnv)
+	GetScriptShouldSucceed(t, pathPrefix+"utils/get_registrar_address.cdc", bridgeEnv, coreEnv)
+	GetScriptShouldSucceed(t, pathPrefix+"bridge/get_bridged_asset_contract_code.cdc", bridgeEnv, coreEnv)
 }
 
 // Tests that a specific transaction path should succeed when retrieving it
@@ -220,4 +227,6 @@ func TestTransactions(t *testing.T) {
 
 	GetTransactionShouldSucceed(t, pathPrefix+"bridge/admin/blocklist/block_evm_address.cdc", bridgeEnv, coreEnv)
 	GetTransactionShouldSucceed(t, pathPrefix+"evm/create_account.cdc", bridgeEnv, coreEnv)
+	GetTransactionShouldSucceed(t, pathPrefix+"example-assets/setup/setup_generic_nft_collection.cdc", bridgeEnv, coreEnv)
+	GetTransactionShouldSucceed(t, pathPrefix+"flow-token/transfer_flow_to_cadence_or_evm.cdc", bridgeEnv, coreEnv)
 }
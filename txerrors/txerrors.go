@@ -0,0 +1,234 @@
+// Package txerrors classifies failed bridge transactions.
+//
+// Failed transactions only carry the Cadence error, a long string embedding
+// the panic or condition message that aborted execution. The Catalog maps
+// fragments of the messages declared by the bridge contracts, and of the
+// Flow errors bridge transactions commonly hit, to stable codes. Classify
+// wraps an error with the matching entry so callers can test it with
+// errors.Is and decide whether sending the transaction again may succeed
+package txerrors
+
+import (
+	"errors"
+	"strings"
+)
+
+// Sentinels of the classified errors, matched with errors.Is
+var (
+	ErrBridgePaused          = errors.New("bridge is paused")
+	ErrTypePaused            = errors.New("bridging the asset is paused")
+	ErrTypeBlocked           = errors.New("asset is blocked from onboarding")
+	ErrBridgingNotAllowed    = errors.New("asset opted out of bridging")
+	ErrRequiresOnboarding    = errors.New("asset requires onboarding")
+	ErrOnboardingNotNeeded   = errors.New("asset does not need onboarding")
+	ErrUnsupportedAsset      = errors.New("asset is not supported by the bridge")
+	ErrNoAssociation         = errors.New("asset has no association in the other VM")
+	ErrCrossVMMisconfigured  = errors.New("cross-VM asset is misconfigured")
+	ErrHandlerUnavailable    = errors.New("token handler is unavailable")
+	ErrFeeExceedsAllowance   = errors.New("bridge fee exceeds the allowance of the transaction")
+	ErrFeeProviderExpired    = errors.New("fee provider expired")
+	ErrInsufficientBalance   = errors.New("insufficient balance")
+	ErrNotOwner              = errors.New("asset is not owned by the caller")
+	ErrAmountTooSmall        = errors.New("amount is too small to bridge")
+	ErrAmountOutOfRange      = errors.New("amount cannot be converted between VMs")
+	ErrEVMCallFailed         = errors.New("EVM call failed")
+	ErrAccountNotConfigured  = errors.New("account is not configured")
+	ErrComputationLimit      = errors.New("computation limit exceeded")
+	ErrStorageCapacity       = errors.New("storage capacity exceeded")
+	ErrInvalidSequenceNumber = errors.New("invalid proposal key sequence number")
+	ErrUnknown               = errors.New("unknown bridge error")
+)
+
+// Contracts declaring the messages of the catalog
+const (
+	sourceBridge         = "cadence/contracts/bridge/FlowEVMBridge.cdc"
+	sourceUtils          = "cadence/contracts/bridge/FlowEVMBridgeUtils.cdc"
+	sourceConfig         = "cadence/contracts/bridge/FlowEVMBridgeConfig.cdc"
+	sourceHandlers       = "cadence/contracts/bridge/FlowEVMBridgeHandlers.cdc"
+	sourceScopedProvider = "cadence/contracts/utils/ScopedFTProviders.cdc"
+	sourceTransactions   = "cadence/transactions/bridge"
+)
+
+// Entry maps the messages of an error to its code
+type Entry struct {
+	Err error
+	// Stable identifier of the error, such as bridge_paused
+	Code string
+	// Whether sending the same transaction again may succeed, for example
+	// once the bridge is unpaused
+	Retryable bool
+	// Fragments of the messages, without their interpolated values. The
+	// first entry with a fragment contained in an error wins
+	Fragments []string
+	// Path of the file declaring the messages, empty for errors raised by
+	// Flow or the token standards
+	Source string
+}
+
+// Catalog of the errors, more specific messages first
+var Catalog = []Entry{
+	{Err: ErrBridgePaused, Code: "bridge_paused", Retryable: true, Source: sourceBridge, Fragments: []string{
+		"Bridge operations are currently paused",
+	}},
+	{Err: ErrTypePaused, Code: "type_paused", Retryable: true, Source: sourceBridge, Fragments: []string{
+		"Bridging is currently paused for this NFT",
+		"Bridging is currently paused for this token",
+		"Bridging is currently paused for type",
+	}},
+	{Err: ErrTypeBlocked, Code: "type_blocked", Source: sourceBridge, Fragments: []string{
+		"is currently blocked from being onboarded",
+		"has been blocked from onboarding",
+	}},
+	{Err: ErrBridgingNotAllowed, Code: "bridging_not_allowed", Source: sourceBridge, Fragments: []string{
+		"developers have opted out of VM bridge integration",
+		"This contract is not supported as defined by the project's development team",
+	}},
+	{Err: ErrRequiresOnboarding, Code: "requires_onboarding", Source: sourceBridge, Fragments: []string{
+		"NFT must first be onboarded",
+		"FungibleToken must first be onboarded",
+	}},
+	{Err: ErrOnboardingNotNeeded, Code: "onboarding_not_needed", Source: sourceBridge, Fragments: []string{
+		"Onboarding is not needed for this type",
+		"Onboarding is not needed for this contract",
+	}},
+	{Err: ErrCrossVMMisconfigured, Code: "cross_vm_misconfigured", Source: sourceBridge, Fragments: []string{
+		"Mismatched type pointers",
+		"Mismatched Cadence Address pointers",
+		"Cross-VM NFTs must be implemented as ERC721 exclusively",
+		"Could not retrieve a Cadence Type declaration from the EVM contract",
+		"Could not retrieve a Cadence address declaration from the EVM contract",
+		"Could not recover declared VM bridge address from EVM contract",
+		"is not supported by the type",
+		"Could not find custom association for cross-VM NFT",
+	}},
+	{Err: ErrUnsupportedAsset, Code: "unsupported_asset", Source: sourceBridge, Fragments: []string{
+		"Mixed asset types are not yet supported",
+		"Only Cadence-native assets can be onboarded by Type",
+		"Attempted to onboard unsupported type",
+	}},
+	{Err: ErrUnsupportedAsset, Code: "unsupported_asset", Source: sourceUtils, Fragments: []string{
+		"defines an asset that is not currently supported by the bridge",
+		"Contract is mixed asset and is not currently supported by the bridge",
+		"This type is not a supported Flow asset type.",
+	}},
+	{Err: ErrNoAssociation, Code: "no_association", Source: sourceBridge, Fragments: []string{
+		"No EVMAddress found for token type",
+		"No EVMAddress found for vault type",
+	}},
+	{Err: ErrNoAssociation, Code: "no_association", Source: sourceConfig, Fragments: []string{
+		"There was no association found for type",
+	}},
+	{Err: ErrHandlerUnavailable, Code: "handler_unavailable", Source: sourceHandlers, Fragments: []string{
+		"Cannot bridge - Minter not set in",
+	}},
+	{Err: ErrHandlerUnavailable, Code: "handler_unavailable", Source: sourceConfig, Fragments: []string{
+		"No handler found for target Type",
+	}},
+	// The same transaction approves the same allowance for an unchanged fee
+	{Err: ErrFeeExceedsAllowance, Code: "fee_exceeds_allowance", Source: sourceScopedProvider, Fragments: []string{
+		"cannot withdraw tokens. filter of type",
+	}},
+	{Err: ErrFeeProviderExpired, Code: "fee_provider_expired", Retryable: true, Source: sourceScopedProvider, Fragments: []string{
+		"provider has expired",
+	}},
+	{Err: ErrInsufficientBalance, Code: "insufficient_balance", Source: sourceUtils, Fragments: []string{
+		"Caller does not have sufficient balance to bridge requested tokens",
+		"Fee provider did not return the requested fee",
+	}},
+	{Err: ErrInsufficientBalance, Code: "insufficient_balance", Fragments: []string{
+		"Amount withdrawn must be less than or equal than the balance of the Vault",
+		"is greater than the balance of the Vault",
+	}},
+	{Err: ErrNotOwner, Code: "not_owner", Source: sourceUtils, Fragments: []string{
+		"Named owner is not the owner of the ERC721",
+		"Bridge COA does not own ERC721 requesting to be transferred",
+	}},
+	{Err: ErrNotOwner, Code: "not_owner", Source: sourceBridge, Fragments: []string{
+		"is not in escrow",
+	}},
+	{Err: ErrAmountTooSmall, Code: "amount_too_small", Source: sourceHandlers, Fragments: []string{
+		"Amount to bridge must be greater than 0",
+		"try bridging a larger amount to avoid UFix64 precision loss",
+	}},
+	{Err: ErrAmountOutOfRange, Code: "amount_out_of_range", Source: sourceUtils, Fragments: []string{
+		"exceeds max UFix64 value",
+		"Fractional digits exceed the defined decimal places",
+		"Value too large to fit into UInt64",
+	}},
+	{Err: ErrAccountNotConfigured, Code: "account_not_configured", Source: sourceTransactions, Fragments: []string{
+		"Could not borrow COA signer's account at path /storage/evm",
+		"Could not borrow a NonFungibleToken Collection from the signer's storage path",
+	}},
+	{Err: ErrEVMCallFailed, Code: "evm_call_failed", Source: sourceUtils, Fragments: []string{
+		"Call to bridge factory failed",
+		"transfer call to ERC20 contract failed",
+		"safeTransferFrom call to ERC721 transferring NFT from escrow to bridge recipient failed",
+		"failed with error code",
+	}},
+	{Err: ErrComputationLimit, Code: "computation_limit", Fragments: []string{
+		"[Error Code: 1110]",
+		"computation exceeds limit",
+	}},
+	{Err: ErrStorageCapacity, Code: "storage_capacity", Fragments: []string{
+		"[Error Code: 1103]",
+		"storage capacity exceeded",
+	}},
+	{Err: ErrInvalidSequenceNumber, Code: "invalid_sequence_number", Retryable: true, Fragments: []string{
+		"[Error Code: 1007]",
+		"invalid proposal key",
+	}},
+}
+
+// Error is a transaction error classified by the Catalog
+type Error struct {
+	// Sentinel of the entry, ErrUnknown if no entry matched
+	Kind      error
+	Code      string
+	Retryable bool
+	// Fragment of the message that matched, empty if none
+	Fragment string
+	Err      error
+}
+
+func (e *Error) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return e.Code + ": " + e.Err.Error()
+}
+
+// Unwraps to the sentinel of the error and the transaction error
+func (e *Error) Unwrap() []error {
+	if e == nil {
+		return nil
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Returns err classified by the first entry of the Catalog matching it as an
+// *Error, nil if err is nil. Errors already classified are returned as is
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified
+	}
+	message := err.Error()
+	for _, entry := range Catalog {
+		for _, fragment := range entry.Fragments {
+			if strings.Contains(message, fragment) {
+				return &Error{Kind: entry.Err, Code: entry.Code, Retryable: entry.Retryable, Fragment: fragment, Err: err}
+			}
+		}
+	}
+	return &Error{Kind: ErrUnknown, Code: "unknown", Err: err}
+}
+
+// Returns whether sending the transaction that failed with err again may
+// succeed. Unknown errors are not retryable
+func IsRetryable(err error) bool {
+	var classified *Error
+	return errors.As(Classify(err), &classified) && classified.Retryable
+}
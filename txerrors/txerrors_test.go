@@ -0,0 +1,85 @@
+package txerrors_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onflow/flow-evm-bridge/txerrors"
+)
+
+// Returns the contents of the file at path, or of every Cadence file below
+// it if path is a directory
+func readSource(t *testing.T, path string) string {
+	var contents strings.Builder
+	err := filepath.WalkDir(filepath.Join("..", path), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".cdc" {
+			return err
+		}
+		code, err := os.ReadFile(path)
+		contents.Write(code)
+		return err
+	})
+	require.Nil(t, err)
+	return contents.String()
+}
+
+func TestCatalogMatchesSources(t *testing.T) {
+	codes := map[error]string{}
+	for _, entry := range txerrors.Catalog {
+		if code, ok := codes[entry.Err]; ok {
+			assert.Equal(t, code, entry.Code, "entries of %s have different codes", entry.Err)
+		}
+		codes[entry.Err] = entry.Code
+		if entry.Source == "" {
+			continue
+		}
+		source := readSource(t, entry.Source)
+		for _, fragment := range entry.Fragments {
+			assert.Contains(t, source, fragment, "%s no longer declares %q", entry.Source, fragment)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for message, expected := range map[string]error{
+		"[Error Code: 1101] error caused by: 1 error occurred:\n\t* transaction execute failed: [Error Code: 1101] cadence runtime error: Execution failed:\nerror: pre-condition failed: Bridge operations are currently paused\n   --> 1e4aa0b87d10b141.FlowEVMBridge:354:12": txerrors.ErrBridgePaused,
+		"error: pre-condition failed: This Cadence Type A.0ae53cb6e3f42a79.ExampleNFT.NFT is currently blocked from being onboarded":                                                                                                                                            txerrors.ErrTypeBlocked,
+		"error: pre-condition failed: NFT must first be onboarded":                                                          txerrors.ErrRequiresOnboarding,
+		"error: panic: Bridging is currently paused for type A.0ae53cb6e3f42a79.ExampleNFT.NFT":                             txerrors.ErrTypePaused,
+		"error: panic: cannot withdraw tokens. filter of type A.1e4aa0b87d10b141.ScopedFTProviders.AllowanceFilter failed.": txerrors.ErrFeeExceedsAllowance,
+		"[Error Code: 1110] computation exceeds limit (9999)":                                                               txerrors.ErrComputationLimit,
+		"error: assertion failed: Named owner is not the owner of the ERC721":                                               txerrors.ErrNotOwner,
+		"error: something unexpected": txerrors.ErrUnknown,
+	} {
+		err := fmt.Errorf("cadence/transactions/bridge/nft/bridge_nft_to_evm.cdc failed: %w", errors.New(message))
+		classified := txerrors.Classify(err)
+		assert.ErrorIs(t, classified, expected, message)
+		// The transaction error stays reachable
+		assert.ErrorIs(t, classified, err)
+	}
+
+	var classified *txerrors.Error
+	require.True(t, errors.As(txerrors.Classify(errors.New("pre-condition failed: Bridging is currently paused for this token")), &classified))
+	assert.Equal(t, "type_paused", classified.Code)
+	assert.True(t, classified.Retryable)
+	assert.Equal(t, "Bridging is currently paused for this token", classified.Fragment)
+	assert.Same(t, classified, txerrors.Classify(fmt.Errorf("retrying: %w", classified)))
+
+	// A nil error stays an untyped nil
+	assert.True(t, txerrors.Classify(nil) == nil)
+	assert.False(t, errors.Is(txerrors.Classify(nil), txerrors.ErrUnknown))
+	var unset *txerrors.Error
+	assert.Nil(t, unset.Unwrap())
+	assert.True(t, txerrors.IsRetryable(errors.New("Bridge operations are currently paused")))
+	assert.False(t, txerrors.IsRetryable(errors.New("FungibleToken must first be onboarded")))
+	assert.False(t, txerrors.IsRetryable(errors.New("cannot withdraw tokens. filter of type A.1e4aa0b87d10b141.ScopedFTProviders.AllowanceFilter failed.")))
+	assert.False(t, txerrors.IsRetryable(nil))
+}
@@ -0,0 +1,264 @@
+// Package upgrade checks that bridge contracts can be updated in place.
+//
+// Cadence rejects contract updates which, among others, remove or add fields,
+// change field types or alter the conformances of resources and structs. The
+// checks here run the same validation Cadence runs on update_contract, so an
+// invalid upgrade is caught before it is attempted on-chain.
+package upgrade
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/onflow/cadence/runtime/ast"
+	"github.com/onflow/cadence/runtime/common"
+	cadenceErrors "github.com/onflow/cadence/runtime/errors"
+	"github.com/onflow/cadence/runtime/parser"
+	"github.com/onflow/cadence/runtime/stdlib"
+	coreContracts "github.com/onflow/flow-core-contracts/lib/go/templates"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+)
+
+// Problem is a single reason an update is invalid
+type Problem struct {
+	Message string `json:"message"`
+	// Position of the problem in the new code, zero if unknown
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+}
+
+// Report is the result of checking the update of one contract
+type Report struct {
+	Contract string    `json:"contract"`
+	Problems []Problem `json:"problems"`
+}
+
+// Returns whether the update would be accepted
+func (r *Report) Valid() bool {
+	return len(r.Problems) == 0
+}
+
+// Checks whether the contract deployed with oldCode can be updated to newCode.
+// Placeholder imports in either version are rendered with the given
+// Environments so both versions refer to the same import locations
+func Check(name string, oldCode, newCode []byte, bridgeEnv bridge.Environment, coreEnv coreContracts.Environment) (*Report, error) {
+	oldProgram, err := parse(oldCode, bridgeEnv, coreEnv)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse previous version of %s: %w", name, err)
+	}
+	newProgram, err := parse(newCode, bridgeEnv, coreEnv)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse new version of %s: %w", name, err)
+	}
+
+	validator := stdlib.NewContractUpdateValidator(
+		location(name, bridgeEnv),
+		name,
+		contractNamesProvider{},
+		oldProgram,
+		newProgram,
+	)
+
+	report := &Report{Contract: name, Problems: []Problem{}}
+	if err := validator.Validate(); err != nil {
+		report.Problems = problems(err)
+	}
+	return report, nil
+}
+
+// Checks whether the contract deployed with previousCode, read from a file,
+// a git ref or fetched from chain, can be updated to the embedded contract
+func CheckEmbedded(name string, previousCode []byte, bridgeEnv bridge.Environment, coreEnv coreContracts.Environment) (*Report, error) {
+	graph, err := bridge.DependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+	path := graph.Path(name)
+	if path == "" {
+		return nil, errors.New("Unknown bridge contract " + name)
+	}
+	newCode, err := bridge.GetCadenceContractCode(path, bridgeEnv, coreEnv)
+	if err != nil {
+		return nil, err
+	}
+	return Check(name, previousCode, newCode, bridgeEnv, coreEnv)
+}
+
+// Checks every embedded contract against its version at the given git ref of
+// the repository at repoDir. Contracts that do not exist at the ref are new
+// and are skipped
+func CheckAgainstGitRef(repoDir, ref string, bridgeEnv bridge.Environment, coreEnv coreContracts.Environment) ([]Report, error) {
+	graph, err := bridge.DependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []Report{}
+	for _, name := range graph.Contracts() {
+		previous, err := ReadGitRef(repoDir, ref, graph.Path(name))
+		if errors.Is(err, errNotInRef) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		report, err := CheckEmbedded(name, previous, bridgeEnv, coreEnv)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, *report)
+	}
+	return reports, nil
+}
+
+var errNotInRef = errors.New("path does not exist in git ref")
+
+// Reads the file at path as of the given git ref of the repository at repoDir
+func ReadGitRef(repoDir, ref, path string) ([]byte, error) {
+	cmd := exec.Command("git", "show", ref+":"+path)
+	cmd.Dir = repoDir
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		message := stderr.String()
+		if strings.Contains(message, "does not exist") || strings.Contains(message, "exists on disk, but not in") {
+			return nil, fmt.Errorf("%s at %s: %w", path, ref, errNotInRef)
+		}
+		return nil, fmt.Errorf("Cannot read %s at %s: %s", path, ref, strings.TrimSpace(message))
+	}
+	return out, nil
+}
+
+func parse(code []byte, bridgeEnv bridge.Environment, coreEnv coreContracts.Environment) (*ast.Program, error) {
+	rendered := bridge.ReplaceAddresses(string(code), bridgeEnv, coreEnv)
+	return parser.ParseProgram(nil, []byte(removeStringTemplates(rendered)), parser.Config{})
+}
+
+// Removes the template expressions, such as \(id) in "id \(id)", from every
+// string literal. The Cadence parser this module depends on predates string
+// templates, and update validation only compares declarations, never the
+// contents of string literals
+func removeStringTemplates(code string) string {
+	var builder strings.Builder
+	builder.Grow(len(code))
+
+	inString := false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if !inString {
+			switch {
+			case strings.HasPrefix(code[i:], "//"):
+				end := strings.IndexByte(code[i:], '\n')
+				if end < 0 {
+					end = len(code) - i
+				}
+				builder.WriteString(code[i : i+end])
+				i += end - 1
+				continue
+			case strings.HasPrefix(code[i:], "/*"):
+				end := strings.Index(code[i+2:], "*/")
+				if end < 0 {
+					builder.WriteString(code[i:])
+					return builder.String()
+				}
+				builder.WriteString(code[i : i+end+4])
+				i += end + 3
+				continue
+			case c == '"':
+				inString = true
+			}
+			builder.WriteByte(c)
+			continue
+		}
+		switch {
+		case c == '\\' && i+1 < len(code) && code[i+1] == '(':
+			i = skipTemplate(code, i+2)
+		case c == '\\' && i+1 < len(code):
+			builder.WriteByte(c)
+			builder.WriteByte(code[i+1])
+			i++
+		default:
+			if c == '"' {
+				inString = false
+			}
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+// Returns the index of the parenthesis closing the template expression that
+// starts at start, skipping nested parentheses and string literals
+func skipTemplate(code string, start int) int {
+	depth := 1
+	for i := start; i < len(code); i++ {
+		switch code[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '"':
+			for i++; i < len(code) && code[i] != '"'; i++ {
+				if code[i] == '\\' {
+					i++
+				}
+			}
+		}
+	}
+	return len(code) - 1
+}
+
+func location(name string, bridgeEnv bridge.Environment) common.Location {
+	address, err := common.HexToAddress(bridgeEnv.ContractAddress(name))
+	if err != nil || bridgeEnv.ContractAddress(name) == "" {
+		return common.StringLocation(name)
+	}
+	return common.NewAddressLocation(nil, address, name)
+}
+
+// Flattens the validation error into one problem per reason
+func problems(err error) []Problem {
+	var parent cadenceErrors.ParentError
+	if errors.As(err, &parent) {
+		result := []Problem{}
+		for _, child := range parent.ChildErrors() {
+			result = append(result, problems(child)...)
+		}
+		return result
+	}
+
+	problem := Problem{Message: err.Error()}
+	var secondary cadenceErrors.SecondaryError
+	if errors.As(err, &secondary) {
+		problem.Message += ": " + secondary.SecondaryError()
+	}
+	var positioned ast.HasPosition
+	if errors.As(err, &positioned) {
+		position := positioned.StartPosition()
+		problem.Line = position.Line
+		problem.Column = position.Column
+	}
+	return []Problem{problem}
+}
+
+// Bridge contracts import contracts by name, so account contract names are
+// only needed for imports of whole accounts which the bridge does not use
+type contractNamesProvider struct{}
+
+func (contractNamesProvider) GetAccountContractNames(address common.Address) ([]string, error) {
+	return nil, errors.New("Cannot resolve contract names of account " + address.HexWithPrefix())
+}
@@ -0,0 +1,117 @@
+package upgrade_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bridge "github.com/onflow/flow-evm-bridge"
+	"github.com/onflow/flow-evm-bridge/upgrade"
+)
+
+const configPath = "cadence/contracts/bridge/FlowEVMBridgeConfig.cdc"
+
+func TestCheckEmbedded(t *testing.T) {
+	bridgeEnv, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+
+	current, err := bridge.GetCadenceContractCode(configPath, bridgeEnv, coreEnv)
+	require.Nil(t, err)
+
+	report, err := upgrade.CheckEmbedded("FlowEVMBridgeConfig", current, bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	assert.True(t, report.Valid(), "%v", report.Problems)
+
+	// Fields cannot be added to a deployed contract
+	previous := strings.Replace(string(current), "    access(all)\n    var gasLimit: UInt64\n", "", 1)
+	require.NotEqual(t, string(current), previous)
+	report, err = upgrade.CheckEmbedded("FlowEVMBridgeConfig", []byte(previous), bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	require.False(t, report.Valid())
+	assert.Contains(t, report.Problems[0].Message, "`gasLimit`")
+
+	// Field types cannot change
+	previous = strings.Replace(string(current), "    var gasLimit: UInt64\n", "    var gasLimit: UInt128\n", 1)
+	report, err = upgrade.CheckEmbedded("FlowEVMBridgeConfig", []byte(previous), bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	require.Len(t, report.Problems, 1)
+	assert.Contains(t, report.Problems[0].Message, "mismatching field `gasLimit`")
+	assert.NotZero(t, report.Problems[0].Line)
+
+	_, err = upgrade.CheckEmbedded("FlowEVMBridgeConfig", []byte("access(all) contract {"), bridgeEnv, coreEnv)
+	assert.NotNil(t, err)
+	_, err = upgrade.CheckEmbedded("CryptoPunks", current, bridgeEnv, coreEnv)
+	assert.NotNil(t, err)
+}
+
+func TestCheckConformanceChange(t *testing.T) {
+	bridgeEnv, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkEmulator)
+	require.Nil(t, err)
+
+	oldCode := `
+access(all) contract Foo {
+    access(all) struct interface Bar {}
+    access(all) struct Baz: Bar {
+        access(all) let value: UInt64
+        init() { self.value = 0 }
+    }
+}`
+	newCode := strings.Replace(oldCode, "struct Baz: Bar", "struct Baz", 1)
+
+	report, err := upgrade.Check("Foo", []byte(oldCode), []byte(newCode), bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	require.False(t, report.Valid())
+	assert.Contains(t, report.Problems[0].String(), "conformances")
+}
+
+// Returns a git repository in a temporary directory with one commit of the
+// given files of this repository
+func gitRepo(t *testing.T, paths ...string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	for _, path := range paths {
+		code, err := os.ReadFile(filepath.Join("..", path))
+		require.Nil(t, err)
+		require.Nil(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, path)), 0o755))
+		require.Nil(t, os.WriteFile(filepath.Join(dir, path), code, 0o644))
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "contracts"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.Nil(t, err, "git %v: %s", args, out)
+	}
+	return dir
+}
+
+func TestCheckAgainstGitRef(t *testing.T) {
+	bridgeEnv, coreEnv, err := bridge.NetworkEnvironment(bridge.NetworkMainnet)
+	require.Nil(t, err)
+	repo := gitRepo(t, configPath, "cadence/contracts/bridge/FlowEVMBridgeUtils.cdc")
+
+	// Contracts missing at the ref are new and skipped
+	reports, err := upgrade.CheckAgainstGitRef(repo, "HEAD", bridgeEnv, coreEnv)
+	require.Nil(t, err)
+	contracts := []string{}
+	for _, report := range reports {
+		contracts = append(contracts, report.Contract)
+		assert.True(t, report.Valid(), "%s: %v", report.Contract, report.Problems)
+	}
+	assert.ElementsMatch(t, []string{"FlowEVMBridgeConfig", "FlowEVMBridgeUtils"}, contracts)
+
+	_, err = upgrade.ReadGitRef(repo, "HEAD", "cadence/contracts/bridge/Missing.cdc")
+	assert.NotNil(t, err)
+	_, err = upgrade.ReadGitRef(repo, "missing-ref", configPath)
+	assert.ErrorContains(t, err, "Cannot read")
+}